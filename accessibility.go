@@ -0,0 +1,256 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"strings"
+)
+
+// Equipment is the type of station equipment an advisory is about.
+type Equipment int
+
+const (
+	// EquipmentNone is for advisories that aren't about equipment.
+	EquipmentNone Equipment = iota
+
+	// EquipmentElevator is for elevator outages.
+	EquipmentElevator
+
+	// EquipmentEscalator is for escalator outages.
+	EquipmentEscalator
+)
+
+func (e Equipment) String() string {
+	switch e {
+	case EquipmentElevator:
+		return "elevator"
+	case EquipmentEscalator:
+		return "escalator"
+	default:
+		return "none"
+	}
+}
+
+// Equipment returns the type of equipment the advisory is about. It's
+// based on the advisory's type, falling back to the first equipment
+// mentioned in the description.
+func (a Advisory) Equipment() Equipment {
+	switch strings.ToUpper(strings.TrimSpace(a.Type)) {
+	case "ELEVATOR":
+		return EquipmentElevator
+	case "ESCALATOR":
+		return EquipmentEscalator
+	}
+
+	desc := strings.ToLower(a.Description)
+	elev, esc := strings.Index(desc, "elevator"), strings.Index(desc, "escalator")
+
+	switch {
+	case elev >= 0 && (esc < 0 || elev < esc):
+		return EquipmentElevator
+	case esc >= 0:
+		return EquipmentEscalator
+	default:
+		return EquipmentNone
+	}
+}
+
+// noElevatorOutages is the lowercased description, after "There are", of
+// the advisory in the elevator status when every elevator is working.
+const noElevatorOutages = "no elevators out of service"
+
+// GetElevatorStatus returns the current elevator advisories. When every
+// elevator is working BART returns an advisory saying so, rather than none,
+// which is removed so that Advisories is empty.
+func (c *Client) GetElevatorStatus(ctx context.Context, opts ...Option) (*AdvisoriesResponse, error) {
+	r := &AdvisoriesResponse{}
+
+	if err := c.get(ctx, c.advisory, "elev", nil, r, opts...); err != nil {
+		return nil, err
+	}
+
+	outages := r.Advisories[:0]
+
+	for _, a := range r.Advisories {
+		if !noElevatorsOut(a) {
+			outages = append(outages, a)
+		}
+	}
+
+	r.Advisories = outages
+
+	return r, nil
+}
+
+// noElevatorsOut returns whether the advisory is BART's message that no
+// elevators are out of service.
+func noElevatorsOut(a Advisory) bool {
+	desc := strings.ToLower(strings.TrimSpace(a.Description))
+	desc = strings.TrimPrefix(desc, "there are ")
+
+	return strings.HasPrefix(desc, noElevatorOutages)
+}
+
+// AccessibilityStatus is the elevator and escalator outages, returned by
+// GetAccessibilityStatus. The affected station of each is its Station.
+type AccessibilityStatus struct {
+	Elevators  []Advisory
+	Escalators []Advisory
+}
+
+// GetAccessibilityStatus returns the current elevator and escalator
+// outages. BART has no command for escalators, and sometimes reports them
+// as service advisories, so both the elevator status and the advisories are
+// fetched and the outages are separated using Advisory.Equipment.
+//
+// If either request fails a MultiError keyed by the command ("elev" or
+// "bsa") is returned, along with the outages from the other request.
+func (c *Client) GetAccessibilityStatus(ctx context.Context) (*AccessibilityStatus, error) {
+	elev, bsa, err := c.accessibilityAdvisories(ctx)
+
+	s := &AccessibilityStatus{}
+	seen := make(map[string]bool)
+
+	for _, r := range []*AdvisoriesResponse{elev, bsa} {
+		if r == nil {
+			continue
+		}
+
+		for _, a := range r.Advisories {
+			// an outage may be reported by both commands
+			key := a.Station + "\x00" + a.Description
+
+			if seen[key] {
+				continue
+			}
+
+			switch a.Equipment() {
+			case EquipmentElevator:
+				s.Elevators = append(s.Elevators, a)
+			case EquipmentEscalator:
+				s.Escalators = append(s.Escalators, a)
+			default:
+				continue
+			}
+
+			seen[key] = true
+		}
+	}
+
+	return s, err
+}
+
+// accessibilityAdvisories concurrently fetches the elevator status and
+// the advisories. See GetAccessibilityStatus for the errors.
+func (c *Client) accessibilityAdvisories(ctx context.Context) (elev, bsa *AdvisoriesResponse, err error) {
+	err = forEach([]string{"elev", "bsa"}, 2, func(cmd string) error {
+		var err error
+
+		if cmd == "elev" {
+			elev, err = c.GetElevatorStatus(ctx)
+		} else {
+			bsa, err = c.GetAdvisories(ctx)
+		}
+
+		return err
+	})
+
+	return elev, bsa, err
+}
+
+// accessTerms are the words that make an advisory that isn't about
+// equipment relevant to accessibility.
+var accessTerms = []string{"accessib", "wheelchair", "mobility", "ramp", "paratransit"}
+
+// AccessibilityAlert is a single thing affecting access to BART, like an
+// elevator outage, returned by GetAccessibilityFeed.
+type AccessibilityAlert struct {
+	// Equipment is the equipment that's out, or EquipmentNone for other
+	// problems, like a wheelchair ramp that's closed.
+	Equipment Equipment
+
+	// Station is the abbreviation of the affected station, or
+	// SystemwideStation if it affects the whole system.
+	Station     string
+	Description string
+
+	// Source is the command the alert was reported by, "elev" or "bsa".
+	// Alerts reported by both are only in the feed once, from "elev".
+	Source string
+
+	Advisory Advisory
+}
+
+// GetAccessibilityFeed returns everything currently affecting access to
+// stations in a single list: the elevator and escalator outages, like
+// GetAccessibilityStatus, along with the advisories about accessibility,
+// like ones mentioning wheelchairs or ramps. The alerts from the elevator
+// status come first, and each list keeps the order BART returned it in.
+//
+// If either request fails a MultiError keyed by the command ("elev" or
+// "bsa") is returned, along with the alerts from the other request.
+func (c *Client) GetAccessibilityFeed(ctx context.Context) ([]AccessibilityAlert, error) {
+	elev, bsa, err := c.accessibilityAdvisories(ctx)
+
+	var alerts []AccessibilityAlert
+
+	seen := make(map[string]bool)
+
+	for _, src := range []struct {
+		cmd string
+		r   *AdvisoriesResponse
+	}{{"elev", elev}, {"bsa", bsa}} {
+		if src.r == nil {
+			continue
+		}
+
+		for _, a := range src.r.Advisories {
+			station := strings.ToUpper(strings.TrimSpace(a.Station))
+
+			if station == "" {
+				station = SystemwideStation
+			}
+
+			desc := strings.TrimSpace(a.Description)
+			key := station + "\x00" + desc
+
+			if seen[key] || AdvisoryStatus(a) == StatusNormal {
+				continue
+			}
+
+			eq := a.Equipment()
+
+			if eq == EquipmentNone && src.cmd == "bsa" && !accessRelevant(desc) {
+				continue
+			}
+
+			seen[key] = true
+
+			alerts = append(alerts, AccessibilityAlert{
+				Equipment:   eq,
+				Station:     station,
+				Description: desc,
+				Source:      src.cmd,
+				Advisory:    a,
+			})
+		}
+	}
+
+	return alerts, err
+}
+
+// accessRelevant returns whether the description mentions accessibility.
+func accessRelevant(desc string) bool {
+	desc = strings.ToLower(desc)
+
+	for _, t := range accessTerms {
+		if strings.Contains(desc, t) {
+			return true
+		}
+	}
+
+	return false
+}
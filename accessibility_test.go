@@ -0,0 +1,187 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestGetElevatorStatus(c *C) {
+	r, err := t.c.GetElevatorStatus(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(r.Advisories, HasLen, 2)
+	c.Check(r.Advisories[0].Station, Equals, "12TH")
+	c.Check(r.Advisories[0].Equipment(), Equals, bart.EquipmentElevator)
+}
+
+func (t *TestSuite) TestGetElevatorStatusNone(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") != "elev" {
+			fixtures.ServeHTTP(rw, req)
+			return
+		}
+
+		http.ServeFile(rw, req, "testdata/elev_none.xml")
+	})
+
+	r, err := t.c.GetElevatorStatus(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(r.Advisories, HasLen, 0)
+
+	s, err := t.c.GetAccessibilityStatus(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(s.Elevators, HasLen, 0)
+
+	alerts, err := t.c.GetAccessibilityFeed(context.Background())
+	c.Assert(err, IsNil)
+
+	for _, a := range alerts {
+		c.Check(a.Source, Not(Equals), "elev", Commentf("%+v", a))
+	}
+}
+
+func (*TestSuite) TestAdvisoryEquipment(c *C) {
+	tests := []struct {
+		a bart.Advisory
+		e bart.Equipment
+	}{
+		{bart.Advisory{Type: "ELEVATOR"}, bart.EquipmentElevator},
+		{bart.Advisory{Type: "escalator"}, bart.EquipmentEscalator},
+		{bart.Advisory{Type: "DELAY", Description: "The escalator at Powell is out of service."}, bart.EquipmentEscalator},
+		{bart.Advisory{Type: "DELAY", Description: "Elevator and escalator work at Civic Center."}, bart.EquipmentElevator},
+		{bart.Advisory{Type: "DELAY", Description: "Expect 5-minute delays."}, bart.EquipmentNone},
+	}
+
+	for _, tt := range tests {
+		c.Check(tt.a.Equipment(), Equals, tt.e, Commentf("%+v", tt.a))
+	}
+
+	c.Check(bart.EquipmentEscalator.String(), Equals, "escalator")
+}
+
+func (t *TestSuite) TestGetAccessibilityStatus(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") != "bsa" {
+			fixtures.ServeHTTP(rw, req)
+			return
+		}
+
+		rw.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<root>
+  <bsa id="1">
+    <station>POWL</station>
+    <type>DELAY</type>
+    <description><![CDATA[The escalator at the Market St. entrance of Powell St. is out of service.]]></description>
+  </bsa>
+  <bsa id="2">
+    <station>MCAR</station>
+    <type>DELAY</type>
+    <description><![CDATA[The platform elevator at MacArthur is out of service.]]></description>
+  </bsa>
+  <bsa id="3">
+    <station>BART</station>
+    <type>DELAY</type>
+    <description><![CDATA[Expect 5-minute delays systemwide.]]></description>
+  </bsa>
+  <message></message>
+</root>`))
+	})
+
+	s, err := t.c.GetAccessibilityStatus(context.Background())
+	c.Assert(err, IsNil)
+
+	// the MacArthur elevator is in both responses
+	c.Assert(s.Elevators, HasLen, 2)
+	c.Check(s.Elevators[0].Station, Equals, "12TH")
+	c.Check(s.Elevators[1].Station, Equals, "MCAR")
+
+	c.Assert(s.Escalators, HasLen, 1)
+	c.Check(s.Escalators[0].Station, Equals, "POWL")
+	c.Check(strings.Contains(s.Escalators[0].Description, "Market St."), Equals, true)
+}
+
+func (t *TestSuite) TestGetAccessibilityStatusErrors(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") == "bsa" {
+			http.Error(rw, "down", http.StatusInternalServerError)
+			return
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	s, err := t.c.GetAccessibilityStatus(context.Background())
+	c.Assert(err, FitsTypeOf, bart.MultiError{})
+	c.Check(err.(bart.MultiError)["bsa"], NotNil)
+	c.Check(s.Elevators, HasLen, 2)
+}
+
+func (t *TestSuite) TestGetAccessibilityFeed(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") != "bsa" {
+			fixtures.ServeHTTP(rw, req)
+			return
+		}
+
+		rw.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<root>
+  <bsa id="1">
+    <station>POWL</station>
+    <type>DELAY</type>
+    <description><![CDATA[The escalator at the Market St. entrance of Powell St. is out of service.]]></description>
+  </bsa>
+  <bsa id="2">
+    <station>MCAR</station>
+    <type>DELAY</type>
+    <description><![CDATA[The platform elevator at MacArthur is out of service.]]></description>
+  </bsa>
+  <bsa id="3">
+    <station>BART</station>
+    <type>DELAY</type>
+    <description><![CDATA[Expect 5-minute delays systemwide.]]></description>
+  </bsa>
+  <bsa id="4">
+    <station>dbrk</station>
+    <type>DELAY</type>
+    <description><![CDATA[The wheelchair ramp at the Shattuck Ave. entrance is closed.]]></description>
+  </bsa>
+  <message></message>
+</root>`))
+	})
+
+	alerts, err := t.c.GetAccessibilityFeed(context.Background())
+	c.Assert(err, IsNil)
+
+	var got []string
+
+	for _, a := range alerts {
+		got = append(got, a.Source+" "+a.Station+" "+a.Equipment.String())
+	}
+
+	// the MacArthur elevator is in both responses, and the delay
+	// isn't about accessibility
+	c.Check(got, DeepEquals, []string{
+		"elev 12TH elevator",
+		"elev MCAR elevator",
+		"bsa POWL escalator",
+		"bsa DBRK none",
+	})
+
+	c.Check(alerts[3].Description, Equals, "The wheelchair ramp at the Shattuck Ave. entrance is closed.")
+	c.Check(alerts[3].Advisory.ID, Equals, "4")
+}
@@ -0,0 +1,130 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// SystemwideStation is the station BART uses for advisories that affect
+// the whole system, rather than a single station.
+const SystemwideStation = "BART"
+
+// SMSLimit is the number of characters that fit in a single SMS message.
+const SMSLimit = 160
+
+// Advisory is a single BART Service Advisory.
+type Advisory struct {
+	ID          string `xml:"id,attr"`
+	Station     string `xml:"station"`
+	Type        string `xml:"type"`
+	Description string `xml:"description"`
+
+	// SMSText is a shortened version of the Description, written by BART
+	// to be sent as a text message. It may be empty.
+	SMSText string `xml:"sms_text"`
+
+	Posted  string `xml:"posted"`
+	Expires string `xml:"expires"`
+}
+
+// FitsSMS returns whether the advisory's SMS text, or its Description if
+// it doesn't have any, fits in a single SMS message.
+func (a Advisory) FitsSMS() bool {
+	return utf8.RuneCountInString(a.smsText()) <= SMSLimit
+}
+
+// TruncateForSMS returns the advisory's SMS text, or its Description if it
+// doesn't have any, shortened to fit in a single SMS message. Text that's
+// too long is cut at the last space that leaves room for a trailing "...".
+func (a Advisory) TruncateForSMS() string {
+	text := a.smsText()
+
+	if utf8.RuneCountInString(text) <= SMSLimit {
+		return text
+	}
+
+	const ellipsis = "..."
+
+	r := []rune(text)[:SMSLimit-len(ellipsis)]
+
+	if i := strings.LastIndexByte(string(r), ' '); i > 0 {
+		return strings.TrimRight(string(r)[:i], " ,;:.-") + ellipsis
+	}
+
+	return string(r) + ellipsis
+}
+
+func (a Advisory) smsText() string {
+	if text := strings.TrimSpace(a.SMSText); text != "" {
+		return text
+	}
+
+	return strings.TrimSpace(a.Description)
+}
+
+// AdvisoriesResponse is the response of the bsa command.
+type AdvisoriesResponse struct {
+	bartapi.Envelope
+	Meta
+	Date       string     `xml:"date"`
+	Time       string     `xml:"time"`
+	Advisories []Advisory `xml:"bsa"`
+}
+
+// TrainCountResponse is the response of the count command.
+type TrainCountResponse struct {
+	bartapi.Envelope
+	Meta
+	Date       string `xml:"date"`
+	Time       string `xml:"time"`
+	TrainCount int    `xml:"traincount"`
+}
+
+// GroupByStation returns the advisories keyed by their station.
+// Advisories without a station are systemwide, so they are grouped
+// under SystemwideStation. Within each station the advisories are
+// in the order of the response; they aren't sorted by Posted.
+func (r *AdvisoriesResponse) GroupByStation() map[string][]Advisory {
+	groups := make(map[string][]Advisory)
+
+	for _, a := range r.Advisories {
+		station := strings.ToUpper(strings.TrimSpace(a.Station))
+
+		if station == "" {
+			station = SystemwideStation
+		}
+
+		groups[station] = append(groups[station], a)
+	}
+
+	return groups
+}
+
+// GetAdvisories returns the current BART Service Advisories.
+func (c *Client) GetAdvisories(ctx context.Context, opts ...Option) (*AdvisoriesResponse, error) {
+	r := &AdvisoriesResponse{}
+
+	if err := c.get(ctx, c.advisory, "bsa", nil, r, opts...); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetTrainCount returns the number of trains currently active in the system.
+func (c *Client) GetTrainCount(ctx context.Context, opts ...Option) (*TrainCountResponse, error) {
+	r := &TrainCountResponse{}
+
+	if err := c.get(ctx, c.advisory, "count", nil, r, opts...); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/theckman/go-bart"
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestGetAdvisories(c *C) {
+	r, err := t.c.GetAdvisories(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(r.Date, Equals, "02/04/2019")
+	c.Assert(r.Advisories, HasLen, 4)
+
+	a := r.Advisories[0]
+	c.Check(a.ID, Equals, "134")
+	c.Check(a.Station, Equals, "BART")
+	c.Check(a.Type, Equals, "DELAY")
+	c.Check(a.Posted, Equals, "Mon Feb 04 2019 09:30 AM PST")
+	c.Check(a.SMSText, Equals, "10-min delay at WOAK in RICH, ANTC, BERY dirs due to equipment problem on a train.")
+}
+
+func (t *TestSuite) TestAdvisorySMS(c *C) {
+	a := bart.Advisory{
+		Description: "Platform 3 at MacArthur is closed for maintenance.",
+		SMSText:     "MCAR platform 3 closed for maintenance.",
+	}
+
+	c.Check(a.FitsSMS(), Equals, true)
+	c.Check(a.TruncateForSMS(), Equals, "MCAR platform 3 closed for maintenance.")
+
+	// the description is used without SMS text
+	a.SMSText = " "
+	c.Check(a.TruncateForSMS(), Equals, "Platform 3 at MacArthur is closed for maintenance.")
+
+	a.SMSText = strings.Repeat("delays systemwide, ", 10)
+	c.Check(a.FitsSMS(), Equals, false)
+
+	sms := a.TruncateForSMS()
+	c.Check(len(sms) <= bart.SMSLimit, Equals, true)
+	c.Check(strings.HasSuffix(sms, " delays systemwide..."), Equals, true)
+
+	// without spaces the text is cut at the limit
+	a.SMSText = strings.Repeat("x", 200)
+	c.Check(a.TruncateForSMS(), Equals, strings.Repeat("x", bart.SMSLimit-3)+"...")
+}
+
+func (t *TestSuite) TestGetTrainCount(c *C) {
+	r, err := t.c.GetTrainCount(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(r.TrainCount, Equals, 52)
+	c.Check(r.Time, Equals, "09:51:00 AM PST")
+}
+
+func (t *TestSuite) TestGroupByStation(c *C) {
+	r, err := t.c.GetAdvisories(context.Background())
+	c.Assert(err, IsNil)
+
+	g := r.GroupByStation()
+	c.Assert(g, HasLen, 2)
+
+	c.Assert(g[bart.SystemwideStation], HasLen, 2)
+	c.Check(g[bart.SystemwideStation][0].ID, Equals, "134")
+	c.Check(g[bart.SystemwideStation][1].ID, Equals, "136")
+
+	c.Assert(g["MCAR"], HasLen, 2)
+	c.Check(g["MCAR"][0].ID, Equals, "135")
+	c.Check(g["MCAR"][1].ID, Equals, "137")
+
+	empty := &bart.AdvisoriesResponse{}
+	c.Check(empty.GroupByStation(), HasLen, 0)
+}
+
+func (t *TestSuite) TestMessageSurfaced(c *C) {
+	srv := newFixtureServer()
+	defer srv.Close()
+
+	srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("key") == "badkey" {
+			rw.Write([]byte("<root><message><error><text>Invalid key</text></error></message></root>"))
+			return
+		}
+
+		rw.Write([]byte("<root><bsa><station/><description>No delays reported.</description></bsa><message><warning>Data may be delayed.</warning></message></root>"))
+	})
+
+	r, err := bart.NewWithBaseURL("testkey", srv.URL+"/").GetAdvisories(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(r.Advisories, HasLen, 1)
+
+	text, level := r.Inspect()
+	c.Check(text, Equals, "Data may be delayed.")
+	c.Check(level, Equals, bartapi.MessageWarning)
+
+	_, err = bart.NewWithBaseURL("badkey", srv.URL+"/").GetAdvisories(context.Background())
+	c.Assert(err, Not(IsNil))
+
+	apiErr, ok := err.(*bartapi.APIError)
+	c.Assert(ok, Equals, true)
+	c.Check(apiErr.Text, Equals, "Invalid key")
+}
+
+func (t *TestSuite) TestSetEntities(c *C) {
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<root>
+  <bsa id="1">
+    <station>BART</station>
+    <type>DELAY</type>
+    <description>Delays&nbsp;at 12th&nbsp;St. &ndash; &bartlogo; trains are holding.</description>
+  </bsa>
+</root>`))
+	})
+
+	_, err := t.c.GetAdvisories(context.Background())
+	c.Check(err, ErrorMatches, ".*invalid character entity &bartlogo;.*")
+
+	t.c.SetEntities(map[string]string{"bartlogo": "BART"})
+
+	r, err := t.c.GetAdvisories(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(r.Advisories, HasLen, 1)
+	c.Check(r.Advisories[0].Description, Equals, "Delays\u00a0at 12th\u00a0St. \u2013 BART trains are holding.")
+}
@@ -12,11 +12,16 @@ package bartapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"code.google.com/p/go-charset/charset"
 
@@ -64,10 +69,70 @@ const ScheduleEndpoint Endpoint = "http://api.bart.gov/api/sched.aspx"
 // neighborhood information.
 const StationEndpoint Endpoint = "http://api.bart.gov/api/stn.aspx"
 
+// Response is a raw API response, along with metadata about the request.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	// Latency is the time it took to make the HTTP request and read the
+	// response body.
+	Latency time.Duration
+}
+
+// ErrClosed is returned when making a request with a closed Client.
+var ErrClosed = errors.New("bartapi: client is closed")
+
+// MaxURLLength is the length of the longest request URL the client sends.
+// The API only supports GET requests, and some proxies and servers truncate,
+// or reject, URLs longer than about 2KB.
+const MaxURLLength = 2048
+
+// ErrURLTooLong is returned, without making a request, when the request
+// URL is longer than MaxURLLength.
+var ErrURLTooLong = fmt.Errorf("bartapi: request URL is longer than %d bytes", MaxURLLength)
+
+// ErrCommandNotAllowed is returned, without making a request, for commands
+// that aren't in the set passed to SetAllowedCommands.
+var ErrCommandNotAllowed = errors.New("bartapi: command not allowed")
+
+// Puller is the interface of the methods of Client that make requests. It's
+// for code that should also work with a test double, like the FakeClient in
+// the bartapitest package.
+type Puller interface {
+	Pull(cmd string, query map[string]string) ([]byte, error)
+	PullContext(ctx context.Context, cmd string, query map[string]string) ([]byte, error)
+	PullResponse(ctx context.Context, cmd string, query map[string]string) (*Response, error)
+}
+
+var _ Puller = (*Client)(nil)
+
 // Client is the BART API client
 type Client struct {
 	key string
 	url Endpoint
+
+	mu           sync.RWMutex
+	disclaimer   string
+	defaultQuery map[string]string
+	verifyURI    bool
+	limiter      Limiter
+	httpClient   *http.Client
+	closed       bool
+	retries      int
+	breaker      *Breaker
+	hooks        Hooks
+	timeout      time.Duration
+
+	quietPublicKey  bool
+	publicKeyWarned bool
+
+	allowedCmds map[string]bool
+	backoff     Backoff
+	middleware  []func(http.RoundTripper) http.RoundTripper
+	redirect    RedirectPolicy
+
+	acceptLanguage string
 }
 
 // New returns a new BART API client.
@@ -85,20 +150,324 @@ func (c *Client) Key() string {
 	return c.key
 }
 
+// SetDefaultQuery sets query params that are added to every request.
+// If a request's query map contains the same param, the per-request
+// value is used instead of the default. A nil map removes the defaults.
+func (c *Client) SetDefaultQuery(query map[string]string) {
+	q := make(map[string]string, len(query))
+
+	for k, v := range query {
+		q[k] = v
+	}
+
+	c.mu.Lock()
+	c.defaultQuery = q
+	c.mu.Unlock()
+}
+
+// SetVerifyURI enables, or disables, verification of the request URI
+// the API echoes back in the response envelope. When enabled, responses
+// whose echoed URI doesn't match the request's cmd and query params result
+// in an error wrapping ErrURIMismatch. This is useful for debugging proxies
+// that mangle the params, but it's off by default as differences in URL
+// normalization can cause false positives.
+func (c *Client) SetVerifyURI(verify bool) {
+	c.mu.Lock()
+	c.verifyURI = verify
+	c.mu.Unlock()
+}
+
+// SetHTTPClient sets the *http.Client used to make requests, allowing
+// the transport and timeouts to be customized. A nil client resets it
+// to http.DefaultClient, which is the default.
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.mu.Lock()
+	c.httpClient = hc
+	c.mu.Unlock()
+}
+
+// Close releases the resources held by the client, closing any idle
+// connections of the *http.Client set using SetHTTPClient. The client
+// is unusable after it's closed: requests return ErrClosed.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	hc := c.httpClient
+	c.closed = true
+	c.mu.Unlock()
+
+	if hc != nil {
+		hc.CloseIdleConnections()
+	}
+
+	return nil
+}
+
+// SetTimeout sets the default timeout of requests, which can be overridden
+// for a single request using PullTimeout. It covers the whole request,
+// including any retries. Zero means that there's no timeout, which is the
+// default, though one may still be set on the *http.Client.
+func (c *Client) SetTimeout(d time.Duration) {
+	c.mu.Lock()
+	c.timeout = d
+	c.mu.Unlock()
+}
+
+// SetAcceptLanguage sets the Accept-Language header sent with requests,
+// like "es" or "zh-Hant, en;q=0.5", asking for localized text. BART only
+// returns English today and ignores the header, but sending it means the
+// text will be localized if that changes. An empty tag, the default,
+// doesn't send the header.
+func (c *Client) SetAcceptLanguage(tag string) {
+	c.mu.Lock()
+	c.acceptLanguage = strings.TrimSpace(tag)
+	c.mu.Unlock()
+}
+
+// SetAllowedCommands limits the commands the client makes requests for to
+// cmds, such as for a proxy that only exposes some of them. Requests for
+// other commands fail with ErrCommandNotAllowed. The commands are matched
+// case-insensitively. No commands, the default, allows all of them.
+func (c *Client) SetAllowedCommands(cmds ...string) {
+	var allowed map[string]bool
+
+	if len(cmds) > 0 {
+		allowed = make(map[string]bool, len(cmds))
+
+		for _, cmd := range cmds {
+			allowed[strings.ToLower(strings.TrimSpace(cmd))] = true
+		}
+	}
+
+	c.mu.Lock()
+	c.allowedCmds = allowed
+	c.mu.Unlock()
+}
+
+// SetRetries sets how many times a failed request is retried. Requests are
+// retried after transient network errors, like timeouts and connection
+// resets, truncated responses, server errors (5xx), and when the API rate
+// limits the client. Other errors, like a canceled context, aren't retried.
+// Between attempts the client waits the time the API
+// asked for using the Retry-After header, or uses the Backoff set with
+// SetBackoff if it didn't. The default is 0, which disables retries.
+func (c *Client) SetRetries(n int) {
+	if n < 0 {
+		n = 0
+	}
+
+	c.mu.Lock()
+	c.retries = n
+	c.mu.Unlock()
+}
+
+// SetBackoff sets the Backoff used to wait between retries, such as one
+// returned by Exponential or DecorrelatedJitter. When the API asks the
+// client to wait using the Retry-After header that's used instead. Nil
+// restores the default, which is exponential starting at 100ms, up to 10s.
+func (c *Client) SetBackoff(b Backoff) {
+	c.mu.Lock()
+	c.backoff = b
+	c.mu.Unlock()
+}
+
+// SetBreaker sets the Breaker used to stop making requests while the API
+// is failing. A nil Breaker disables it, which is the default.
+func (c *Client) SetBreaker(b *Breaker) {
+	c.mu.Lock()
+	c.breaker = b
+	c.mu.Unlock()
+}
+
+// SetLimiter sets the Limiter used to rate limit requests.
+// A nil Limiter disables rate limiting, which is the default.
+func (c *Client) SetLimiter(l Limiter) {
+	c.mu.Lock()
+	c.limiter = l
+	c.mu.Unlock()
+}
+
+// Disclaimer returns the copyright and legal disclaimer text from the
+// response envelope. The text is static, so it's cached from the first
+// response that includes it. An empty string is returned if no response
+// containing it has been pulled yet.
+func (c *Client) Disclaimer() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.disclaimer
+}
+
+// cacheDisclaimer stores the disclaimer from the body's envelope,
+// if one hasn't been stored yet.
+func (c *Client) cacheDisclaimer(body []byte) {
+	if c.Disclaimer() != "" {
+		return
+	}
+
+	e, err := DecodeEnvelope(body)
+
+	if err != nil {
+		return
+	}
+
+	if d := e.Disclaimer(); d != "" {
+		c.mu.Lock()
+		c.disclaimer = d
+		c.mu.Unlock()
+	}
+}
+
 // Pull does an HTTP GET request against the API endpoint.
 // You need to provide the command (cmd) to send the API.
 // You can add more query params using the "query" map
 // if you need to, otherwise use nil.
 func (c *Client) Pull(cmd string, query map[string]string) ([]byte, error) {
-	var params bytes.Buffer
+	return c.PullContext(context.Background(), cmd, query)
+}
 
-	params.WriteString(fmt.Sprintf("%v?cmd=%v&key=%v", string(c.url), cmd, c.key))
+// PullContext is the same as Pull, except that the request is
+// bound to the provided context.
+func (c *Client) PullContext(ctx context.Context, cmd string, query map[string]string) ([]byte, error) {
+	resp, err := c.PullResponse(ctx, cmd, query)
 
-	for k, v := range query {
-		params.WriteString(fmt.Sprintf("&%v=%v", k, v))
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// PullResponse is the same as PullContext, except that it returns
+// the body as part of a Response.
+//
+// If retries are enabled using SetRetries, failed requests are retried
+// before an error is returned. The Latency of the Response is that of the
+// final, successful, attempt.
+func (c *Client) PullResponse(ctx context.Context, cmd string, query map[string]string) (*Response, error) {
+	c.mu.RLock()
+	timeout := c.timeout
+	c.mu.RUnlock()
+
+	return c.pull(ctx, cmd, query, timeout)
+}
+
+// PullTimeout is the same as PullResponse, except that the timeout set using
+// SetTimeout is overridden by timeout for this request. Zero means that
+// there's no timeout. The request is still bound to the context, so the
+// shorter of its deadline and the timeout wins.
+func (c *Client) PullTimeout(ctx context.Context, cmd string, query map[string]string, timeout time.Duration) (*Response, error) {
+	return c.pull(ctx, cmd, query, timeout)
+}
+
+func (c *Client) pull(ctx context.Context, cmd string, query map[string]string, timeout time.Duration) (*Response, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	params, final := c.buildURL(cmd, query)
+
+	c.mu.RLock()
+	limiter, hc, closed, retries, breaker, hooks := c.limiter, c.httpClient, c.closed, c.retries, c.breaker, c.hooks
+	backoff, middleware, redirect := c.backoff, c.middleware, c.redirect
+	allowed := c.allowedCmds == nil || c.allowedCmds[strings.ToLower(cmd)]
+	c.mu.RUnlock()
+
+	if closed {
+		return nil, ErrClosed
+	}
+
+	if !allowed {
+		return nil, fmt.Errorf("%w: %s", ErrCommandNotAllowed, cmd)
+	}
+
+	if len(params) > MaxURLLength {
+		return nil, ErrURLTooLong
+	}
+
+	if hc == nil {
+		hc = http.DefaultClient
 	}
 
-	resp, err := http.Get(params.String())
+	hc = withRedirectPolicy(withMiddleware(hc, middleware), redirect)
+
+	c.warnPublicKey(ctx, hooks)
+
+	var resp *Response
+	var err error
+
+	for attempt := 0; ; attempt++ {
+		// wait for the limiter first, as a half-open breaker's trial is
+		// only given back by record
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		if breaker != nil {
+			if err := breaker.allow(); err != nil {
+				return nil, err
+			}
+		}
+
+		if hooks.Request != nil {
+			hooks.Request(ctx, c.requestInfo(cmd, final, attempt))
+		}
+
+		resp, err = c.do(ctx, hc, params)
+
+		if breaker != nil {
+			breaker.record(ctx, resp, err)
+		}
+
+		if attempt >= retries || !retryable(ctx, resp, err) {
+			break
+		}
+
+		if err := sleep(ctx, retryDelay(attempt, err, backoff)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.cacheDisclaimer(resp.Body)
+
+	c.mu.RLock()
+	verify := c.verifyURI
+	c.mu.RUnlock()
+
+	if verify {
+		e, err := DecodeEnvelope(resp.Body)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := verifyURI(params, e.URI); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// do makes a single request for the URL u. If the API responds that the
+// request was rate limited, a *RateLimitError is returned along with the
+// response.
+func (c *Client) do(ctx context.Context, hc *http.Client, u string) (*Response, error) {
+	req, err := c.newRequest(ctx, u)
+
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+
+	resp, err := hc.Do(req)
 
 	if err != nil {
 		return nil, err
@@ -108,19 +477,146 @@ func (c *Client) Pull(cmd string, query map[string]string) ([]byte, error) {
 
 	body, err := ioutil.ReadAll(resp.Body)
 
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w: %v", ErrTruncatedResponse, err)
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
-	return body, nil
+	r := &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		Latency:    time.Since(start),
+	}
+
+	if rateLimited(r) {
+		return r, &RateLimitError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	}
+
+	if r.StatusCode < 300 && truncated(body) {
+		return nil, ErrTruncatedResponse
+	}
+
+	return r, nil
+}
+
+// PullReader is for passing a response through to another consumer, like
+// the client of a proxy, without decoding it. The status code and the
+// response envelope are validated first, so a *StatusError or
+// *APIError is returned instead of a reader for failed requests. The
+// body is decompressed if the server used a Content-Encoding supported
+// by the net/http package. The caller must close the returned reader.
+func (c *Client) PullReader(ctx context.Context, cmd string, query map[string]string) (io.ReadCloser, error) {
+	resp, err := c.PullResponse(ctx, cmd, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	e, err := DecodeEnvelope(resp.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.Err(); err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(resp.Body)), nil
+}
+
+// StatusError is returned when the API responds with
+// an unexpected HTTP status code.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("bartapi: unexpected HTTP status: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
 }
 
 // Decode is a function to help with decoding the XML provided by BART.
 // Because of their encoding format, we need to set the CharsetReader in
 // this function. r is the data to parse, and v is data structure
 // to parse it in to.
+//
+// Elements that repeat, like each <station> within <stations>, need to be
+// decoded in to a slice field tagged with the full path from the root
+// (e.g., `xml:"stations>station"`). A non-slice field only keeps the last
+// element. The types in the bart package are set up this way, and can be
+// used as a reference or decoded in to directly.
+//
+// Documents that are UTF-8, which are most of BART's responses, skip the
+// CharsetReader. A UTF-8 byte order mark is also supported. HTML entities,
+// like &nbsp;, are replaced the same as the XML ones. Use DecodeWithOptions
+// to add others.
+//
+// If r ends before the XML document does, ErrTruncatedResponse is returned.
 func Decode(r io.Reader, v interface{}) error {
+	return decode(newUTF8Decoder(r), v)
+}
+
+// decode decodes the document read by d in to v, returning
+// ErrTruncatedResponse if it ends early.
+func decode(d *xml.Decoder, v interface{}) error {
+	if err := d.Decode(v); err != nil {
+		if isUnexpectedEOF(err) {
+			return fmt.Errorf("%w: %v", ErrTruncatedResponse, err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// DecodeWithCharset is the same as Decode, except that r is decoded from the
+// named charset, like "ISO-8859-1", regardless of the one declared by the XML
+// document. This is for when the declaration is missing or wrong. Decode
+// should be used otherwise, as it uses the declared charset.
+func DecodeWithCharset(r io.Reader, v interface{}, charsetName string) error {
+	cr, err := charset.NewReader(charsetName, r)
+
+	if err != nil {
+		return fmt.Errorf("bartapi: charset %q: %w", charsetName, err)
+	}
+
+	d := xml.NewDecoder(cr)
+	d.Entity = xml.HTMLEntity
+
+	// the input has already been converted to UTF-8,
+	// so the declaration is ignored
+	d.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	return decode(d, v)
+}
+
+// NewDecoder returns an *xml.Decoder reading from r, for callers that need
+// to change its settings before decoding. Its CharsetReader is set to one
+// which supports the non-UTF-8 encodings BART declares, like ISO-8859-1,
+// and its Entity map to xml.HTMLEntity, as HTML entities like &nbsp; turn
+// up in the text of some responses. The other settings are the xml
+// package's defaults: Strict is true, and there is no AutoClose.
+//
+// It differs from the decoder used by Decode in two ways. Decode reads
+// UTF-8 documents, going by their byte order mark or declaration, as is,
+// without the CharsetReader; the xml package still rejects invalid UTF-8
+// in them, as it does with this decoder. And Decode returns
+// ErrTruncatedResponse for a document that ends early, where this decoder
+// returns the xml package's io.ErrUnexpectedEOF or *xml.SyntaxError.
+func NewDecoder(r io.Reader) *xml.Decoder {
 	d := xml.NewDecoder(r)
 	d.CharsetReader = charset.NewReader
-	return d.Decode(v)
+	d.Entity = xml.HTMLEntity
+	return d
 }
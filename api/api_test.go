@@ -6,11 +6,14 @@ package bartapi_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/theckman/go-bart/api"
@@ -91,6 +94,116 @@ func (t *TestSuite) TestPull(c *C) {
 	c.Check((j["salad"]).(string), Equals, "bad")
 }
 
+func (t *TestSuite) TestSetDefaultQuery(c *C) {
+	t.c.SetDefaultQuery(map[string]string{"bacon": "good", "tag": "test"})
+
+	resp, err := t.c.Pull("test", map[string]string{"bacon": "great"})
+	c.Assert(err, IsNil)
+
+	var j map[string]interface{}
+
+	err = json.Unmarshal(resp, &j)
+	c.Assert(err, IsNil)
+	c.Check((j["cmd"]).(string), Equals, "test")
+	c.Check((j["bacon"]).(string), Equals, "great")
+	c.Check((j["tag"]).(string), Equals, "test")
+
+	t.c.SetDefaultQuery(nil)
+
+	resp, err = t.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+
+	j = make(map[string]interface{})
+
+	err = json.Unmarshal(resp, &j)
+	c.Assert(err, IsNil)
+	_, ok := j["tag"]
+	c.Check(ok, Equals, false)
+}
+
+func (t *TestSuite) TestPullContext(c *C) {
+	resp, err := t.c.PullContext(context.Background(), "test", nil)
+	c.Assert(err, IsNil)
+
+	var j map[string]interface{}
+
+	err = json.Unmarshal(resp, &j)
+	c.Assert(err, IsNil)
+	c.Check((j["cmd"]).(string), Equals, "test")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = t.c.PullContext(ctx, "test", nil)
+	c.Check(err, Not(IsNil))
+}
+
+func (t *TestSuite) TestPullResponse(c *C) {
+	resp, err := t.c.PullResponse(context.Background(), "test", nil)
+	c.Assert(err, IsNil)
+	c.Check(resp.Latency > 0, Equals, true)
+
+	var j map[string]interface{}
+
+	err = json.Unmarshal(resp.Body, &j)
+	c.Assert(err, IsNil)
+	c.Check((j["cmd"]).(string), Equals, "test")
+}
+
+func (t *TestSuite) TestSetHTTPClient(c *C) {
+	var used bool
+
+	hc := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		used = true
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	t.c.SetHTTPClient(hc)
+
+	_, err := t.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(used, Equals, true)
+}
+
+func (t *TestSuite) TestClose(c *C) {
+	t.c.SetHTTPClient(&http.Client{Transport: &http.Transport{}})
+
+	_, err := t.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+
+	c.Assert(t.c.Close(), IsNil)
+
+	_, err = t.c.Pull("test", nil)
+	c.Check(err, Equals, bartapi.ErrClosed)
+}
+
+func (t *TestSuite) TestURLTooLong(c *C) {
+	long := strings.Repeat("a", bartapi.MaxURLLength)
+
+	_, err := t.c.Pull("test", map[string]string{"long": long})
+	c.Check(err, Equals, bartapi.ErrURLTooLong)
+	c.Check(err, ErrorMatches, "bartapi: request URL is longer than 2048 bytes")
+
+	_, err = t.c.Pull("test", map[string]string{"long": long[:1024]})
+	c.Check(err, IsNil)
+}
+
+func (t *TestSuite) TestSetAllowedCommands(c *C) {
+	t.c.SetAllowedCommands("stns", "STNINFO")
+
+	_, err := t.c.Pull("stninfo", nil)
+	c.Check(err, IsNil)
+
+	_, err = t.c.Pull("etd", nil)
+	c.Check(errors.Is(err, bartapi.ErrCommandNotAllowed), Equals, true)
+	c.Check(err, ErrorMatches, "bartapi: command not allowed: etd")
+
+	t.c.SetAllowedCommands()
+
+	_, err = t.c.Pull("etd", nil)
+	c.Check(err, IsNil)
+}
+
 func (t *TestSuite) TestDecode(c *C) {
 	r := bytes.NewReader([]byte(exampleXml))
 	x := &xmlType{}
@@ -106,6 +219,10 @@ func (t *TestSuite) TestDecode(c *C) {
 	c.Assert(err, Not(IsNil))
 }
 
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
 type handler struct{}
 
 func (*handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
@@ -127,3 +244,71 @@ func (*handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 
 	fmt.Fprintf(rw, string(resp))
 }
+
+func (*TestSuite) TestNewDecoder(c *C) {
+	d := bartapi.NewDecoder(strings.NewReader(`<?xml version="1.0" encoding="ISO-8859-1"?><root><somekey>caf&eacute;</somekey></root>`))
+	c.Check(d.Strict, Equals, true)
+
+	x := &xmlType{}
+	c.Assert(d.Decode(x), IsNil)
+	c.Check(x.Some, Equals, "café")
+
+	// the caller can change the other settings
+	d = bartapi.NewDecoder(strings.NewReader(`<root><somekey>&bart;</somekey></root>`))
+	d.Entity = map[string]string{"bart": "Bay Area Rapid Transit"}
+
+	x = &xmlType{}
+	c.Assert(d.Decode(x), IsNil)
+	c.Check(x.Some, Equals, "Bay Area Rapid Transit")
+}
+
+func (*TestSuite) TestDecodeEntities(c *C) {
+	const body = `<root><somekey>Service&nbsp;resumed &mdash; &bart;</somekey></root>`
+
+	// the HTML entities are known by default
+	x := &xmlType{}
+	c.Assert(bartapi.Decode(strings.NewReader(`<root><somekey>a&nbsp;&mdash;&nbsp;b</somekey></root>`), x), IsNil)
+	c.Check(x.Some, Equals, "a\u00a0\u2014\u00a0b")
+
+	err := bartapi.Decode(strings.NewReader(body), &xmlType{})
+	c.Check(err, ErrorMatches, ".*invalid character entity &bart;.*")
+
+	x = &xmlType{}
+	err = bartapi.DecodeWithOptions(strings.NewReader(body), x, bartapi.DecodeOptions{
+		Entity: map[string]string{"bart": "BART", "mdash": "-"},
+	})
+	c.Assert(err, IsNil)
+	c.Check(x.Some, Equals, "Service\u00a0resumed - BART")
+}
+
+func (*TestSuite) TestDecodeTruncated(c *C) {
+	err := bartapi.Decode(strings.NewReader(`<root><somekey>hel`), &xmlType{})
+	c.Check(errors.Is(err, bartapi.ErrTruncatedResponse), Equals, true)
+
+	// other errors aren't truncation
+	err = bartapi.Decode(strings.NewReader(`<root><somekey></root>`), &xmlType{})
+	c.Check(err, NotNil)
+	c.Check(errors.Is(err, bartapi.ErrTruncatedResponse), Equals, false)
+}
+
+func (*TestSuite) TestDecodeWithCharset(c *C) {
+	const body = `<?xml version="1.0" encoding="not-a-charset"?><root><somekey>hello!</somekey></root>`
+
+	// the declaration is wrong, so Decode fails
+	c.Check(bartapi.Decode(strings.NewReader(body), &xmlType{}), NotNil)
+
+	x := &xmlType{}
+	c.Assert(bartapi.DecodeWithCharset(strings.NewReader(body), x, "UTF-8"), IsNil)
+	c.Check(x.Some, Equals, "hello!")
+
+	// a missing declaration
+	x = &xmlType{}
+	c.Assert(bartapi.DecodeWithCharset(strings.NewReader(exampleXml), x, "ISO-8859-1"), IsNil)
+	c.Check(x.Some, Equals, "hello!")
+
+	err := bartapi.DecodeWithCharset(strings.NewReader(body), &xmlType{}, "not-a-charset")
+	c.Check(err, ErrorMatches, `bartapi: charset "not-a-charset": .*`)
+
+	err = bartapi.DecodeWithCharset(strings.NewReader(`<root><somekey>hel`), &xmlType{}, "UTF-8")
+	c.Check(errors.Is(err, bartapi.ErrTruncatedResponse), Equals, true)
+}
@@ -0,0 +1,191 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+// Package bartapitest provides a test double of the bartapi.Client, for
+// testing how code using the API handles its errors and latency, and
+// helpers for testing the types responses are decoded in to.
+package bartapitest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// FakeClient is a bartapi.Puller that responds to each command with the
+// body, error, and delay set for it, without making any requests. Bodies
+// are returned as is, so they can be malformed XML. The zero value is
+// ready to use, and responds to every command with an error.
+//
+// It's also an http.RoundTripper, so it can be the transport of the real
+// clients: see RoundTrip.
+type FakeClient struct {
+	mu        sync.Mutex
+	responses map[string][]byte
+	errs      map[string]error
+	delays    map[string]time.Duration
+	calls     map[string]int
+	failures  map[string]failure
+}
+
+// failure is the status code the next n requests for a command fail with.
+type failure struct {
+	n      int
+	status int
+}
+
+var _ bartapi.Puller = (*FakeClient)(nil)
+
+// NewFakeClient returns a new FakeClient.
+func NewFakeClient() *FakeClient {
+	return &FakeClient{}
+}
+
+// SetResponse sets the body returned for cmd.
+func (f *FakeClient) SetResponse(cmd string, body []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.responses == nil {
+		f.responses = make(map[string][]byte)
+	}
+
+	f.responses[cmd] = body
+}
+
+// SetError sets the error returned for cmd, which takes precedence over
+// its response. A nil error removes it.
+func (f *FakeClient) SetError(cmd string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.errs == nil {
+		f.errs = make(map[string]error)
+	}
+
+	if err == nil {
+		delete(f.errs, cmd)
+		return
+	}
+
+	f.errs[cmd] = err
+}
+
+// SetDelay sets how long requests for cmd take, before the response or
+// error is returned. If the context is done first its error is returned.
+func (f *FakeClient) SetDelay(cmd string, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.delays == nil {
+		f.delays = make(map[string]time.Duration)
+	}
+
+	f.delays[cmd] = d
+}
+
+// SetFailures makes the next n requests for cmd fail with the HTTP status
+// code, like 503, before its response or error is used again. Along with
+// RoundTrip this is for testing retries and the circuit breaker.
+func (f *FakeClient) SetFailures(cmd string, n, status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failures == nil {
+		f.failures = make(map[string]failure)
+	}
+
+	f.failures[cmd] = failure{n: n, status: status}
+}
+
+// Calls returns how many requests have been made for cmd.
+func (f *FakeClient) Calls(cmd string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[cmd]
+}
+
+// Pull satisfies the bartapi.Puller interface.
+func (f *FakeClient) Pull(cmd string, query map[string]string) ([]byte, error) {
+	return f.PullContext(context.Background(), cmd, query)
+}
+
+// PullContext satisfies the bartapi.Puller interface.
+func (f *FakeClient) PullContext(ctx context.Context, cmd string, query map[string]string) ([]byte, error) {
+	resp, err := f.PullResponse(ctx, cmd, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// PullResponse satisfies the bartapi.Puller interface. The response has a
+// 200 status code, unless one was set using SetFailures, and its latency is
+// the delay set for the command. Errors are returned as is, without any
+// retries; use the fake as a transport, see RoundTrip, to exercise them.
+func (f *FakeClient) PullResponse(ctx context.Context, cmd string, query map[string]string) (*bartapi.Response, error) {
+	status, body, delay, err := f.respond(ctx, cmd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &bartapi.Response{StatusCode: status, Body: body, Latency: delay}, nil
+}
+
+// respond counts a request for cmd, waits for its delay, and returns the
+// status code and body of its response, or its error.
+func (f *FakeClient) respond(ctx context.Context, cmd string) (int, []byte, time.Duration, error) {
+	f.mu.Lock()
+
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+
+	f.calls[cmd]++
+
+	body, ok := f.responses[cmd]
+	err, delay := f.errs[cmd], f.delays[cmd]
+
+	status := http.StatusOK
+
+	if fail := f.failures[cmd]; fail.n > 0 {
+		fail.n--
+		f.failures[cmd] = fail
+		status = fail.status
+	}
+
+	f.mu.Unlock()
+
+	if delay > 0 {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+
+		select {
+		case <-ctx.Done():
+			return 0, nil, 0, ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	if err != nil {
+		return 0, nil, 0, err
+	}
+
+	if status != http.StatusOK {
+		return status, nil, delay, nil
+	}
+
+	if !ok {
+		return 0, nil, 0, fmt.Errorf("bartapitest: no response set for %q", cmd)
+	}
+
+	return status, body, delay, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapitest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+	"github.com/theckman/go-bart/api/bartapitest"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type TestSuite struct{}
+
+var _ = Suite(&TestSuite{})
+
+func (*TestSuite) TestFakeClient(c *C) {
+	f := bartapitest.NewFakeClient()
+	f.SetResponse("etd", []byte("<root><message /></root>"))
+
+	body, err := f.Pull("etd", nil)
+	c.Assert(err, IsNil)
+	c.Check(string(body), Equals, "<root><message /></root>")
+
+	_, err = f.Pull("bsa", nil)
+	c.Check(err, ErrorMatches, `bartapitest: no response set for "bsa"`)
+
+	f.SetError("etd", bartapi.ErrRateLimited)
+
+	_, err = f.Pull("etd", nil)
+	c.Check(err, Equals, bartapi.ErrRateLimited)
+
+	f.SetError("etd", nil)
+
+	_, err = f.Pull("etd", nil)
+	c.Check(err, IsNil)
+
+	c.Check(f.Calls("etd"), Equals, 3)
+	c.Check(f.Calls("bsa"), Equals, 1)
+}
+
+func (*TestSuite) TestFakeClientDelay(c *C) {
+	f := bartapitest.NewFakeClient()
+	f.SetResponse("etd", []byte("<root />"))
+	f.SetDelay("etd", 20*time.Millisecond)
+
+	resp, err := f.PullResponse(context.Background(), "etd", nil)
+	c.Assert(err, IsNil)
+	c.Check(resp.Latency, Equals, 20*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	_, err = f.PullContext(ctx, "etd", nil)
+	c.Check(err, Equals, context.DeadlineExceeded)
+}
@@ -0,0 +1,123 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapitest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// RoundTripXML decodes src in to v, which must be a pointer, and then
+// re-encodes v, to check that the struct captures all of the data in a
+// response. The diff lists the element and attribute paths that have a
+// value in src but are missing from the re-encoded XML, one per line like
+// "- station/etd/estimate/@extra", relative to the root element. An empty
+// diff means nothing was dropped.
+//
+// Only the paths are compared, not the values, as the types normalize
+// some values (e.g., "Leaving" is decoded as zero minutes). Types that
+// implement xml.Unmarshaler without also implementing xml.Marshaler are
+// re-encoded with their default encoding, so their paths may be listed
+// even though nothing was dropped.
+func RoundTripXML(src []byte, v interface{}) (diff string, err error) {
+	if err := bartapi.DecodeWithOptions(bytes.NewReader(src), v, bartapi.DecodeOptions{}); err != nil {
+		return "", err
+	}
+
+	out, err := xml.Marshal(v)
+
+	if err != nil {
+		return "", err
+	}
+
+	want, err := xmlPaths(src)
+
+	if err != nil {
+		return "", err
+	}
+
+	got, err := xmlPaths(out)
+
+	if err != nil {
+		return "", err
+	}
+
+	var dropped []string
+
+	for p := range want {
+		if !got[p] {
+			dropped = append(dropped, "- "+p)
+		}
+	}
+
+	sort.Strings(dropped)
+
+	return strings.Join(dropped, "\n"), nil
+}
+
+// xmlPaths returns the paths of the elements with text, and attributes
+// with values, in the document. They're relative to the root element,
+// which is skipped as the types don't always name it.
+func xmlPaths(doc []byte) (map[string]bool, error) {
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+
+	paths := make(map[string]bool)
+
+	var stack []string
+
+	// text is whether each open element has text
+	var text []bool
+
+	for {
+		tok, err := d.Token()
+
+		if err == io.EOF {
+			return paths, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, tok.Name.Local)
+			text = append(text, false)
+
+			for _, a := range tok.Attr {
+				if strings.TrimSpace(a.Value) != "" {
+					paths[joinPath(stack[1:], "@"+a.Name.Local)] = true
+				}
+			}
+
+		case xml.CharData:
+			if len(text) > 0 && strings.TrimSpace(string(tok)) != "" {
+				text[len(text)-1] = true
+			}
+
+		case xml.EndElement:
+			if len(stack) > 1 && text[len(text)-1] {
+				paths[joinPath(stack[1:], "")] = true
+			}
+
+			stack, text = stack[:len(stack)-1], text[:len(text)-1]
+		}
+	}
+}
+
+func joinPath(elems []string, attr string) string {
+	if attr == "" {
+		return strings.Join(elems, "/")
+	}
+
+	return strings.Join(append(append([]string(nil), elems...), attr), "/")
+}
@@ -0,0 +1,48 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapitest_test
+
+import (
+	"io/ioutil"
+
+	"github.com/theckman/go-bart"
+	"github.com/theckman/go-bart/api"
+	"github.com/theckman/go-bart/api/bartapitest"
+	. "gopkg.in/check.v1"
+)
+
+type trainResponse struct {
+	bartapi.Envelope
+	Trains []struct {
+		Line string `xml:"line,attr"`
+		Head string `xml:"head"`
+	} `xml:"trains>train"`
+}
+
+func (*TestSuite) TestRoundTripXML(c *C) {
+	src := []byte(`<root><uri>http://api.bart.gov/</uri><trains>` +
+		`<train line="ROUTE 7" car="3"><head>MLBR</head><load>2</load></train>` +
+		`<train line="ROUTE 8"><head>RICH</head><load></load></train>` +
+		`</trains><message /></root>`)
+
+	diff, err := bartapitest.RoundTripXML(src, &trainResponse{})
+	c.Assert(err, IsNil)
+	c.Check(diff, Equals, "- trains/train/@car\n- trains/train/load")
+
+	_, err = bartapitest.RoundTripXML([]byte("<root>"), &trainResponse{})
+	c.Check(err, NotNil)
+}
+
+func (*TestSuite) TestRoundTripXMLEstimates(c *C) {
+	src, err := ioutil.ReadFile("../../testdata/etd_rich.xml")
+	c.Assert(err, IsNil)
+
+	diff, err := bartapitest.RoundTripXML(src, &bart.EstimatesResponse{})
+	c.Assert(err, IsNil)
+
+	// the delay is parsed by Estimate's UnmarshalXML in to a field that
+	// isn't encoded, as there's no matching MarshalXML
+	c.Check(diff, Equals, "- station/etd/estimate/delay")
+}
@@ -0,0 +1,43 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapitest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+// RoundTrip satisfies the http.RoundTripper interface, responding to the
+// request with what's set for its cmd param. Use HTTPClient to pass the
+// fake to the SetHTTPClient method of a bartapi.Client, or of a bart.Client,
+// so the requests go through their real retry, backoff, circuit breaker,
+// and decoding code. Errors set using SetError are returned as transport
+// errors, like a network failure would be.
+func (f *FakeClient) RoundTrip(req *http.Request) (*http.Response, error) {
+	status, body, _, err := f.respond(req.Context(), req.URL.Query().Get("cmd"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/xml; charset=utf-8"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// HTTPClient returns an *http.Client using the fake as its transport.
+func (f *FakeClient) HTTPClient() *http.Client {
+	return &http.Client{Transport: f}
+}
@@ -0,0 +1,70 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapitest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/theckman/go-bart"
+	"github.com/theckman/go-bart/api"
+	"github.com/theckman/go-bart/api/bartapitest"
+	. "gopkg.in/check.v1"
+)
+
+const etdXML = `<root><station><abbr>MCAR</abbr><etd><destination>Antioch</destination>` +
+	`<estimate><minutes>5</minutes></estimate></etd></station><message /></root>`
+
+func (*TestSuite) TestFakeClientTransportRetries(c *C) {
+	f := bartapitest.NewFakeClient()
+	f.SetResponse("etd", []byte(etdXML))
+	f.SetFailures("etd", 2, http.StatusServiceUnavailable)
+
+	cl := bartapi.New("testkey", bartapi.Endpoint("http://api.bart.example/api/etd.aspx"))
+	cl.SetHTTPClient(f.HTTPClient())
+	cl.SetBackoff(bartapi.Exponential(time.Millisecond, time.Millisecond))
+
+	// without retries the failure is returned
+	resp, err := cl.PullResponse(context.Background(), "etd", nil)
+	c.Assert(err, IsNil)
+	c.Check(resp.StatusCode, Equals, http.StatusServiceUnavailable)
+
+	// the second failure is retried
+	cl.SetRetries(2)
+
+	resp, err = cl.PullResponse(context.Background(), "etd", nil)
+	c.Assert(err, IsNil)
+	c.Check(resp.StatusCode, Equals, http.StatusOK)
+	c.Check(f.Calls("etd"), Equals, 3)
+
+	// errors are transport errors, which the breaker counts
+	b := bartapi.NewBreaker(1, time.Hour)
+	cl.SetBreaker(b)
+	cl.SetRetries(0)
+	f.SetError("etd", errors.New("connection reset"))
+
+	_, err = cl.Pull("etd", nil)
+	c.Check(err, ErrorMatches, ".*connection reset")
+	c.Check(b.State(), Equals, bartapi.BreakerOpen)
+}
+
+func (*TestSuite) TestFakeClientTransportBart(c *C) {
+	f := bartapitest.NewFakeClient()
+	f.SetResponse("etd", []byte(etdXML))
+	f.SetFailures("etd", 1, http.StatusInternalServerError)
+
+	cl := bart.NewWithBaseURL("testkey", "http://api.bart.example/api")
+	cl.SetHTTPClient(f.HTTPClient())
+	cl.SetRetries(1)
+	cl.SetBackoff(bartapi.Exponential(time.Millisecond, time.Millisecond))
+
+	r, err := cl.GetEstimates(context.Background(), "mcar")
+	c.Assert(err, IsNil)
+	c.Assert(r.Stations, HasLen, 1)
+	c.Check(r.Stations[0].ETDs[0].Estimates[0].Minutes, Equals, bart.Minutes(5))
+	c.Check(f.Calls("etd"), Equals, 2)
+}
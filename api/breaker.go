@@ -0,0 +1,138 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned, without making a request, when the client's
+// Breaker is open because of too many consecutive failures.
+var ErrCircuitOpen = errors.New("bartapi: circuit breaker is open")
+
+// BreakerState is the state of a Breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed is the normal state, where requests are allowed.
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen is the state after too many consecutive failures, where
+	// requests fail with ErrCircuitOpen until the cooldown has passed.
+	BreakerOpen
+
+	// BreakerHalfOpen is the state after the cooldown, where a single
+	// trial request is allowed to check whether the API has recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker is a circuit breaker, for not making requests while the API is
+// down. After threshold consecutive failed requests it opens, and requests
+// fail with ErrCircuitOpen. Once the cooldown has passed it's half-open,
+// and a single trial request is allowed: if it succeeds the breaker
+// closes, otherwise it opens for another cooldown.
+//
+// Network errors, server errors (5xx), and being rate limited are failures.
+// Requests canceled by their context, and those rejected by the API for
+// other reasons, are not. A single Breaker can be shared by multiple clients.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewBreaker returns a Breaker that opens after threshold consecutive
+// failures, for the cooldown. A threshold less than 1 is treated as 1.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &Breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+
+	return b.state
+}
+
+// allow returns ErrCircuitOpen if a request isn't allowed.
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		b.state = BreakerHalfOpen
+	}
+
+	switch b.state {
+	case BreakerOpen:
+		return ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.trial {
+			return ErrCircuitOpen
+		}
+
+		b.trial = true
+	}
+
+	return nil
+}
+
+// record updates the breaker with the result of an allowed request.
+func (b *Breaker) record(ctx context.Context, resp *Response, err error) {
+	failed := err != nil || resp.StatusCode >= 500
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// the caller gave up, which says nothing about the API
+	if ctx.Err() != nil {
+		if b.state == BreakerHalfOpen {
+			b.trial = false
+		}
+
+		return
+	}
+
+	if !failed {
+		b.state, b.failures, b.trial = BreakerClosed, 0, false
+		return
+	}
+
+	b.failures++
+
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state, b.openedAt, b.trial = BreakerOpen, time.Now(), false
+	}
+}
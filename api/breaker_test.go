@@ -0,0 +1,158 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+type BreakerSuite struct {
+	srv    *httptest.Server
+	c      *bartapi.Client
+	b      *bartapi.Breaker
+	hits   int
+	status int
+}
+
+var _ = Suite(&BreakerSuite{})
+
+func (s *BreakerSuite) SetUpTest(c *C) {
+	s.hits, s.status = 0, http.StatusInternalServerError
+	s.srv = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		s.hits++
+		rw.WriteHeader(s.status)
+	}))
+	s.b = bartapi.NewBreaker(3, 50*time.Millisecond)
+	s.c = bartapi.New("testkey", bartapi.Endpoint(s.srv.URL))
+	s.c.SetBreaker(s.b)
+}
+
+func (s *BreakerSuite) TearDownTest(c *C) {
+	s.srv.Close()
+}
+
+func (s *BreakerSuite) TestBreaker(c *C) {
+	for i := 0; i < 3; i++ {
+		c.Check(s.b.State(), Equals, bartapi.BreakerClosed)
+
+		_, err := s.c.Pull("test", nil)
+		c.Assert(err, IsNil)
+	}
+
+	c.Check(s.b.State(), Equals, bartapi.BreakerOpen)
+	c.Check(s.b.State().String(), Equals, "open")
+
+	_, err := s.c.Pull("test", nil)
+	c.Check(err, Equals, bartapi.ErrCircuitOpen)
+	c.Check(s.hits, Equals, 3)
+
+	time.Sleep(60 * time.Millisecond)
+	c.Check(s.b.State(), Equals, bartapi.BreakerHalfOpen)
+
+	// the trial fails, so it opens again
+	_, err = s.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(s.hits, Equals, 4)
+	c.Check(s.b.State(), Equals, bartapi.BreakerOpen)
+
+	_, err = s.c.Pull("test", nil)
+	c.Check(err, Equals, bartapi.ErrCircuitOpen)
+
+	time.Sleep(60 * time.Millisecond)
+
+	// the trial succeeds, so it closes
+	s.status = http.StatusOK
+
+	_, err = s.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(s.b.State(), Equals, bartapi.BreakerClosed)
+	c.Check(s.hits, Equals, 5)
+}
+
+func (s *BreakerSuite) TestBreakerResetsOnSuccess(c *C) {
+	for i := 0; i < 10; i++ {
+		if i%2 == 0 {
+			s.status = http.StatusBadGateway
+		} else {
+			s.status = http.StatusOK
+		}
+
+		_, err := s.c.Pull("test", nil)
+		c.Assert(err, IsNil)
+	}
+
+	// the failures weren't consecutive
+	c.Check(s.b.State(), Equals, bartapi.BreakerClosed)
+}
+
+func (s *BreakerSuite) TestBreakerIgnoresCanceled(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i := 0; i < 5; i++ {
+		_, err := s.c.PullContext(ctx, "test", nil)
+		c.Check(err, NotNil)
+		c.Check(err, Not(Equals), bartapi.ErrCircuitOpen)
+	}
+
+	c.Check(s.b.State(), Equals, bartapi.BreakerClosed)
+}
+
+func (s *BreakerSuite) TestBreakerWithRetries(c *C) {
+	s.c.SetBreaker(bartapi.NewBreaker(3, time.Minute))
+	s.c.SetRetries(5)
+
+	// the breaker opens before the retries are exhausted
+	_, err := s.c.Pull("test", nil)
+	c.Check(err, Equals, bartapi.ErrCircuitOpen)
+	c.Check(s.hits, Equals, 3)
+}
+
+func (*BreakerSuite) TestBreakerState(c *C) {
+	c.Check(bartapi.BreakerClosed.String(), Equals, "closed")
+	c.Check(bartapi.BreakerHalfOpen.String(), Equals, "half-open")
+	c.Check(bartapi.BreakerState(9).String(), Equals, "unknown")
+}
+
+type limiterFunc func(ctx context.Context) error
+
+func (f limiterFunc) Wait(ctx context.Context) error { return f(ctx) }
+
+func (s *BreakerSuite) TestBreakerCanceledTrial(c *C) {
+	for i := 0; i < 3; i++ {
+		_, err := s.c.Pull("test", nil)
+		c.Assert(err, IsNil)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	c.Check(s.b.State(), Equals, bartapi.BreakerHalfOpen)
+
+	// the context is canceled while the trial request waits for the limiter
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.c.SetLimiter(limiterFunc(func(ctx context.Context) error {
+		cancel()
+		return ctx.Err()
+	}))
+
+	_, err := s.c.PullContext(ctx, "test", nil)
+	c.Check(err, Equals, context.Canceled)
+	c.Check(s.hits, Equals, 3)
+
+	// the trial is still available
+	s.c.SetLimiter(nil)
+	s.status = http.StatusOK
+
+	_, err = s.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(s.hits, Equals, 4)
+	c.Check(s.b.State(), Equals, bartapi.BreakerClosed)
+}
@@ -0,0 +1,46 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+type ContextSuite struct{}
+
+var _ = Suite(&ContextSuite{})
+
+func (*ContextSuite) TestRequestID(c *C) {
+	var got string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		got = req.Header.Get(bartapi.RequestIDHeader)
+	}))
+	defer srv.Close()
+
+	cl := bartapi.New("testkey", bartapi.Endpoint(srv.URL))
+
+	_, err := cl.PullContext(context.Background(), "test", nil)
+	c.Assert(err, IsNil)
+	c.Check(got, Equals, "")
+
+	ctx := bartapi.WithRequestID(context.Background(), "abc123")
+
+	id, ok := bartapi.RequestIDFromContext(ctx)
+	c.Check(ok, Equals, true)
+	c.Check(id, Equals, "abc123")
+
+	_, err = cl.PullContext(ctx, "test", nil)
+	c.Assert(err, IsNil)
+	c.Check(got, Equals, "abc123")
+
+	_, ok = bartapi.RequestIDFromContext(bartapi.WithRequestID(context.Background(), ""))
+	c.Check(ok, Equals, false)
+}
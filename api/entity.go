@@ -0,0 +1,45 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// DecodeOptions are the settings of DecodeWithOptions.
+type DecodeOptions struct {
+	// Entity maps the names of entities, without the & and ;, to the text
+	// they're replaced with. It's merged in to xml.HTMLEntity, which every
+	// decoder in this package knows, and takes precedence over it.
+	Entity map[string]string
+}
+
+// DecodeWithOptions is the same as Decode, with the settings in opts. The
+// zero DecodeOptions decodes the same way Decode does.
+func DecodeWithOptions(r io.Reader, v interface{}, opts DecodeOptions) error {
+	d := newUTF8Decoder(r)
+
+	if len(opts.Entity) > 0 {
+		d.Entity = mergeEntities(opts.Entity)
+	}
+
+	return decode(d, v)
+}
+
+// mergeEntities returns a new map of the HTML entities and those in extra.
+func mergeEntities(extra map[string]string) map[string]string {
+	m := make(map[string]string, len(xml.HTMLEntity)+len(extra))
+
+	for k, v := range xml.HTMLEntity {
+		m[k] = v
+	}
+
+	for k, v := range extra {
+		m[k] = v
+	}
+
+	return m
+}
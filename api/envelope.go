@@ -0,0 +1,189 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrURIMismatch is returned when URI verification is enabled, and the
+// request URI echoed in the response envelope doesn't match the one sent.
+var ErrURIMismatch = errors.New("bartapi: echoed request URI does not match the one sent")
+
+// Envelope is the <root> element that wraps every BART API response.
+// It can be decoded on its own to inspect response metadata, or be
+// embedded in a consumer's struct so that it's populated alongside the
+// command-specific data.
+type Envelope struct {
+	XMLName xml.Name `xml:"root"`
+	URI     string   `xml:"uri"`
+	Message Message  `xml:"message"`
+}
+
+// Message is the <message> element of the response envelope.
+type Message struct {
+	Text            string    `xml:",chardata"`
+	Copyright       string    `xml:"copyright"`
+	LegalDisclaimer string    `xml:"legalDisclaimer"`
+	Warning         string    `xml:"warning"`
+	Error           *APIError `xml:"error"`
+
+	// Legend is the description of the codes used in the response.
+	// It's only included by commands that support it, when requested.
+	Legend string `xml:"legend"`
+}
+
+// APIError is an error reported by the API in the response envelope.
+// BART returns these with a 200 status code, so they can only be
+// detected by inspecting the response body.
+type APIError struct {
+	Text    string `xml:"text"`
+	Details string `xml:"details"`
+}
+
+func (e *APIError) Error() string {
+	text, details := strings.TrimSpace(e.Text), strings.TrimSpace(e.Details)
+
+	if details == "" {
+		return "bartapi: " + text
+	}
+
+	return fmt.Sprintf("bartapi: %s: %s", text, details)
+}
+
+// MessageLevel is the classification of the message in a response.
+type MessageLevel int
+
+const (
+	// MessageNone is the level of a response without a message.
+	MessageNone MessageLevel = iota
+
+	// MessageInfo is the level of an informational message.
+	MessageInfo
+
+	// MessageWarning is the level of a warning. The response data may
+	// be incomplete.
+	MessageWarning
+
+	// MessageError is the level of an error. The response data is not usable.
+	MessageError
+)
+
+func (l MessageLevel) String() string {
+	switch l {
+	case MessageInfo:
+		return "info"
+	case MessageWarning:
+		return "warning"
+	case MessageError:
+		return "error"
+	default:
+		return "none"
+	}
+}
+
+// Inspect returns the text of the response message along with its level,
+// so that callers can act on soft failures that BART doesn't reflect in the
+// HTTP status code. The copyright and legal disclaimer aren't considered
+// to be part of the message; see Disclaimer for those.
+func (e *Envelope) Inspect() (string, MessageLevel) {
+	m := e.Message
+
+	if m.Error != nil {
+		return strings.TrimPrefix(m.Error.Error(), "bartapi: "), MessageError
+	}
+
+	if s := strings.TrimSpace(m.Warning); s != "" {
+		return s, MessageWarning
+	}
+
+	if s := strings.TrimSpace(m.Text); s != "" {
+		return s, MessageInfo
+	}
+
+	return "", MessageNone
+}
+
+// Err returns the error reported in the envelope, if there is one.
+func (e *Envelope) Err() error {
+	if e.Message.Error != nil {
+		return e.Message.Error
+	}
+
+	return nil
+}
+
+// Disclaimer returns the attribution text BART includes in the envelope.
+// The copyright and the legal disclaimer are joined by a newline if both
+// are present. BART's license agreement requires this to be displayed.
+func (e *Envelope) Disclaimer() string {
+	var parts []string
+
+	if s := strings.TrimSpace(e.Message.Copyright); s != "" {
+		parts = append(parts, s)
+	}
+
+	if s := strings.TrimSpace(e.Message.LegalDisclaimer); s != "" {
+		parts = append(parts, s)
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// DecodeEnvelope decodes only the envelope of a raw API response.
+func DecodeEnvelope(body []byte) (*Envelope, error) {
+	e := &Envelope{}
+
+	if err := Decode(bytes.NewReader(body), e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// verifyURI compares the request URI that was sent with the one echoed by
+// the API. The hosts aren't compared, to allow for proxies, and the key
+// param is ignored as it's not included in the echoed URI.
+func verifyURI(sent, echoed string) error {
+	mismatch := fmt.Errorf("%w: sent %q, echoed %q", ErrURIMismatch, sent, echoed)
+
+	s, err := url.Parse(strings.TrimSpace(sent))
+
+	if err != nil {
+		return err
+	}
+
+	e, err := url.Parse(strings.TrimSpace(echoed))
+
+	if err != nil || echoed == "" {
+		return mismatch
+	}
+
+	if !strings.EqualFold(path.Base(s.Path), path.Base(e.Path)) {
+		return mismatch
+	}
+
+	sq, eq := s.Query(), e.Query()
+	sq.Del("key")
+	eq.Del("key")
+
+	if len(sq) != len(eq) {
+		return mismatch
+	}
+
+	for k, v := range sq {
+		if eq.Get(k) != v[0] {
+			return mismatch
+		}
+	}
+
+	return nil
+}
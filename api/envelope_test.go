@@ -0,0 +1,173 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+var exampleEnvelope = `<?xml version="1.0" encoding="utf-8"?>
+<root>
+	<uri><![CDATA[http://api.bart.gov/api/stn.aspx?cmd=stns]]></uri>
+	<message>
+		<copyright>Copyright 2015 Bay Area Rapid Transit District</copyright>
+		<legalDisclaimer>BART data is provided as-is.</legalDisclaimer>
+	</message>
+</root>
+`
+
+type EnvelopeSuite struct{}
+
+var _ = Suite(&EnvelopeSuite{})
+
+func (*EnvelopeSuite) TestDecodeEnvelope(c *C) {
+	e, err := bartapi.DecodeEnvelope([]byte(exampleEnvelope))
+	c.Assert(err, IsNil)
+	c.Check(e.URI, Equals, "http://api.bart.gov/api/stn.aspx?cmd=stns")
+	c.Check(e.Message.Copyright, Equals, "Copyright 2015 Bay Area Rapid Transit District")
+	c.Check(e.Message.LegalDisclaimer, Equals, "BART data is provided as-is.")
+	c.Check(e.Disclaimer(), Equals, "Copyright 2015 Bay Area Rapid Transit District\nBART data is provided as-is.")
+
+	e, err = bartapi.DecodeEnvelope([]byte("<root><message/></root>"))
+	c.Assert(err, IsNil)
+	c.Check(e.Disclaimer(), Equals, "")
+
+	_, err = bartapi.DecodeEnvelope([]byte(""))
+	c.Check(err, Not(IsNil))
+}
+
+func (*EnvelopeSuite) TestClientDisclaimer(c *C) {
+	var hits int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		hits++
+
+		if hits > 1 {
+			fmt.Fprint(rw, "<root><message/></root>")
+			return
+		}
+
+		fmt.Fprint(rw, exampleEnvelope)
+	}))
+	defer srv.Close()
+
+	cl := bartapi.New("testkey", bartapi.Endpoint(srv.URL))
+	c.Check(cl.Disclaimer(), Equals, "")
+
+	_, err := cl.Pull("stns", nil)
+	c.Assert(err, IsNil)
+	c.Check(cl.Disclaimer(), Equals, "Copyright 2015 Bay Area Rapid Transit District\nBART data is provided as-is.")
+
+	// the cached value survives responses without a disclaimer
+	_, err = cl.Pull("stns", nil)
+	c.Assert(err, IsNil)
+	c.Check(hits, Equals, 2)
+	c.Check(cl.Disclaimer(), Equals, "Copyright 2015 Bay Area Rapid Transit District\nBART data is provided as-is.")
+}
+
+func (*EnvelopeSuite) TestVerifyURI(c *C) {
+	var mangle bool
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		q.Del("key")
+
+		if mangle {
+			q.Set("orig", "mangled")
+		}
+
+		fmt.Fprintf(rw, "<root><uri><![CDATA[ http://api.bart.gov/api/stn.aspx?%s ]]></uri><message/></root>", q.Encode())
+	}))
+	defer srv.Close()
+
+	cl := bartapi.New("testkey", bartapi.Endpoint(srv.URL+"/stn.aspx"))
+
+	// verification is off by default
+	mangle = true
+	_, err := cl.Pull("stninfo", map[string]string{"orig": "MCAR"})
+	c.Check(err, IsNil)
+
+	cl.SetVerifyURI(true)
+
+	_, err = cl.Pull("stninfo", map[string]string{"orig": "MCAR"})
+	c.Check(errors.Is(err, bartapi.ErrURIMismatch), Equals, true)
+
+	mangle = false
+	_, err = cl.Pull("stninfo", map[string]string{"orig": "MCAR"})
+	c.Check(err, IsNil)
+}
+
+func (*EnvelopeSuite) TestInspect(c *C) {
+	tests := []struct {
+		xml   string
+		text  string
+		level bartapi.MessageLevel
+	}{
+		{"<root><message/></root>", "", bartapi.MessageNone},
+		{exampleEnvelope, "", bartapi.MessageNone},
+		{"<root><message>Station closed</message></root>", "Station closed", bartapi.MessageInfo},
+		{"<root><message><warning>No data matched your criteria.</warning></message></root>", "No data matched your criteria.", bartapi.MessageWarning},
+		{"<root><message><error><text>Invalid key</text><details>The api key was missing or invalid.</details></error></message></root>", "Invalid key: The api key was missing or invalid.", bartapi.MessageError},
+	}
+
+	for _, tt := range tests {
+		e, err := bartapi.DecodeEnvelope([]byte(tt.xml))
+		c.Assert(err, IsNil)
+
+		text, level := e.Inspect()
+		c.Check(text, Equals, tt.text)
+		c.Check(level, Equals, tt.level)
+
+		if tt.level == bartapi.MessageError {
+			c.Check(e.Err(), ErrorMatches, "bartapi: Invalid key: The api key was missing or invalid.")
+		} else {
+			c.Check(e.Err(), IsNil)
+		}
+	}
+
+	c.Check(bartapi.MessageWarning.String(), Equals, "warning")
+}
+
+func (*EnvelopeSuite) TestPullReader(c *C) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.FormValue("cmd") {
+		case "missing":
+			http.NotFound(rw, req)
+		case "error":
+			fmt.Fprint(rw, "<root><message><error><text>Invalid cmd</text></error></message></root>")
+		default:
+			fmt.Fprint(rw, exampleEnvelope)
+		}
+	}))
+	defer srv.Close()
+
+	cl := bartapi.New("testkey", bartapi.Endpoint(srv.URL))
+	ctx := context.Background()
+
+	r, err := cl.PullReader(ctx, "stns", nil)
+	c.Assert(err, IsNil)
+
+	body, err := ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+	c.Check(r.Close(), IsNil)
+	c.Check(string(body), Equals, exampleEnvelope)
+
+	_, err = cl.PullReader(ctx, "missing", nil)
+	c.Assert(err, Not(IsNil))
+	statusErr, ok := err.(*bartapi.StatusError)
+	c.Assert(ok, Equals, true)
+	c.Check(statusErr.StatusCode, Equals, http.StatusNotFound)
+
+	_, err = cl.PullReader(ctx, "error", nil)
+	c.Check(err, ErrorMatches, "bartapi: Invalid cmd")
+}
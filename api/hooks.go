@@ -0,0 +1,102 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrPublicAPIKey is passed to the Warning hook the first time a client
+// using PublicAPIKey makes a request. The key is shared by everyone who
+// hasn't registered for one of their own, and is rate limited.
+var ErrPublicAPIKey = errors.New("bartapi: using the shared public API key, which is rate limited; register for a key of your own at https://api.bart.gov/api/register.aspx")
+
+// Hooks are functions called to report what a client is doing, for
+// logging and monitoring. Any of the functions may be nil, and they may
+// be called concurrently.
+type Hooks struct {
+	// Warning is called with problems that don't fail the request,
+	// but that may mean the response is missing data.
+	Warning func(ctx context.Context, err error)
+
+	// Request is called before each request is sent, including
+	// each retry, with the params that are being sent.
+	Request func(ctx context.Context, info RequestInfo)
+}
+
+// RequestInfo is the information about a request passed to the Request
+// hook, for auditing what is sent to the API.
+type RequestInfo struct {
+	Endpoint Endpoint
+	Cmd      string
+
+	// Query is all of the params of the request, including the cmd, the
+	// defaults set using SetDefaultQuery, and the API key. The key is
+	// masked using MaskKey.
+	Query map[string]string
+
+	// Attempt is the number of the attempt, starting at zero,
+	// when retries are enabled using SetRetries.
+	Attempt int
+}
+
+// SetHooks sets the Hooks called by the client. The zero value disables
+// them, which is the default.
+func (c *Client) SetHooks(h Hooks) {
+	c.mu.Lock()
+	c.hooks = h
+	c.mu.Unlock()
+}
+
+// SetPublicKeyWarning sets whether ErrPublicAPIKey is passed to the Warning
+// hook when the client is using PublicAPIKey, which it is by default. The
+// warning is only sent once per client, before its first request.
+func (c *Client) SetPublicKeyWarning(warn bool) {
+	c.mu.Lock()
+	c.quietPublicKey = !warn
+	c.mu.Unlock()
+}
+
+// warnPublicKey passes ErrPublicAPIKey to the Warning hook, if the client
+// is using PublicAPIKey and it hasn't already been sent or suppressed.
+func (c *Client) warnPublicKey(ctx context.Context, h Hooks) {
+	if c.key != PublicAPIKey || h.Warning == nil {
+		return
+	}
+
+	c.mu.Lock()
+	warn := !c.quietPublicKey && !c.publicKeyWarned
+	c.publicKeyWarned = c.publicKeyWarned || warn
+	c.mu.Unlock()
+
+	if warn {
+		h.Warning(ctx, ErrPublicAPIKey)
+	}
+}
+
+// requestInfo returns the RequestInfo of the request with the params.
+func (c *Client) requestInfo(cmd string, params map[string]string, attempt int) RequestInfo {
+	q := make(map[string]string, len(params)+2)
+
+	for k, v := range params {
+		q[k] = v
+	}
+
+	q["cmd"], q["key"] = cmd, MaskKey(c.key)
+
+	return RequestInfo{Endpoint: c.url, Cmd: cmd, Query: q, Attempt: attempt}
+}
+
+// MaskKey masks all but the last four characters of the API key, so
+// that it can be logged. Keys of four characters or less are fully masked.
+func MaskKey(key string) string {
+	if len(key) <= 4 {
+		return strings.Repeat("*", len(key))
+	}
+
+	return strings.Repeat("*", len(key)-4) + key[len(key)-4:]
+}
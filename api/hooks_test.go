@@ -0,0 +1,78 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi_test
+
+import (
+	"context"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestRequestHook(c *C) {
+	var infos []bartapi.RequestInfo
+
+	t.c.SetDefaultQuery(map[string]string{"json": "y", "orig": "12TH"})
+	t.c.SetHooks(bartapi.Hooks{Request: func(ctx context.Context, info bartapi.RequestInfo) {
+		infos = append(infos, info)
+	}})
+
+	_, err := t.c.Pull("etd", map[string]string{"orig": "MCAR"})
+	c.Assert(err, IsNil)
+
+	c.Assert(infos, HasLen, 1)
+	c.Check(infos[0], DeepEquals, bartapi.RequestInfo{
+		Endpoint: t.url,
+		Cmd:      "etd",
+		Query:    map[string]string{"cmd": "etd", "key": "***tkey", "json": "y", "orig": "MCAR"},
+	})
+
+	t.c.SetHooks(bartapi.Hooks{})
+
+	_, err = t.c.Pull("etd", nil)
+	c.Assert(err, IsNil)
+	c.Check(infos, HasLen, 1)
+}
+
+func (*TestSuite) TestMaskKey(c *C) {
+	c.Check(bartapi.MaskKey(bartapi.PublicAPIKey), Equals, "***************VV8V")
+	c.Check(bartapi.MaskKey("abcd"), Equals, "****")
+	c.Check(bartapi.MaskKey(""), Equals, "")
+}
+
+func (t *TestSuite) TestPublicKeyWarning(c *C) {
+	var warnings []error
+
+	hooks := bartapi.Hooks{Warning: func(ctx context.Context, err error) {
+		warnings = append(warnings, err)
+	}}
+
+	// clients with their own key don't warn
+	t.c.SetHooks(hooks)
+
+	_, err := t.c.Pull("etd", nil)
+	c.Assert(err, IsNil)
+	c.Check(warnings, HasLen, 0)
+
+	pub := bartapi.New(bartapi.PublicAPIKey, t.url)
+	pub.SetHooks(hooks)
+
+	for i := 0; i < 2; i++ {
+		_, err = pub.Pull("etd", nil)
+		c.Assert(err, IsNil)
+	}
+
+	c.Check(warnings, DeepEquals, []error{bartapi.ErrPublicAPIKey})
+
+	warnings = nil
+
+	quiet := bartapi.New(bartapi.PublicAPIKey, t.url)
+	quiet.SetHooks(hooks)
+	quiet.SetPublicKeyWarning(false)
+
+	_, err = quiet.Pull("etd", nil)
+	c.Assert(err, IsNil)
+	c.Check(warnings, HasLen, 0)
+}
@@ -0,0 +1,61 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter rate limits the requests made to the API. Wait blocks until the
+// next request is allowed, or until the context is done.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// NewLimiter returns a Limiter that allows one request per interval.
+// A single Limiter can be shared by multiple clients, so that they are
+// limited together.
+func NewLimiter(interval time.Duration) Limiter {
+	return &intervalLimiter{interval: interval}
+}
+
+type intervalLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func (l *intervalLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+
+	now := time.Now()
+	t := l.next
+
+	if t.Before(now) {
+		t = now
+	}
+
+	l.next = t.Add(l.interval)
+
+	l.mu.Unlock()
+
+	d := t.Sub(now)
+
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+type LimiterSuite struct{}
+
+var _ = Suite(&LimiterSuite{})
+
+func (*LimiterSuite) TestLimiter(c *C) {
+	l := bartapi.NewLimiter(20 * time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		c.Assert(l.Wait(ctx), IsNil)
+	}
+
+	// the first request is immediate, the next two wait an interval each
+	c.Check(time.Since(start) >= 40*time.Millisecond, Equals, true)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	c.Check(l.Wait(ctx), Equals, context.Canceled)
+}
+
+func (t *TestSuite) TestSetLimiter(c *C) {
+	t.c.SetLimiter(bartapi.NewLimiter(time.Hour))
+
+	_, err := t.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = t.c.PullContext(ctx, "test", nil)
+	c.Check(err, Equals, context.DeadlineExceeded)
+}
@@ -0,0 +1,99 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrTooManyRedirects is returned when a request is redirected more times
+// than its RedirectPolicy allows.
+var ErrTooManyRedirects = errors.New("bartapi: too many redirects")
+
+// ErrCrossHostRedirect is returned when a request is redirected to a
+// different host, and the RedirectPolicy doesn't allow it.
+var ErrCrossHostRedirect = errors.New("bartapi: redirected to a different host")
+
+// RedirectPolicy decides whether a redirect is followed, the same as the
+// CheckRedirect field of *http.Client: req is the request about to be made,
+// and via the requests made so far, oldest first. Returning an error stops
+// the redirect. The policy may modify req.
+type RedirectPolicy func(req *http.Request, via []*http.Request) error
+
+// SameHostRedirects returns a RedirectPolicy that follows up to max
+// redirects, as long as they're to the host of the original request. This
+// is the default policy, as the API key is part of the URL, and following a
+// redirect to another host would send the key to it.
+func SameHostRedirects(max int) RedirectPolicy {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > max {
+			return fmt.Errorf("%w: %d", ErrTooManyRedirects, max)
+		}
+
+		if !sameHost(req, via[0]) {
+			return fmt.Errorf("%w: %s", ErrCrossHostRedirect, req.URL.Host)
+		}
+
+		return nil
+	}
+}
+
+// StripKeyRedirects returns a RedirectPolicy that follows up to max
+// redirects to any host, removing the API key from the requests to hosts
+// other than the one of the original request.
+func StripKeyRedirects(max int) RedirectPolicy {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > max {
+			return fmt.Errorf("%w: %d", ErrTooManyRedirects, max)
+		}
+
+		if !sameHost(req, via[0]) {
+			q := req.URL.Query()
+			q.Del("key")
+			req.URL.RawQuery = q.Encode()
+		}
+
+		return nil
+	}
+}
+
+func sameHost(a, b *http.Request) bool {
+	return strings.EqualFold(a.URL.Hostname(), b.URL.Hostname())
+}
+
+// defaultRedirectPolicy is the RedirectPolicy used when one isn't set using
+// SetRedirectPolicy. It allows the same number of redirects as net/http.
+var defaultRedirectPolicy = SameHostRedirects(10)
+
+// SetRedirectPolicy sets the RedirectPolicy used to decide whether to
+// follow the redirects of requests. It takes precedence over the
+// CheckRedirect of the *http.Client set using SetHTTPClient. Nil restores
+// the default, which uses the CheckRedirect of the *http.Client if it has
+// one, and SameHostRedirects(10) if it doesn't.
+func (c *Client) SetRedirectPolicy(p RedirectPolicy) {
+	c.mu.Lock()
+	c.redirect = p
+	c.mu.Unlock()
+}
+
+// withRedirectPolicy returns a copy of hc that uses the policy p, or the
+// default policy if neither p nor hc's CheckRedirect are set.
+func withRedirectPolicy(hc *http.Client, p RedirectPolicy) *http.Client {
+	if p == nil {
+		if hc.CheckRedirect != nil {
+			return hc
+		}
+
+		p = defaultRedirectPolicy
+	}
+
+	redirected := *hc
+	redirected.CheckRedirect = p
+
+	return &redirected
+}
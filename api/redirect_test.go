@@ -0,0 +1,119 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+type RedirectSuite struct {
+	// api is where requests are made, and other is a different host
+	// (127.0.0.1 vs localhost) that api redirects to
+	api, other *httptest.Server
+	keys       []string
+	c          *bartapi.Client
+}
+
+var _ = Suite(&RedirectSuite{})
+
+func (s *RedirectSuite) SetUpTest(c *C) {
+	s.keys = nil
+
+	s.other = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		s.keys = append(s.keys, req.FormValue("key"))
+		fmt.Fprint(rw, "<root/>")
+	}))
+
+	s.api = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/moved":
+			http.Redirect(rw, req, "/api?"+req.URL.RawQuery, http.StatusMovedPermanently)
+		case "/elsewhere":
+			u := strings.Replace(s.other.URL, "127.0.0.1", "localhost", 1)
+			http.Redirect(rw, req, u+"/api?"+req.URL.RawQuery, http.StatusFound)
+		case "/loop":
+			http.Redirect(rw, req, "/loop?"+req.URL.RawQuery, http.StatusFound)
+		default:
+			s.keys = append(s.keys, req.FormValue("key"))
+			fmt.Fprint(rw, "<root/>")
+		}
+	}))
+}
+
+func (s *RedirectSuite) TearDownTest(c *C) {
+	s.api.Close()
+	s.other.Close()
+}
+
+func (s *RedirectSuite) client(path string) *bartapi.Client {
+	cl := bartapi.New("testkey", bartapi.Endpoint(s.api.URL+path))
+	cl.SetRetries(2)
+	return cl
+}
+
+func (s *RedirectSuite) TestSameHost(c *C) {
+	_, err := s.client("/moved").Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(s.keys, DeepEquals, []string{"testkey"})
+}
+
+func (s *RedirectSuite) TestCrossHost(c *C) {
+	_, err := s.client("/elsewhere").Pull("test", nil)
+	c.Check(errors.Is(err, bartapi.ErrCrossHostRedirect), Equals, true)
+
+	// the key isn't sent, and the redirect isn't retried
+	c.Check(s.keys, HasLen, 0)
+}
+
+func (s *RedirectSuite) TestTooMany(c *C) {
+	_, err := s.client("/loop").Pull("test", nil)
+	c.Check(errors.Is(err, bartapi.ErrTooManyRedirects), Equals, true)
+}
+
+func (s *RedirectSuite) TestStripKeyRedirects(c *C) {
+	cl := s.client("/elsewhere")
+	cl.SetRedirectPolicy(bartapi.StripKeyRedirects(10))
+
+	_, err := cl.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(s.keys, DeepEquals, []string{""})
+
+	// same host redirects keep the key
+	cl = s.client("/moved")
+	cl.SetRedirectPolicy(bartapi.StripKeyRedirects(10))
+
+	_, err = cl.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(s.keys, DeepEquals, []string{"", "testkey"})
+}
+
+func (s *RedirectSuite) TestHTTPClientCheckRedirect(c *C) {
+	var followed int
+
+	cl := s.client("/elsewhere")
+	cl.SetHTTPClient(&http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		followed++
+		return nil
+	}})
+
+	// the *http.Client's policy is used when one isn't set
+	_, err := cl.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(followed, Equals, 1)
+	c.Check(s.keys, DeepEquals, []string{"testkey"})
+
+	cl.SetRedirectPolicy(bartapi.SameHostRedirects(10))
+
+	_, err = cl.Pull("test", nil)
+	c.Check(errors.Is(err, bartapi.ErrCrossHostRedirect), Equals, true)
+	c.Check(followed, Equals, 1)
+}
@@ -0,0 +1,117 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// BuildRequest returns the *http.Request the client would send for cmd with
+// the query params, without sending it. It's the same as the request made
+// by PullContext, including the defaults set using SetDefaultQuery, the API
+// key, and the request ID header, and has the context ctx. The errors are
+// the ones PullContext returns before sending the request, like
+// ErrURLTooLong.
+//
+// The URL contains the API key, so use RedactURL when logging it.
+func (c *Client) BuildRequest(ctx context.Context, cmd string, query map[string]string) (*http.Request, error) {
+	u, _ := c.buildURL(cmd, query)
+
+	c.mu.RLock()
+	closed := c.closed
+	allowed := c.allowedCmds == nil || c.allowedCmds[strings.ToLower(cmd)]
+	c.mu.RUnlock()
+
+	if closed {
+		return nil, ErrClosed
+	}
+
+	if !allowed {
+		return nil, fmt.Errorf("%w: %s", ErrCommandNotAllowed, cmd)
+	}
+
+	if len(u) > MaxURLLength {
+		return nil, ErrURLTooLong
+	}
+
+	return c.newRequest(ctx, u)
+}
+
+// RedactURL returns a copy of u with the API key masked using MaskKey, so
+// that it can be logged. u is returned as is if it doesn't have a key.
+func RedactURL(u *url.URL) *url.URL {
+	q := u.Query()
+
+	key, ok := q["key"]
+
+	if !ok {
+		return u
+	}
+
+	for i := range key {
+		key[i] = MaskKey(key[i])
+	}
+
+	r := *u
+	r.RawQuery = q.Encode()
+
+	return &r
+}
+
+// buildURL returns the URL of the request for cmd with the query params,
+// and the params other than the cmd and the key.
+func (c *Client) buildURL(cmd string, query map[string]string) (string, map[string]string) {
+	var params bytes.Buffer
+
+	params.WriteString(fmt.Sprintf("%v?cmd=%v&key=%v", string(c.url), cmd, c.key))
+
+	final := make(map[string]string, len(query))
+
+	c.mu.RLock()
+	for k, v := range c.defaultQuery {
+		if _, ok := query[k]; !ok {
+			final[k] = v
+		}
+	}
+	c.mu.RUnlock()
+
+	for k, v := range query {
+		final[k] = v
+	}
+
+	for k, v := range final {
+		params.WriteString(fmt.Sprintf("&%v=%v", k, v))
+	}
+
+	return params.String(), final
+}
+
+// newRequest returns the GET request for the URL u, with the context ctx.
+func (c *Client) newRequest(ctx context.Context, u string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	lang := c.acceptLanguage
+	c.mu.RUnlock()
+
+	if lang != "" {
+		req.Header.Set("Accept-Language", lang)
+	}
+
+	if id, ok := RequestIDFromContext(ctx); ok {
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	return req, nil
+}
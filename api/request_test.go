@@ -0,0 +1,90 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestBuildRequest(c *C) {
+	t.c.SetDefaultQuery(map[string]string{"json": "n", "orig": "12TH"})
+
+	ctx := bartapi.WithRequestID(context.Background(), "abc123")
+
+	req, err := t.c.BuildRequest(ctx, "etd", map[string]string{"orig": "MCAR"})
+	c.Assert(err, IsNil)
+	c.Check(req.Method, Equals, "GET")
+	c.Check(req.Context(), Equals, ctx)
+	c.Check(req.Header.Get(bartapi.RequestIDHeader), Equals, "abc123")
+
+	q := req.URL.Query()
+	c.Check(q.Get("cmd"), Equals, "etd")
+	c.Check(q.Get("key"), Equals, "testkey")
+	c.Check(q.Get("orig"), Equals, "MCAR")
+	c.Check(q.Get("json"), Equals, "n")
+	c.Check(strings.HasPrefix(req.URL.String(), t.srv.URL+"?"), Equals, true)
+
+	// the request can be sent as is
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Check(resp.StatusCode, Equals, http.StatusOK)
+
+	_, err = t.c.BuildRequest(ctx, "etd", map[string]string{"orig": strings.Repeat("x", bartapi.MaxURLLength)})
+	c.Check(err, Equals, bartapi.ErrURLTooLong)
+
+	t.c.SetAllowedCommands("bsa")
+
+	_, err = t.c.BuildRequest(ctx, "etd", nil)
+	c.Check(errors.Is(err, bartapi.ErrCommandNotAllowed), Equals, true)
+}
+
+func (t *TestSuite) TestRedactURL(c *C) {
+	u, err := url.Parse("http://api.bart.gov/api/etd.aspx?cmd=etd&key=MW9S-E7SL-26DU-VV8V&orig=MCAR")
+	c.Assert(err, IsNil)
+
+	r := bartapi.RedactURL(u)
+	c.Check(r.Query().Get("key"), Equals, "***************VV8V")
+	c.Check(r.Query().Get("orig"), Equals, "MCAR")
+
+	// u isn't modified
+	c.Check(u.Query().Get("key"), Equals, "MW9S-E7SL-26DU-VV8V")
+
+	u, err = url.Parse("http://api.bart.gov/api/etd.aspx?cmd=etd")
+	c.Assert(err, IsNil)
+	c.Check(bartapi.RedactURL(u), Equals, u)
+}
+
+func (t *TestSuite) TestSetAcceptLanguage(c *C) {
+	var got []string
+
+	t.c.Use(func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			got = append(got, req.Header.Get("Accept-Language"))
+			return next.RoundTrip(req)
+		})
+	})
+
+	_, err := t.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+
+	t.c.SetAcceptLanguage(" es-MX, es;q=0.8 ")
+
+	_, err = t.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+
+	req, err := t.c.BuildRequest(context.Background(), "test", nil)
+	c.Assert(err, IsNil)
+	c.Check(req.Header.Get("Accept-Language"), Equals, "es-MX, es;q=0.8")
+
+	c.Check(got, DeepEquals, []string{"", "es-MX, es;q=0.8"})
+}
@@ -0,0 +1,223 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrRateLimited is matched, using errors.Is, by the *RateLimitError
+// returned when the API throttles the client.
+var ErrRateLimited = errors.New("bartapi: rate limited")
+
+// RateLimitError is returned when the API throttles the client, either with
+// a 429 status code or an error message saying so. RetryAfter is how long
+// the API asked the client to wait before retrying, or zero if it didn't.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s: retry after %s", ErrRateLimited, e.RetryAfter)
+	}
+
+	return ErrRateLimited.Error()
+}
+
+// Is allows the error to match ErrRateLimited using errors.Is.
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// retryBase is the delay before the first retry when using
+// the exponential backoff. It doubles for each attempt.
+const retryBase = 100 * time.Millisecond
+
+// retryMax is the maximum delay of the exponential backoff.
+const retryMax = 10 * time.Second
+
+// rateLimited returns whether the response says the client was throttled.
+func rateLimited(r *Response) bool {
+	if r.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	// only decode the envelope if it might be needed
+	lower := bytes.ToLower(r.Body)
+
+	if !bytes.Contains(lower, []byte("rate limit")) && !bytes.Contains(lower, []byte("too many requests")) {
+		return false
+	}
+
+	e, err := DecodeEnvelope(r.Body)
+
+	if err != nil || e.Message.Error == nil {
+		return false
+	}
+
+	text := strings.ToLower(e.Message.Error.Text + " " + e.Message.Error.Details)
+
+	return strings.Contains(text, "rate limit") || strings.Contains(text, "too many requests")
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either
+// a number of seconds or an HTTP date. Zero is returned if it's invalid.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+
+	if v == "" {
+		return 0
+	}
+
+	if n, err := strconv.Atoi(v); err == nil {
+		if n < 0 {
+			return 0
+		}
+
+		return time.Duration(n) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+
+	return 0
+}
+
+// retryable returns whether the result of an attempt should be retried.
+// Only failures that are likely to be transient are: 5xx statuses, rate
+// limiting, truncated responses, network timeouts and temporary errors,
+// and connection resets. Other errors, like a canceled context or a bad
+// URL, would fail the same way again.
+func retryable(ctx context.Context, resp *Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err == nil {
+		return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrTruncatedResponse) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var ne net.Error
+
+	if errors.As(err, &ne) {
+		if ne.Timeout() {
+			return true
+		}
+
+		// Temporary is deprecated, but is still how some errors,
+		// like those of the DNS resolver, say they're transient
+		if t, ok := ne.(interface{ Temporary() bool }); ok && t.Temporary() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Backoff returns how long to wait before retrying a request after the
+// attempt, which starts at zero, failed.
+type Backoff func(attempt int) time.Duration
+
+// Exponential returns a Backoff that waits base before the first retry,
+// and doubles the wait for each attempt after that, up to max.
+func Exponential(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := base << uint(attempt)
+
+		if d <= 0 || d > max {
+			d = max
+		}
+
+		return d
+	}
+}
+
+// DecorrelatedJitter returns a Backoff using the "decorrelated jitter"
+// strategy: each wait is random, between base and three times the previous
+// wait, up to max. This spreads out the retries of many clients better
+// than Exponential does. The previous wait is reset by each first retry,
+// and is shared by the concurrent requests of the clients it's set on.
+func DecorrelatedJitter(base, max time.Duration) Backoff {
+	var mu sync.Mutex
+
+	prev := base
+
+	return func(attempt int) time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if attempt == 0 || prev < base {
+			prev = base
+		}
+
+		d := base
+
+		if n := int64(prev*3 - base); n > 0 {
+			d += time.Duration(rand.Int63n(n))
+		}
+
+		if d > max {
+			d = max
+		}
+
+		prev = d
+
+		return d
+	}
+}
+
+// defaultBackoff is the Backoff used when one isn't set using SetBackoff.
+var defaultBackoff = Exponential(retryBase, retryMax)
+
+// retryDelay returns how long to wait before retrying after the attempt,
+// which starts at zero, failed with err. The time the API asked for is
+// used if there is one, otherwise the backoff is.
+func retryDelay(attempt int, err error, backoff Backoff) time.Duration {
+	var rle *RateLimitError
+
+	if errors.As(err, &rle) && rle.RetryAfter > 0 {
+		return rle.RetryAfter
+	}
+
+	if backoff == nil {
+		backoff = defaultBackoff
+	}
+
+	return backoff(attempt)
+}
+
+// sleep waits for d, or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+type RetrySuite struct {
+	srv  *httptest.Server
+	c    *bartapi.Client
+	hits int
+
+	// respond is called with the number of the request, starting at 1
+	respond func(rw http.ResponseWriter, n int)
+}
+
+var _ = Suite(&RetrySuite{})
+
+func (s *RetrySuite) SetUpTest(c *C) {
+	s.hits = 0
+	s.srv = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		s.hits++
+		s.respond(rw, s.hits)
+	}))
+	s.c = bartapi.New("testkey", bartapi.Endpoint(s.srv.URL))
+}
+
+func (s *RetrySuite) TearDownTest(c *C) {
+	s.srv.Close()
+}
+
+func (s *RetrySuite) TestRateLimited(c *C) {
+	s.respond = func(rw http.ResponseWriter, n int) {
+		rw.Header().Set("Retry-After", "2")
+		rw.WriteHeader(http.StatusTooManyRequests)
+	}
+
+	_, err := s.c.Pull("test", nil)
+	c.Assert(err, Not(IsNil))
+	c.Check(errors.Is(err, bartapi.ErrRateLimited), Equals, true)
+	c.Check(err, ErrorMatches, "bartapi: rate limited: retry after 2s")
+
+	var rle *bartapi.RateLimitError
+	c.Assert(errors.As(err, &rle), Equals, true)
+	c.Check(rle.RetryAfter, Equals, 2*time.Second)
+	c.Check(s.hits, Equals, 1)
+}
+
+func (s *RetrySuite) TestRateLimitedHTTPDate(c *C) {
+	s.respond = func(rw http.ResponseWriter, n int) {
+		rw.Header().Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+		rw.WriteHeader(http.StatusTooManyRequests)
+	}
+
+	_, err := s.c.Pull("test", nil)
+
+	var rle *bartapi.RateLimitError
+	c.Assert(errors.As(err, &rle), Equals, true)
+	c.Check(rle.RetryAfter > 55*time.Second && rle.RetryAfter <= time.Minute, Equals, true)
+}
+
+func (s *RetrySuite) TestRateLimitedMessage(c *C) {
+	s.respond = func(rw http.ResponseWriter, n int) {
+		fmt.Fprint(rw, "<root><message><error><text>Rate limit exceeded</text></error></message></root>")
+	}
+
+	_, err := s.c.Pull("test", nil)
+	c.Check(errors.Is(err, bartapi.ErrRateLimited), Equals, true)
+
+	// other errors are left for the caller to inspect
+	s.respond = func(rw http.ResponseWriter, n int) {
+		fmt.Fprint(rw, "<root><message><error><text>Invalid key</text></error></message></root>")
+	}
+
+	_, err = s.c.Pull("test", nil)
+	c.Check(err, IsNil)
+}
+
+func (s *RetrySuite) TestRetries(c *C) {
+	s.respond = func(rw http.ResponseWriter, n int) {
+		switch n {
+		case 1:
+			rw.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			rw.WriteHeader(http.StatusInternalServerError)
+		default:
+			fmt.Fprint(rw, "<root/>")
+		}
+	}
+
+	var attempts []int
+
+	s.c.SetRetries(2)
+	s.c.SetHooks(bartapi.Hooks{Request: func(ctx context.Context, info bartapi.RequestInfo) {
+		attempts = append(attempts, info.Attempt)
+	}})
+
+	resp, err := s.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(string(resp), Equals, "<root/>")
+	c.Check(s.hits, Equals, 3)
+	c.Check(attempts, DeepEquals, []int{0, 1, 2})
+}
+
+func (s *RetrySuite) TestRetriesHonorRetryAfter(c *C) {
+	s.respond = func(rw http.ResponseWriter, n int) {
+		if n == 1 {
+			rw.Header().Set("Retry-After", "1")
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		fmt.Fprint(rw, "<root/>")
+	}
+
+	s.c.SetRetries(1)
+
+	start := time.Now()
+
+	_, err := s.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(s.hits, Equals, 2)
+	c.Check(time.Since(start) >= time.Second, Equals, true)
+}
+
+func (s *RetrySuite) TestSetBackoff(c *C) {
+	s.respond = func(rw http.ResponseWriter, n int) {
+		if n < 3 {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		fmt.Fprint(rw, "<root/>")
+	}
+
+	var attempts []int
+
+	s.c.SetRetries(2)
+	s.c.SetBackoff(func(attempt int) time.Duration {
+		attempts = append(attempts, attempt)
+		return time.Millisecond
+	})
+
+	_, err := s.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(s.hits, Equals, 3)
+	c.Check(attempts, DeepEquals, []int{0, 1})
+}
+
+func (s *RetrySuite) TestExponential(c *C) {
+	b := bartapi.Exponential(100*time.Millisecond, time.Second)
+
+	c.Check(b(0), Equals, 100*time.Millisecond)
+	c.Check(b(1), Equals, 200*time.Millisecond)
+	c.Check(b(3), Equals, 800*time.Millisecond)
+	c.Check(b(4), Equals, time.Second)
+	c.Check(b(100), Equals, time.Second)
+}
+
+func (s *RetrySuite) TestDecorrelatedJitter(c *C) {
+	base, max := 10*time.Millisecond, 500*time.Millisecond
+	b := bartapi.DecorrelatedJitter(base, max)
+
+	prev := base
+
+	for i := 0; i < 50; i++ {
+		d := b(i)
+		c.Check(d >= base, Equals, true)
+		c.Check(d <= max, Equals, true)
+		c.Check(d < prev*3 || d == base, Equals, true)
+		prev = d
+	}
+
+	c.Check(b(0) < 3*base, Equals, true)
+}
+
+func (s *RetrySuite) TestRetriesExhausted(c *C) {
+	s.respond = func(rw http.ResponseWriter, n int) {
+		rw.WriteHeader(http.StatusTooManyRequests)
+	}
+
+	s.c.SetRetries(1)
+
+	_, err := s.c.Pull("test", nil)
+	c.Check(errors.Is(err, bartapi.ErrRateLimited), Equals, true)
+	c.Check(s.hits, Equals, 2)
+}
+
+func (s *RetrySuite) TestTruncatedResponse(c *C) {
+	const body = `<?xml version="1.0" encoding="utf-8"?><root><message>hello</message></root>`
+
+	s.respond = func(rw http.ResponseWriter, n int) {
+		if n == 1 {
+			rw.Write([]byte(body[:len(body)-20]))
+			return
+		}
+
+		rw.Write([]byte(body))
+	}
+
+	_, err := s.c.Pull("test", nil)
+	c.Check(errors.Is(err, bartapi.ErrTruncatedResponse), Equals, true)
+	c.Check(s.hits, Equals, 1)
+
+	s.hits = 0
+	s.c.SetRetries(1)
+
+	out, err := s.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(string(out), Equals, body)
+	c.Check(s.hits, Equals, 2)
+}
+
+func (s *RetrySuite) TestTruncatedConnection(c *C) {
+	// the connection is closed before the whole body is sent
+	s.respond = func(rw http.ResponseWriter, n int) {
+		rw.Header().Set("Content-Length", "100")
+		rw.Write([]byte("<root><message>"))
+	}
+
+	_, err := s.c.Pull("test", nil)
+	c.Check(errors.Is(err, bartapi.ErrTruncatedResponse), Equals, true)
+}
+
+func (s *RetrySuite) TestTruncatedRootEndTag(c *C) {
+	var body string
+
+	s.respond = func(rw http.ResponseWriter, n int) {
+		rw.Write([]byte(body))
+	}
+
+	// cut between elements, so every token is whole
+	for _, body = range []string{
+		`<?xml version="1.0" encoding="utf-8"?><root><message>hello</message>`,
+		`<root><message>hello</message></message>`,
+		`<?xml version="1.0" encoding="utf-8"?>` + "\n<root>\n",
+	} {
+		_, err := s.c.Pull("test", nil)
+		c.Check(errors.Is(err, bartapi.ErrTruncatedResponse), Equals, true, Commentf("%s", body))
+	}
+
+	for _, body = range []string{
+		`<?xml version="1.0" encoding="utf-8"?><root><message>hello</message></root>` + "\n",
+		`<root><message>hello</message></root >`,
+		`<?xml version="1.0" encoding="utf-8"?><root />`,
+		`<root></root><!-- served by test -->`,
+		`not xml`,
+	} {
+		out, err := s.c.Pull("test", nil)
+		c.Check(err, IsNil, Commentf("%s", body))
+		c.Check(string(out), Equals, body)
+	}
+}
+
+// netError is a net.Error for testing which errors are retried.
+type netError struct {
+	timeout, temporary bool
+}
+
+func (e netError) Error() string   { return "net error" }
+func (e netError) Timeout() bool   { return e.timeout }
+func (e netError) Temporary() bool { return e.temporary }
+
+func (s *RetrySuite) TestRetryable(c *C) {
+	tests := []struct {
+		name   string
+		status int
+		err    error
+		retry  bool
+	}{
+		{name: "5xx", status: http.StatusBadGateway, retry: true},
+		{name: "429", status: http.StatusTooManyRequests, retry: true},
+		{name: "4xx", status: http.StatusNotFound},
+		{name: "timeout", err: netError{timeout: true}, retry: true},
+		{name: "temporary", err: netError{temporary: true}, retry: true},
+		{name: "other net error", err: netError{}},
+		{name: "connection reset", err: &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, retry: true},
+		{name: "canceled", err: context.Canceled},
+		{name: "deadline exceeded", err: context.DeadlineExceeded},
+		{name: "other", err: errors.New("unsupported protocol scheme")},
+	}
+
+	for _, tt := range tests {
+		var hits int
+
+		s.c.SetRetries(1)
+		s.c.SetBackoff(func(int) time.Duration { return 0 })
+		s.c.SetHTTPClient(&http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			hits++
+
+			if tt.err != nil {
+				return nil, tt.err
+			}
+
+			rec := httptest.NewRecorder()
+			rec.WriteHeader(tt.status)
+
+			return rec.Result(), nil
+		})})
+
+		s.c.Pull("test", nil)
+
+		want := 1
+
+		if tt.retry {
+			want = 2
+		}
+
+		c.Check(hits, Equals, want, Commentf("%s", tt.name))
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi
+
+import (
+	"encoding"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// UnknownFieldError is a warning that a response has an element, or an
+// attribute, that isn't decoded in to the struct it was decoded in to.
+// It usually means the API has changed and data is being dropped.
+type UnknownFieldError struct {
+	// Path is the path of the element from the root, using the same
+	// syntax as the struct tags (e.g., "root>stations>station>foo").
+	Path string
+
+	// Attr is the name of the attribute, if the unknown field is an
+	// attribute of the element at Path.
+	Attr string
+}
+
+func (e *UnknownFieldError) Error() string {
+	if e.Attr != "" {
+		return "bartapi: unknown attribute in response: " + e.Path + " " + e.Attr
+	}
+
+	return "bartapi: unknown element in response: " + e.Path
+}
+
+// UnknownFields returns the elements and attributes of the XML in r that
+// wouldn't be decoded in to v by Decode. Each unknown field is returned
+// once, even if it repeats. The contents of unknown elements, and of types
+// that implement xml.Unmarshaler, aren't checked.
+//
+// This is more expensive than decoding, as the struct tags of v are walked
+// using reflection, so it's meant for catching changes to the API rather
+// than for use with every response.
+func UnknownFields(r io.Reader, v interface{}) ([]*UnknownFieldError, error) {
+	t := reflect.TypeOf(v)
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	root := schemaOf(t)
+
+	d := NewDecoder(r)
+
+	var unknown []*UnknownFieldError
+	var path []string
+	var stack []*schemaNode
+
+	seen := make(map[UnknownFieldError]bool)
+
+	report := func(e UnknownFieldError) {
+		if !seen[e] {
+			seen[e] = true
+			unknown = append(unknown, &UnknownFieldError{Path: e.Path, Attr: e.Attr})
+		}
+	}
+
+	for {
+		tok, err := d.Token()
+
+		if err == io.EOF {
+			return unknown, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			var n *schemaNode
+
+			if len(stack) == 0 {
+				n = root
+			} else if parent := stack[len(stack)-1]; parent != nil {
+				if n = parent.elems[tok.Name.Local]; n == nil && !parent.any {
+					report(UnknownFieldError{Path: strings.Join(append(path, tok.Name.Local), ">")})
+				}
+			}
+
+			path = append(path, tok.Name.Local)
+			stack = append(stack, n)
+
+			if n == nil || n.any {
+				continue
+			}
+
+			for _, a := range tok.Attr {
+				if !n.attrs[a.Name.Local] && a.Name.Space == "" {
+					report(UnknownFieldError{Path: strings.Join(path, ">"), Attr: a.Name.Local})
+				}
+			}
+
+		case xml.EndElement:
+			path = path[:len(path)-1]
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// schemaNode is the elements and attributes a type decodes.
+type schemaNode struct {
+	elems map[string]*schemaNode
+	attrs map[string]bool
+
+	// any is set when the type accepts any element or attribute
+	any bool
+}
+
+var (
+	schemaMu    sync.Mutex
+	schemaCache = make(map[reflect.Type]*schemaNode)
+
+	unmarshalerType     = reflect.TypeOf((*xml.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// schemaOf returns the schemaNode for the type t.
+func schemaOf(t reflect.Type) *schemaNode {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	return buildSchema(t)
+}
+
+func buildSchema(t reflect.Type) *schemaNode {
+	for t != nil && (t.Kind() == reflect.Ptr || (t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8)) {
+		t = t.Elem()
+	}
+
+	if n, ok := schemaCache[t]; ok {
+		return n
+	}
+
+	n := &schemaNode{elems: make(map[string]*schemaNode), attrs: make(map[string]bool)}
+
+	// cache before walking the fields, in case the type is recursive
+	schemaCache[t] = n
+
+	switch {
+	case t == nil || t.Kind() == reflect.Interface:
+		n.any = true
+	case reflect.PtrTo(t).Implements(unmarshalerType):
+		n.any = true
+	case t.Kind() != reflect.Struct || reflect.PtrTo(t).Implements(textUnmarshalerType):
+		// a leaf, which has no elements or attributes
+	default:
+		addFields(n, t)
+	}
+
+	return n
+}
+
+// addFields adds the fields of the struct type t to n, using the
+// same rules as encoding/xml.
+func addFields(n *schemaNode, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("xml")
+
+		if tag == "-" || f.Name == "XMLName" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		name, flags := parts[0], parts[1:]
+
+		// the name may be namespaced: "namespace-URL name"
+		if i := strings.LastIndex(name, " "); i >= 0 {
+			name = name[i+1:]
+		}
+
+		if f.Anonymous && name == "" && len(flags) == 0 {
+			ft := f.Type
+
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				addFields(n, ft)
+				continue
+			}
+		}
+
+		switch {
+		case hasFlag(flags, "attr"):
+			if name == "" {
+				name = f.Name
+			}
+
+			n.attrs[name] = true
+		case hasFlag(flags, "any"), hasFlag(flags, "innerxml"):
+			n.any = true
+		case hasFlag(flags, "chardata"), hasFlag(flags, "cdata"), hasFlag(flags, "comment"):
+		default:
+			if name == "" {
+				name = f.Name
+			}
+
+			elems := strings.Split(name, ">")
+			parent := n
+
+			for _, e := range elems[:len(elems)-1] {
+				child := parent.elems[e]
+
+				if child == nil {
+					child = &schemaNode{elems: make(map[string]*schemaNode), attrs: make(map[string]bool)}
+					parent.elems[e] = child
+				}
+
+				parent = child
+			}
+
+			parent.elems[elems[len(elems)-1]] = buildSchema(f.Type)
+		}
+	}
+}
+
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,69 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi_test
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+type SchemaSuite struct{}
+
+var _ = Suite(&SchemaSuite{})
+
+type schemaType struct {
+	bartapi.Envelope
+	Date  string `xml:"date"`
+	Items []struct {
+		ID   int    `xml:"id,attr"`
+		Name string `xml:"name"`
+		Any  struct {
+			Inner string `xml:",innerxml"`
+		} `xml:"any"`
+	} `xml:"items>item"`
+	Custom customType `xml:"custom"`
+}
+
+type customType struct{}
+
+func (*customType) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return d.Skip()
+}
+
+func (*SchemaSuite) TestUnknownFields(c *C) {
+	doc := `<?xml version="1.0" encoding="utf-8"?>
+<root>
+	<uri>http://example.org</uri>
+	<date>01/01/2019</date>
+	<time>12:00 PM</time>
+	<items>
+		<item id="1" color="red"><name>one</name><extra>1</extra><any><whatever a="1" /></any></item>
+		<item id="2" color="blue"><name>two</name><extra><deeper /></extra></item>
+	</items>
+	<custom><whatever /></custom>
+	<message><warning>hi</warning></message>
+</root>`
+
+	unknown, err := bartapi.UnknownFields(strings.NewReader(doc), &schemaType{})
+	c.Assert(err, IsNil)
+	c.Check(unknown, DeepEquals, []*bartapi.UnknownFieldError{
+		{Path: "root>time"},
+		{Path: "root>items>item", Attr: "color"},
+		{Path: "root>items>item>extra"},
+	})
+
+	c.Check(unknown[0], ErrorMatches, "bartapi: unknown element in response: root>time")
+	c.Check(unknown[1], ErrorMatches, "bartapi: unknown attribute in response: root>items>item color")
+
+	unknown, err = bartapi.UnknownFields(strings.NewReader(exampleXml), &xmlType{})
+	c.Assert(err, IsNil)
+	c.Check(unknown, HasLen, 0)
+
+	_, err = bartapi.UnknownFields(strings.NewReader("<root><oops></root>"), &xmlType{})
+	c.Check(err, NotNil)
+}
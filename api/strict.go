@@ -0,0 +1,167 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrUnexpectedRoot is returned by StrictDecode when the root element
+// of the XML doesn't match the one expected by the value being decoded.
+var ErrUnexpectedRoot = errors.New("bartapi: unexpected root element")
+
+// StrictDecode is a stricter version of Decode, for catching responses
+// that would otherwise decode successfully in to a struct full of zero
+// values. LenientDecode is the opposite, for when the missing sections are
+// expected. v must be a pointer to a struct with an XMLName field that names
+// the root element, like one embedding Envelope, and the root element of the
+// XML must match it. If the response envelope contains an error, it's
+// returned as an *APIError without decoding in to v.
+func StrictDecode(r io.Reader, v interface{}) error {
+	name, err := rootName(v)
+
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadAll(r)
+
+	if err != nil {
+		return err
+	}
+
+	d := NewDecoder(bytes.NewReader(data))
+
+	for {
+		tok, err := d.Token()
+
+		if err != nil {
+			return err
+		}
+
+		if start, ok := tok.(xml.StartElement); ok {
+			if start.Name.Local != name {
+				return fmt.Errorf("%w: expected <%s>, got <%s>", ErrUnexpectedRoot, name, start.Name.Local)
+			}
+
+			break
+		}
+	}
+
+	if e, err := DecodeEnvelope(data); err == nil && e.Err() != nil {
+		return e.Err()
+	}
+
+	return Decode(bytes.NewReader(data), v)
+}
+
+// LenientDecode decodes the XML in r in to v like Decode, and returns the
+// sections v expects that are missing from it, so partial responses can be
+// handled intentionally rather than as a struct with zero values. The
+// sections are the child elements of the root that the fields of v decode,
+// named by their path from the root using the syntax of UnknownFieldError
+// (e.g., "root>station"), and sorted. A missing section isn't an error.
+func LenientDecode(r io.Reader, v interface{}) ([]string, error) {
+	data, err := ioutil.ReadAll(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Decode(bytes.NewReader(data), v); err != nil {
+		return nil, err
+	}
+
+	t := reflect.TypeOf(v)
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	root := schemaOf(t)
+
+	if root.any {
+		return nil, nil
+	}
+
+	d := NewDecoder(bytes.NewReader(data))
+
+	var name string
+	var depth int
+
+	present := make(map[string]bool)
+
+	for {
+		tok, err := d.Token()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				name = tok.Name.Local
+			} else if depth == 1 {
+				present[tok.Name.Local] = true
+			}
+
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	var missing []string
+
+	for elem := range root.elems {
+		if !present[elem] {
+			missing = append(missing, name+">"+elem)
+		}
+	}
+
+	sort.Strings(missing)
+
+	return missing, nil
+}
+
+// rootName returns the root element name from the XMLName field of the
+// struct v points to.
+func rootName(v interface{}) (string, error) {
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return "", fmt.Errorf("bartapi: StrictDecode needs a pointer to a struct, got %T", v)
+	}
+
+	f, ok := rv.Elem().Type().FieldByName("XMLName")
+
+	if ok && f.Type == reflect.TypeOf(xml.Name{}) {
+		tag := strings.Split(f.Tag.Get("xml"), ",")[0]
+
+		// the tag may be namespaced: "namespace-URL name"
+		if i := strings.LastIndex(tag, " "); i >= 0 {
+			tag = tag[i+1:]
+		}
+
+		if tag != "" {
+			return tag, nil
+		}
+	}
+
+	return "", fmt.Errorf("bartapi: %T has no XMLName field naming the root element", v)
+}
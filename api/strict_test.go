@@ -0,0 +1,78 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi_test
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+type StrictSuite struct{}
+
+var _ = Suite(&StrictSuite{})
+
+type embeddedType struct {
+	bartapi.Envelope
+	Some string `xml:"somekey"`
+}
+
+func (*StrictSuite) TestStrictDecode(c *C) {
+	x := &xmlType{}
+	c.Assert(bartapi.StrictDecode(strings.NewReader(exampleXml), x), IsNil)
+	c.Check(x.Some, Equals, "hello!")
+
+	e := &embeddedType{}
+	c.Assert(bartapi.StrictDecode(strings.NewReader(exampleXml), e), IsNil)
+	c.Check(e.Some, Equals, "hello!")
+
+	err := bartapi.StrictDecode(strings.NewReader("<other><somekey>hi</somekey></other>"), &xmlType{})
+	c.Check(errors.Is(err, bartapi.ErrUnexpectedRoot), Equals, true)
+	c.Check(err, ErrorMatches, "bartapi: unexpected root element: expected <root>, got <other>")
+
+	// error envelopes have the same root, but shouldn't decode successfully
+	err = bartapi.StrictDecode(strings.NewReader("<root><message><error><text>Invalid key</text></error></message></root>"), &xmlType{})
+	_, ok := err.(*bartapi.APIError)
+	c.Check(ok, Equals, true)
+
+	// the lenient Decode still accepts it
+	c.Check(bartapi.Decode(strings.NewReader("<root><message><error><text>Invalid key</text></error></message></root>"), &xmlType{}), IsNil)
+
+	var untagged struct {
+		Some string `xml:"somekey"`
+	}
+
+	c.Check(bartapi.StrictDecode(strings.NewReader(exampleXml), &untagged), ErrorMatches, "bartapi: .* has no XMLName field naming the root element")
+	c.Check(bartapi.StrictDecode(strings.NewReader(exampleXml), xmlType{}), ErrorMatches, "bartapi: StrictDecode needs a pointer to a struct, got .*")
+	c.Check(bartapi.StrictDecode(strings.NewReader(""), &xmlType{}), Not(IsNil))
+}
+
+type combinedType struct {
+	bartapi.Envelope
+	Station struct {
+		Name string `xml:"name"`
+	} `xml:"station"`
+	Holidays []string `xml:"holidays>holiday>name"`
+	Time     string   `xml:"time"`
+}
+
+func (*StrictSuite) TestLenientDecode(c *C) {
+	v := &combinedType{}
+
+	missing, err := bartapi.LenientDecode(strings.NewReader("<root><uri>u</uri><station><name>MacArthur</name></station><message /></root>"), v)
+	c.Assert(err, IsNil)
+	c.Check(v.Station.Name, Equals, "MacArthur")
+	c.Check(missing, DeepEquals, []string{"root>holidays", "root>time"})
+
+	// empty sections are present
+	missing, err = bartapi.LenientDecode(strings.NewReader("<root><uri /><station /><holidays /><time /><message /></root>"), v)
+	c.Assert(err, IsNil)
+	c.Check(missing, HasLen, 0)
+
+	_, err = bartapi.LenientDecode(strings.NewReader("<root><station>"), v)
+	c.Check(errors.Is(err, bartapi.ErrTruncatedResponse), Equals, true)
+}
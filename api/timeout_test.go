@@ -0,0 +1,89 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+type TimeoutSuite struct {
+	srv *httptest.Server
+	c   *bartapi.Client
+}
+
+var _ = Suite(&TimeoutSuite{})
+
+func (s *TimeoutSuite) SetUpTest(c *C) {
+	s.srv = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}))
+	s.c = bartapi.New("testkey", bartapi.Endpoint(s.srv.URL))
+}
+
+func (s *TimeoutSuite) TearDownTest(c *C) {
+	s.srv.Close()
+}
+
+func (s *TimeoutSuite) TestSetTimeout(c *C) {
+	s.c.SetTimeout(20 * time.Millisecond)
+
+	_, err := s.c.PullResponse(context.Background(), "test", nil)
+	c.Check(errors.Is(err, context.DeadlineExceeded), Equals, true)
+
+	s.c.SetTimeout(0)
+
+	_, err = s.c.PullResponse(context.Background(), "test", nil)
+	c.Check(err, IsNil)
+}
+
+func (s *TimeoutSuite) TestPullTimeout(c *C) {
+	s.c.SetTimeout(20 * time.Millisecond)
+
+	// the override is longer than the server is slow
+	_, err := s.c.PullTimeout(context.Background(), "test", nil, time.Second)
+	c.Check(err, IsNil)
+
+	s.c.SetTimeout(0)
+
+	_, err = s.c.PullTimeout(context.Background(), "test", nil, 20*time.Millisecond)
+	c.Check(errors.Is(err, context.DeadlineExceeded), Equals, true)
+
+	// the context's deadline is shorter, so it wins
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	_, err = s.c.PullTimeout(ctx, "test", nil, time.Second)
+	c.Check(errors.Is(err, context.DeadlineExceeded), Equals, true)
+	c.Check(time.Since(start) < 150*time.Millisecond, Equals, true)
+}
+
+func (s *TimeoutSuite) TestNewTunedTransport(c *C) {
+	tr := bartapi.NewTunedTransport(time.Second, 20*time.Millisecond)
+	c.Check(tr.TLSHandshakeTimeout, Equals, time.Second)
+	c.Check(tr.ResponseHeaderTimeout, Equals, 20*time.Millisecond)
+
+	s.c.SetHTTPClient(&http.Client{Transport: tr})
+	s.c.SetRetries(0)
+
+	_, err := s.c.PullResponse(context.Background(), "test", nil)
+	c.Check(err, ErrorMatches, ".*timeout awaiting response headers.*")
+
+	// zero keeps the default transport's timeouts
+	tr = bartapi.NewTunedTransport(0, 0)
+	c.Check(tr.TLSHandshakeTimeout, Equals, http.DefaultTransport.(*http.Transport).TLSHandshakeTimeout)
+	c.Check(tr.ResponseHeaderTimeout, Equals, time.Duration(0))
+}
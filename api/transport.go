@@ -0,0 +1,101 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Dialer is what's used by the transport returned from NewTransportWithDialer
+// to open connections to the API. *net.Dialer satisfies the interface.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// NewTransportWithDialer returns a copy of http.DefaultTransport that opens
+// its connections using d. Pass the transport to SetHTTPClient in an
+// *http.Client to use it for requests.
+//
+// This is the hook for environments where the system resolver can't be
+// used. Split-horizon DNS can be supported by using a *net.Dialer with a
+// custom Resolver, and the API's IP can be pinned using a Dialer that
+// ignores the address it's given and dials the pinned one instead.
+func NewTransportWithDialer(d Dialer) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = d.DialContext
+	return t
+}
+
+// NewTunedTransport returns a copy of http.DefaultTransport with separate
+// timeouts for connecting and for waiting on the response. connectTimeout
+// bounds dialing and the TLS handshake, so an unreachable API is detected
+// quickly. responseHeaderTimeout bounds the time from sending the request
+// until the response headers arrive, which is when BART builds the
+// response; reading the body isn't limited by either of them. A zero
+// timeout keeps the default transport's value.
+//
+// For the BART API, a connect timeout of about 5 seconds and a response
+// header timeout of about 30 seconds work well: the large schedule
+// commands, like routesched, can take several seconds to start
+// responding. Use the timeout of SetTimeout, or the context, to bound
+// the whole request.
+func NewTunedTransport(connectTimeout, responseHeaderTimeout time.Duration) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if connectTimeout > 0 {
+		d := &net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}
+		t.DialContext = d.DialContext
+		t.TLSHandshakeTimeout = connectTimeout
+	}
+
+	if responseHeaderTimeout > 0 {
+		t.ResponseHeaderTimeout = responseHeaderTimeout
+	}
+
+	return t
+}
+
+// Use adds middleware that wraps the transport of the *http.Client used to
+// make requests, for things like logging, tracing, and metrics that work
+// at the HTTP level. Each call adds to the middleware set before it. The
+// context of the requests is the one passed to PullContext and friends, so
+// middleware can use the values in it, like the ID from WithRequestID.
+//
+// The middleware is applied in the order it's added: the first one is the
+// outermost, so it sees each request first and its response last, and the
+// last one wraps the transport itself. The transport is the one of the
+// client set with SetHTTPClient, or http.DefaultTransport. Retries go
+// through the middleware again, as each attempt is a separate round trip.
+func (c *Client) Use(mw ...func(http.RoundTripper) http.RoundTripper) {
+	c.mu.Lock()
+	c.middleware = append(c.middleware[:len(c.middleware):len(c.middleware)], mw...)
+	c.mu.Unlock()
+}
+
+// withMiddleware returns a copy of hc whose transport is wrapped in the
+// middleware, or hc itself if there isn't any.
+func withMiddleware(hc *http.Client, mw []func(http.RoundTripper) http.RoundTripper) *http.Client {
+	if len(mw) == 0 {
+		return hc
+	}
+
+	rt := hc.Transport
+
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	for i := len(mw) - 1; i >= 0; i-- {
+		rt = mw[i](rt)
+	}
+
+	wrapped := *hc
+	wrapped.Transport = rt
+
+	return &wrapped
+}
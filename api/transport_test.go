@@ -0,0 +1,80 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+type dialerFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+func (f dialerFunc) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return f(ctx, network, address)
+}
+
+func (t *TestSuite) TestNewTransportWithDialer(c *C) {
+	var dialed string
+
+	// pin the API's host to the test server
+	pinned := t.srv.Listener.Addr().String()
+
+	d := dialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed = address
+		return (&net.Dialer{}).DialContext(ctx, network, pinned)
+	})
+
+	cl := bartapi.New("testkey", bartapi.Endpoint(strings.Replace(t.srv.URL, pinned, "api.bart.example:80", 1)))
+	cl.SetHTTPClient(&http.Client{Transport: bartapi.NewTransportWithDialer(d)})
+
+	_, err := cl.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(dialed, Equals, "api.bart.example:80")
+}
+
+func (t *TestSuite) TestUse(c *C) {
+	var calls []string
+
+	mw := func(name string) func(http.RoundTripper) http.RoundTripper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" before")
+				resp, err := next.RoundTrip(req)
+				calls = append(calls, name+" after")
+				return resp, err
+			})
+		}
+	}
+
+	t.c.Use(mw("first"), mw("second"))
+	t.c.Use(mw("third"))
+
+	_, err := t.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(calls, DeepEquals, []string{
+		"first before", "second before", "third before",
+		"third after", "second after", "first after",
+	})
+
+	// the transport of the client's *http.Client is wrapped
+	var base int
+
+	t.c.SetHTTPClient(&http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		base++
+		return http.DefaultTransport.RoundTrip(req)
+	})})
+
+	calls = nil
+
+	_, err = t.c.Pull("test", nil)
+	c.Assert(err, IsNil)
+	c.Check(calls, HasLen, 6)
+	c.Check(base, Equals, 1)
+}
@@ -0,0 +1,92 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrTruncatedResponse is returned when a response body ends before its XML
+// document does, which usually means the connection dropped while it was
+// being read. Requests that fail with it are retried, if retries are enabled
+// using SetRetries.
+var ErrTruncatedResponse = errors.New("bartapi: truncated response")
+
+// truncated returns whether body is an XML document that ends early. Rather
+// than parsing the whole document, which Decode does later, only the start
+// tag of the root element and the end of the body are read: the body is
+// truncated if it doesn't end with the root's end tag. Bodies that aren't
+// XML, or are malformed in some other way, aren't truncated and are left for
+// Decode to report.
+func truncated(body []byte) bool {
+	body = bytes.TrimSpace(body)
+
+	if !bytes.HasPrefix(body, []byte("<")) {
+		return false
+	}
+
+	d := NewDecoder(bytes.NewReader(body))
+
+	for {
+		tok, err := d.RawToken()
+
+		if err != nil {
+			return isUnexpectedEOF(err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+
+		if !ok {
+			continue
+		}
+
+		// a self-closing root element is the whole document
+		if bytes.HasSuffix(body[:d.InputOffset()], []byte("/>")) {
+			return false
+		}
+
+		// trailing comments and processing instructions are rare
+		// enough to be left to Decode
+		if bytes.HasSuffix(body, []byte("-->")) || bytes.HasSuffix(body, []byte("?>")) {
+			return false
+		}
+
+		return !endsWithEndTag(body, start.Name)
+	}
+}
+
+// endsWithEndTag returns whether body ends with the end tag of name, which
+// may have whitespace before its closing bracket.
+func endsWithEndTag(body []byte, name xml.Name) bool {
+	if !bytes.HasSuffix(body, []byte(">")) {
+		return false
+	}
+
+	tag := name.Local
+
+	if name.Space != "" {
+		tag = name.Space + ":" + tag
+	}
+
+	body = bytes.TrimRight(body[:len(body)-1], " \t\r\n")
+
+	return bytes.HasSuffix(body, []byte("</"+tag))
+}
+
+// isUnexpectedEOF returns whether err is from the xml package reaching the
+// end of its input in the middle of a token.
+func isUnexpectedEOF(err error) bool {
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var se *xml.SyntaxError
+
+	return errors.As(err, &se) && strings.Contains(se.Msg, "unexpected EOF")
+}
@@ -0,0 +1,74 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// declSniffLen is how much of the input is read to find the XML
+// declaration. It's at the start of the document, and is short.
+const declSniffLen = 256
+
+var (
+	utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+	declEncodingRegexp = regexp.MustCompile(`^<\?xml[^>]*?\sencoding\s*=\s*["']([^"']*)["']`)
+)
+
+// newUTF8Decoder returns an *xml.Decoder reading from r. If the document
+// is UTF-8, going by its byte order mark or XML declaration, the decoder
+// reads it as is. Otherwise the decoder is the same as NewDecoder's, with
+// a CharsetReader to convert the document. A byte order mark is skipped,
+// as the xml package doesn't support them.
+func newUTF8Decoder(r io.Reader) *xml.Decoder {
+	br := bufio.NewReaderSize(r, declSniffLen)
+
+	// the error is returned by the decoder when it reads
+	head, _ := br.Peek(declSniffLen)
+
+	if bytes.HasPrefix(head, utf8BOM) {
+		br.Discard(len(utf8BOM))
+		return plainDecoder(br)
+	}
+
+	if utf8Decl(bytes.TrimLeft(head, " \t\r\n")) {
+		return plainDecoder(br)
+	}
+
+	return NewDecoder(br)
+}
+
+// plainDecoder returns an *xml.Decoder reading from r without converting
+// its charset, that knows the same entities as the one from NewDecoder.
+func plainDecoder(r io.Reader) *xml.Decoder {
+	d := xml.NewDecoder(r)
+	d.Entity = xml.HTMLEntity
+	return d
+}
+
+// utf8Decl returns whether the start of a document declares it's UTF-8,
+// which is the default if it doesn't have a declaration, or the declaration
+// doesn't include the encoding.
+func utf8Decl(head []byte) bool {
+	if !bytes.HasPrefix(head, []byte("<?xml")) {
+		return true
+	}
+
+	if m := declEncodingRegexp.FindSubmatch(head); m != nil {
+		// the xml package only handles this spelling by itself
+		return strings.EqualFold(string(m[1]), "utf-8")
+	}
+
+	// only trust the missing encoding if the whole declaration was read
+	end := bytes.Index(head, []byte("?>"))
+
+	return end >= 0 && !bytes.Contains(head[:end], []byte("encoding"))
+}
@@ -0,0 +1,89 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bartapi_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+func (*TestSuite) TestDecodeUTF8(c *C) {
+	docs := []string{
+		"\xEF\xBB\xBF<?xml version=\"1.0\" encoding=\"utf-8\"?><root><somekey>hello!</somekey></root>",
+		`<?xml version="1.0" encoding="UTF-8"?><root><somekey>hello!</somekey></root>`,
+		`<?xml version="1.0"?><root><somekey>hello!</somekey></root>`,
+		"\n  <?xml version='1.0' encoding='utf8'?><root><somekey>hello!</somekey></root>",
+		`<?xml version="1.0" encoding="ISO-8859-1"?><root><somekey>hello!</somekey></root>`,
+		`<root><somekey>hello!</somekey></root>`,
+	}
+
+	for _, doc := range docs {
+		x := &xmlType{}
+		c.Check(bartapi.Decode(strings.NewReader(doc), x), IsNil, Commentf("%q", doc))
+		c.Check(x.Some, Equals, "hello!", Commentf("%q", doc))
+	}
+
+	// other charsets still go through the charset reader
+	err := bartapi.Decode(strings.NewReader(`<?xml version="1.0" encoding="not-a-charset"?><root />`), &xmlType{})
+	c.Check(err, NotNil)
+}
+
+// largeSchedule returns a routesched response with n trains.
+func largeSchedule(n int) []byte {
+	var b bytes.Buffer
+
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?><root><date>02/11/2019</date><sched_num>47</sched_num><route>`)
+
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `<train trainId="6-%d" trainIdx="%d">`, i, i)
+
+		for _, stn := range []string{"DALY", "BALB", "GLEN", "24TH", "16TH", "CIVC", "POWL", "MONT", "EMBR", "WOAK", "LAKE", "FTVL", "COLS", "SANL", "BAYF", "HAYW", "SHAY", "UCTY", "FRMT", "WARM"} {
+			fmt.Fprintf(&b, `<stop station="%s" origTime="5:03 am" bikeflag="1" load="1" level="normal" />`, stn)
+		}
+
+		b.WriteString(`</train>`)
+	}
+
+	b.WriteString(`</route><message /></root>`)
+
+	return b.Bytes()
+}
+
+func BenchmarkDecode(b *testing.B) {
+	body := largeSchedule(500)
+
+	type schedule struct {
+		Trains []struct {
+			Stops []struct {
+				Station string `xml:"station,attr"`
+			} `xml:"stop"`
+		} `xml:"route>train"`
+	}
+
+	b.Run("fast path", func(b *testing.B) {
+		b.SetBytes(int64(len(body)))
+
+		for i := 0; i < b.N; i++ {
+			if err := bartapi.Decode(bytes.NewReader(body), &schedule{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("charset reader", func(b *testing.B) {
+		b.SetBytes(int64(len(body)))
+
+		for i := 0; i < b.N; i++ {
+			if err := bartapi.NewDecoder(bytes.NewReader(body)).Decode(&schedule{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
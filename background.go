@@ -0,0 +1,62 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import "context"
+
+// SetBackgroundContext sets the parent of the context used by the work the
+// client does in the background, outside of any one request, like writing
+// responses to the directory set by WithResponseSink. When ctx is done the
+// workers stop, and no new ones are started, so canceling it when your
+// program shuts down keeps them from outliving it. Setting a new context
+// cancels the work started using the previous one.
+//
+// Close cancels the background context as well, and then waits for the
+// running workers to return, so it isn't necessary to set one to clean up
+// a client that's being closed. The default is context.Background().
+func (c *Client) SetBackgroundContext(ctx context.Context) {
+	bg, cancel := context.WithCancel(ctx)
+
+	c.mu.Lock()
+	prev := c.bgCancel
+	c.bg, c.bgCancel = bg, cancel
+	c.mu.Unlock()
+
+	if prev != nil {
+		prev()
+	}
+}
+
+// background runs fn in a new goroutine with the background context, unless
+// that context is already done. Close waits for fn to return.
+func (c *Client) background(fn func(ctx context.Context)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.bg == nil || c.bg.Err() != nil {
+		return
+	}
+
+	c.bgWG.Add(1)
+
+	go func(ctx context.Context) {
+		defer c.bgWG.Done()
+		fn(ctx)
+	}(c.bg)
+}
+
+// stopBackground cancels the background context and waits for the workers
+// using it to return.
+func (c *Client) stopBackground() {
+	c.mu.Lock()
+	cancel := c.bgCancel
+	c.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	c.bgWG.Wait()
+}
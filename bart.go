@@ -5,3 +5,468 @@
 // Package bart is used for interacting with the Bay Area Rapid Transit (BART) API.
 // This package is a work in progress.
 package bart
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultBaseURL is the URL all of the BART API endpoints live under.
+const DefaultBaseURL = "http://api.bart.gov/api/"
+
+// Meta is metadata about the request that produced a response.
+// It's embedded in each of the response types.
+type Meta struct {
+	// Latency is the time it took to make the HTTP request
+	// and read the response body.
+	Latency time.Duration `xml:"-"`
+
+	// Legend is the description of the codes used in the response,
+	// if it was requested using the WithLegend option.
+	Legend Legend `xml:"-"`
+
+	// Stale is set when the request failed and the response is the last
+	// successful one, see SetStaleIfError. Latency is the original's.
+	Stale bool `xml:"-"`
+
+	// staleAge is the client's SetStaleScheduleAge, for IsStale
+	staleAge time.Duration
+}
+
+func (m *Meta) meta() *Meta { return m }
+
+// Client is a BART API client that decodes the responses in to the types
+// provided by this package. It uses a bartapi.Client for each of the API
+// endpoints under the hood.
+type Client struct {
+	advisory  *bartapi.Client
+	estimates *bartapi.Client
+	route     *bartapi.Client
+	schedule  *bartapi.Client
+	station   *bartapi.Client
+
+	mu        sync.Mutex
+	schedules *ScheduleListResponse
+	holidays  *HolidaysResponse
+	stations  *StationsResponse
+	routes    []RouteInfo
+	legend    Legend
+
+	hooks        bartapi.Hooks
+	strictSchema bool
+	clock        func() time.Time
+	entities     map[string]string
+	displayLoc   *time.Location
+	staleAge     time.Duration
+
+	quietPublicKey  bool
+	publicKeyWarned bool
+
+	coalesceWindow time.Duration
+	coalesced      map[string]coalesced
+
+	// scheduleCache is nil unless it's enabled using SetScheduleCache
+	scheduleCache map[string]scheduleEntry
+
+	// lastGood is nil unless it's enabled using SetStaleIfError
+	lastGood   map[string]lastGood
+	staleGrace time.Duration
+
+	// bg is the context of the work done in the background,
+	// see SetBackgroundContext
+	bg       context.Context
+	bgCancel context.CancelFunc
+	bgWG     sync.WaitGroup
+
+	// flight coalesces concurrent requests for cached responses
+	flight singleflight.Group
+}
+
+// New returns a new BART client using the provided API key.
+// If you don't have a key of your own, bartapi.PublicAPIKey can be used,
+// though the client then warns about it using the Warning hook unless
+// that's disabled using SetPublicKeyWarning.
+func New(key string) *Client {
+	return NewWithBaseURL(key, DefaultBaseURL)
+}
+
+// NewWithBaseURL is the same as New, except that the endpoints are
+// relative to baseURL instead of DefaultBaseURL. This is useful for
+// pointing the client at a proxy or a test server.
+func NewWithBaseURL(key, baseURL string) *Client {
+	endpoint := func(e bartapi.Endpoint) *bartapi.Client {
+		return bartapi.New(key, bartapi.Endpoint(baseURL+path.Base(string(e))))
+	}
+
+	c := &Client{
+		advisory:  endpoint(bartapi.AdvisoryEndpoint),
+		estimates: endpoint(bartapi.EstimatesEndpoint),
+		route:     endpoint(bartapi.RouteEndpoint),
+		schedule:  endpoint(bartapi.ScheduleEndpoint),
+		station:   endpoint(bartapi.StationEndpoint),
+	}
+
+	// the public key warning is sent once by this client,
+	// rather than once by each of the endpoints
+	c.each(func(api *bartapi.Client) { api.SetPublicKeyWarning(false) })
+
+	c.SetBackgroundContext(context.Background())
+
+	return c
+}
+
+// API returns the underlying bartapi.Client used for the endpoint e, for
+// making requests the typed methods don't cover. The endpoint is matched by
+// its path, so any of the bartapi endpoint constants can be used. Nil is
+// returned for unknown endpoints.
+func (c *Client) API(e bartapi.Endpoint) *bartapi.Client {
+	var found *bartapi.Client
+
+	c.each(func(api *bartapi.Client) {
+		if path.Base(string(api.URL())) == path.Base(string(e)) {
+			found = api
+		}
+	})
+
+	return found
+}
+
+// SetDefaultQuery sets query params that are added to every request made
+// by the client. Params passed to a specific request take precedence.
+func (c *Client) SetDefaultQuery(query map[string]string) {
+	c.each(func(api *bartapi.Client) { api.SetDefaultQuery(query) })
+}
+
+// SetVerifyURI enables, or disables, verification of the request URI the
+// API echoes in each response. See bartapi.Client.SetVerifyURI for details.
+func (c *Client) SetVerifyURI(verify bool) {
+	c.each(func(api *bartapi.Client) { api.SetVerifyURI(verify) })
+}
+
+// SetTimeout sets the default timeout of requests, for all of the API
+// endpoints. It can be overridden for a single call using the WithTimeout
+// option. Zero means that there's no timeout, which is the default.
+func (c *Client) SetTimeout(d time.Duration) {
+	c.each(func(api *bartapi.Client) { api.SetTimeout(d) })
+}
+
+// SetAllowedCommands limits the commands the client makes requests for,
+// for all of the API endpoints. See bartapi.Client.SetAllowedCommands
+// for details.
+func (c *Client) SetAllowedCommands(cmds ...string) {
+	c.each(func(api *bartapi.Client) { api.SetAllowedCommands(cmds...) })
+}
+
+// SetRetries sets how many times failed requests are retried.
+// See bartapi.Client.SetRetries for details.
+func (c *Client) SetRetries(n int) {
+	c.each(func(api *bartapi.Client) { api.SetRetries(n) })
+}
+
+// SetBackoff sets the strategy used to wait between retries on all of the
+// API endpoints. See bartapi.Exponential and bartapi.DecorrelatedJitter.
+func (c *Client) SetBackoff(b bartapi.Backoff) {
+	c.each(func(api *bartapi.Client) { api.SetBackoff(b) })
+}
+
+// Use adds HTTP middleware to all of the API endpoints. See
+// bartapi.Client.Use for the order it's applied in.
+func (c *Client) Use(mw ...func(http.RoundTripper) http.RoundTripper) {
+	c.each(func(api *bartapi.Client) { api.Use(mw...) })
+}
+
+// SetAcceptLanguage sets the Accept-Language header sent with the requests
+// to all of the API endpoints. See bartapi.Client.SetAcceptLanguage.
+func (c *Client) SetAcceptLanguage(tag string) {
+	c.each(func(api *bartapi.Client) { api.SetAcceptLanguage(tag) })
+}
+
+// SetRedirectPolicy sets the policy deciding which redirects are followed
+// on all of the API endpoints. See bartapi.Client.SetRedirectPolicy.
+func (c *Client) SetRedirectPolicy(p bartapi.RedirectPolicy) {
+	c.each(func(api *bartapi.Client) { api.SetRedirectPolicy(p) })
+}
+
+// SetLimiter sets the Limiter used to rate limit the client's requests.
+// The Limiter is shared across all of the API endpoints.
+func (c *Client) SetLimiter(l bartapi.Limiter) {
+	c.each(func(api *bartapi.Client) { api.SetLimiter(l) })
+}
+
+// SetBreaker sets the circuit breaker used to stop making requests while
+// the API is failing. The Breaker is shared across all of the API endpoints.
+// A nil Breaker disables it, which is the default.
+func (c *Client) SetBreaker(b *bartapi.Breaker) {
+	c.each(func(api *bartapi.Client) { api.SetBreaker(b) })
+}
+
+// SetHTTPClient sets the *http.Client used to make requests. A nil client
+// resets it to http.DefaultClient. To use a custom resolver, or to pin the
+// API's IP, use a transport from bartapi.NewTransportWithDialer. For
+// separate connect and response timeouts, use one from
+// bartapi.NewTunedTransport.
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.each(func(api *bartapi.Client) { api.SetHTTPClient(hc) })
+}
+
+// Close releases the resources held by the client, like the idle
+// connections of its *http.Client. This is important for long-lived
+// programs that create and discard clients. The client is unusable
+// after it's closed: requests return bartapi.ErrClosed. Close stops the
+// client's background work, and waits for it to finish, before returning.
+func (c *Client) Close() error {
+	c.stopBackground()
+
+	var err error
+
+	c.each(func(api *bartapi.Client) {
+		if cerr := api.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	})
+
+	return err
+}
+
+// SetEntities sets XML entities, in addition to the HTML ones, that are
+// replaced when decoding responses. The keys are the names of the entities
+// without the & and ;, like "nbsp". Nil clears them. See
+// bartapi.DecodeOptions.
+func (c *Client) SetEntities(entities map[string]string) {
+	c.mu.Lock()
+	c.entities = entities
+	c.mu.Unlock()
+}
+
+// SetHooks sets the Hooks called by the client, for all of the API
+// endpoints. The zero value disables them, which is the default.
+func (c *Client) SetHooks(h bartapi.Hooks) {
+	c.mu.Lock()
+	c.hooks = h
+	c.mu.Unlock()
+
+	c.each(func(api *bartapi.Client) { api.SetHooks(h) })
+}
+
+// SetStrictSchema enables, or disables, checking responses for elements
+// and attributes that aren't decoded in to the response types. Each one
+// found is passed to the Warning hook as a *bartapi.UnknownFieldError,
+// which gives early warning of API changes that would otherwise silently
+// drop data. Responses are still decoded as usual. It's disabled by default
+// as it's more expensive than decoding.
+func (c *Client) SetStrictSchema(strict bool) {
+	c.mu.Lock()
+	c.strictSchema = strict
+	c.mu.Unlock()
+}
+
+// SetClock sets the function used to get the current time, for the methods
+// that default to now, like ScheduleType with a zero time. This is mostly
+// useful for freezing time in tests. A nil func resets it to time.Now, which
+// is the default.
+func (c *Client) SetClock(now func() time.Time) {
+	c.mu.Lock()
+	c.clock = now
+	c.mu.Unlock()
+}
+
+// now returns the current time from the clock set using SetClock.
+func (c *Client) now() time.Time {
+	c.mu.Lock()
+	clock := c.clock
+	c.mu.Unlock()
+
+	if clock == nil {
+		return time.Now()
+	}
+
+	return clock()
+}
+
+// SetDisplayLocation sets the location the times parsed from responses are
+// in, like the Departs field of a Leg, for showing them to a user in their
+// own timezone. Only the location of the times changes, they're the same
+// instants, and the dates and times the API works with are still in Pacific
+// time. Nil resets it to Pacific, which is the default.
+func (c *Client) SetDisplayLocation(loc *time.Location) {
+	c.mu.Lock()
+	c.displayLoc = loc
+	c.mu.Unlock()
+}
+
+// SetStaleScheduleAge sets how long after the start of its date a schedule
+// response from the client is considered stale by its IsStale method. Zero
+// resets it to the default of 27 hours, as BART's service day runs until
+// about 3am the next day.
+func (c *Client) SetStaleScheduleAge(d time.Duration) {
+	c.mu.Lock()
+	c.staleAge = d
+	c.mu.Unlock()
+}
+
+// DisplayTime returns t in the location set using SetDisplayLocation.
+func (c *Client) DisplayTime(t time.Time) time.Time {
+	return t.In(c.displayLocation())
+}
+
+// displayLocation returns the location set using SetDisplayLocation.
+func (c *Client) displayLocation() *time.Location {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.displayLoc == nil {
+		return Pacific
+	}
+
+	return c.displayLoc
+}
+
+// SetPublicKeyWarning sets whether bartapi.ErrPublicAPIKey is passed to
+// the Warning hook when the client is using bartapi.PublicAPIKey, which it
+// is by default. The warning is only sent once, before the first request.
+func (c *Client) SetPublicKeyWarning(warn bool) {
+	c.mu.Lock()
+	c.quietPublicKey = !warn
+	c.mu.Unlock()
+}
+
+// warnPublicKey sends the public key warning, if it's needed.
+func (c *Client) warnPublicKey(ctx context.Context) {
+	if c.advisory.Key() != bartapi.PublicAPIKey {
+		return
+	}
+
+	c.mu.Lock()
+	warn := c.hooks.Warning != nil && !c.quietPublicKey && !c.publicKeyWarned
+	c.publicKeyWarned = c.publicKeyWarned || warn
+	c.mu.Unlock()
+
+	if warn {
+		c.warn(ctx, bartapi.ErrPublicAPIKey)
+	}
+}
+
+// warn calls the Warning hook, if one is set.
+func (c *Client) warn(ctx context.Context, err error) {
+	c.mu.Lock()
+	fn := c.hooks.Warning
+	c.mu.Unlock()
+
+	if fn != nil {
+		fn(ctx, err)
+	}
+}
+
+// each calls fn with the API client of each endpoint.
+func (c *Client) each(fn func(*bartapi.Client)) {
+	for _, api := range []*bartapi.Client{c.advisory, c.estimates, c.route, c.schedule, c.station} {
+		fn(api)
+	}
+}
+
+// shared calls fn once for concurrent calls with the same key, using
+// flight. fn is run with a context of its own rather than the first
+// caller's, so that caller canceling doesn't fail the others; the client's
+// timeout still applies to its requests. Each caller stops waiting when its
+// ctx is done, returning its error.
+func (c *Client) shared(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	ch := c.flight.DoChan(key, func() (interface{}, error) {
+		return fn(context.Background())
+	})
+
+	select {
+	case r := <-ch:
+		return r.Val, r.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// get pulls cmd from the API client and decodes the response in to v.
+// If the response envelope contains an error, it's returned as a
+// *bartapi.APIError. Lesser messages are left for the caller to
+// inspect using the response's Inspect method.
+func (c *Client) get(ctx context.Context, api *bartapi.Client, cmd string, query map[string]string, v interface{}, opts ...Option) error {
+	c.warnPublicKey(ctx)
+
+	o := newOptions(opts)
+
+	resp, stale, err := c.pullStale(ctx, api, cmd, query, func() (*bartapi.Response, error) {
+		if o.timeout != nil {
+			return api.PullTimeout(ctx, cmd, query, *o.timeout)
+		}
+
+		return api.PullResponse(ctx, cmd, query)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if o.sinkDir != "" && !stale {
+		c.sink(ctx, o.sinkDir, cmd, resp.Body)
+	}
+
+	c.mu.Lock()
+	strict, entities := c.strictSchema, c.entities
+	c.mu.Unlock()
+
+	if err := bartapi.DecodeWithOptions(bytes.NewReader(resp.Body), v, bartapi.DecodeOptions{Entity: entities}); err != nil {
+		return err
+	}
+
+	if strict {
+		unknown, err := bartapi.UnknownFields(bytes.NewReader(resp.Body), v)
+
+		if err != nil {
+			return err
+		}
+
+		for _, u := range unknown {
+			c.warn(ctx, fmt.Errorf("bart: %s response: %w", cmd, u))
+		}
+	}
+
+	if e, ok := v.(interface {
+		Err() error
+	}); ok {
+		if err := e.Err(); err != nil {
+			return err
+		}
+	}
+
+	if x, ok := v.(interface {
+		setExtra(body []byte) error
+	}); ok && o.extraAttrs {
+		if err := x.setExtra(resp.Body); err != nil {
+			return err
+		}
+	}
+
+	if m, ok := v.(interface {
+		meta() *Meta
+	}); ok {
+		m.meta().Latency = resp.Latency
+		m.meta().Stale = stale
+
+		c.mu.Lock()
+		m.meta().staleAge = c.staleAge
+		c.mu.Unlock()
+
+		if query["l"] == "1" {
+			if e, err := bartapi.DecodeEnvelope(resp.Body); err == nil {
+				m.meta().Legend = ParseLegend(e.Message.Legend)
+			}
+		}
+	}
+
+	return nil
+}
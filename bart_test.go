@@ -0,0 +1,219 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theckman/go-bart"
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+// fixtureServer serves the files in testdata/ as API responses. The
+// file is picked based on the cmd and orig (or route) params: a request
+// for cmd=stninfo&orig=MCAR is served testdata/stninfo_mcar.xml, falling
+// back to testdata/stninfo.xml if that doesn't exist.
+type fixtureServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	cmds    map[string]int
+	queries map[string]url.Values
+}
+
+func newFixtureServer() *fixtureServer {
+	f := &fixtureServer{cmds: make(map[string]int), queries: make(map[string]url.Values)}
+	f.Server = httptest.NewServer(f)
+	return f
+}
+
+func (f *fixtureServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	cmd := req.FormValue("cmd")
+
+	f.mu.Lock()
+	f.cmds[cmd]++
+	f.queries[cmd] = req.Form
+	f.mu.Unlock()
+
+	names := []string{cmd + ".xml"}
+
+	key := req.FormValue("orig")
+
+	if key == "" {
+		key = req.FormValue("route")
+	}
+
+	if key != "" {
+		names = append([]string{cmd + "_" + strings.ToLower(key) + ".xml"}, names...)
+	}
+
+	for _, name := range names {
+		body, err := ioutil.ReadFile(filepath.Join("testdata", name))
+
+		if err == nil {
+			rw.Write(body)
+			return
+		}
+	}
+
+	http.NotFound(rw, req)
+}
+
+// query returns the query params of the last request for cmd.
+func (f *fixtureServer) query(cmd string) url.Values {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.queries[cmd]
+}
+
+// count returns how many times cmd has been requested.
+func (f *fixtureServer) count(cmd string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.cmds[cmd]
+}
+
+type TestSuite struct {
+	srv *fixtureServer
+	c   *bart.Client
+}
+
+var _ = Suite(&TestSuite{})
+
+func (t *TestSuite) SetUpTest(c *C) {
+	t.srv = newFixtureServer()
+	t.c = bart.NewWithBaseURL("testkey", t.srv.URL+"/")
+}
+
+func (t *TestSuite) TearDownTest(c *C) {
+	t.srv.Close()
+}
+
+func (t *TestSuite) TestAPI(c *C) {
+	api := t.c.API(bartapi.StationEndpoint)
+	c.Assert(api, NotNil)
+	c.Check(api.URL(), Equals, bartapi.Endpoint(t.srv.URL+"/stn.aspx"))
+	c.Check(api.Key(), Equals, "testkey")
+
+	c.Check(t.c.API(bartapi.Endpoint("nope.aspx")), IsNil)
+}
+
+func (t *TestSuite) TestClose(c *C) {
+	c.Assert(t.c.Close(), IsNil)
+
+	_, err := t.c.GetStations(context.Background())
+	c.Check(err, Equals, bartapi.ErrClosed)
+
+	_, err = t.c.GetAdvisories(context.Background())
+	c.Check(err, Equals, bartapi.ErrClosed)
+}
+
+func (t *TestSuite) TestSetHooks(c *C) {
+	var mu sync.Mutex
+	var cmds []string
+
+	t.c.SetHooks(bartapi.Hooks{Request: func(ctx context.Context, info bartapi.RequestInfo) {
+		mu.Lock()
+		cmds = append(cmds, info.Cmd)
+		mu.Unlock()
+
+		c.Check(info.Query["key"], Equals, "***tkey")
+	}})
+
+	_, err := t.c.GetStations(context.Background())
+	c.Assert(err, IsNil)
+
+	_, err = t.c.GetAdvisories(context.Background())
+	c.Assert(err, IsNil)
+
+	c.Check(cmds, DeepEquals, []string{"stns", "bsa"})
+}
+
+func (t *TestSuite) TestWithTimeout(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	t.c.SetTimeout(20 * time.Millisecond)
+
+	_, err := t.c.GetAdvisories(context.Background())
+	c.Check(err, NotNil)
+
+	r, err := t.c.GetAdvisories(context.Background(), bart.WithTimeout(time.Second))
+	c.Assert(err, IsNil)
+	c.Check(r.Advisories, Not(HasLen), 0)
+
+	// the caller's deadline is shorter, so it wins
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = t.c.GetAdvisories(ctx, bart.WithTimeout(time.Second))
+	c.Check(err, NotNil)
+}
+
+func (t *TestSuite) TestPublicKeyWarning(c *C) {
+	var mu sync.Mutex
+	var warnings []error
+
+	hooks := bartapi.Hooks{Warning: func(ctx context.Context, err error) {
+		mu.Lock()
+		warnings = append(warnings, err)
+		mu.Unlock()
+	}}
+
+	pub := bart.NewWithBaseURL(bartapi.PublicAPIKey, t.srv.URL+"/")
+	pub.SetHooks(hooks)
+
+	// only one warning, not one for each of the endpoints
+	_, err := pub.GetAdvisories(context.Background())
+	c.Assert(err, IsNil)
+
+	_, err = pub.GetStations(context.Background())
+	c.Assert(err, IsNil)
+
+	c.Check(warnings, DeepEquals, []error{bartapi.ErrPublicAPIKey})
+
+	warnings = nil
+
+	quiet := bart.NewWithBaseURL(bartapi.PublicAPIKey, t.srv.URL+"/")
+	quiet.SetHooks(hooks)
+	quiet.SetPublicKeyWarning(false)
+
+	_, err = quiet.GetAdvisories(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(warnings, HasLen, 0)
+}
+
+func (t *TestSuite) TestSetAllowedCommands(c *C) {
+	t.c.SetAllowedCommands("stns", "routes")
+
+	_, err := t.c.GetStations(context.Background())
+	c.Check(err, IsNil)
+
+	_, err = t.c.GetAdvisories(context.Background())
+	c.Check(errors.Is(err, bartapi.ErrCommandNotAllowed), Equals, true)
+	c.Check(t.srv.count("bsa"), Equals, 0)
+}
@@ -0,0 +1,32 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"fmt"
+	"strings"
+)
+
+// xmlBool is a bool that decodes all of the encodings BART uses for them,
+// which vary by field: "1" and "0", "true" and "false", and "yes" and "no"
+// (or "y" and "n"). An empty value is false.
+//
+// It's used in the UnmarshalXML methods of the types with flags, so
+// that their fields can be plain bools.
+type xmlBool bool
+
+// UnmarshalText satisfies the encoding.TextUnmarshaler interface.
+func (b *xmlBool) UnmarshalText(text []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(text))) {
+	case "1", "true", "yes", "y":
+		*b = true
+	case "0", "false", "no", "n", "":
+		*b = false
+	default:
+		return fmt.Errorf("bart: invalid boolean %q", string(text))
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/theckman/go-bart"
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+var boolEncodings = []struct {
+	text string
+	want bool
+}{
+	{"1", true},
+	{"0", false},
+	{"true", true},
+	{"false", false},
+	{"True", true},
+	{"yes", true},
+	{"no", false},
+	{"Y", true},
+	{"N", false},
+	{" 1 ", true},
+	{"", false},
+}
+
+func (*TestSuite) TestBoolEncodings(c *C) {
+	for _, tt := range boolEncodings {
+		comment := Commentf("%q", tt.text)
+
+		var access bart.StationAccessResponse
+
+		doc := fmt.Sprintf(`<root><stations><station parking_flag="%[1]s" bike_flag="%[1]s" bike_station_flag="%[1]s" locker_flag="%[1]s" /></stations></root>`, tt.text)
+		c.Assert(bartapi.Decode(strings.NewReader(doc), &access), IsNil, comment)
+		c.Check(access.Station.ParkingFlag, Equals, tt.want, comment)
+		c.Check(access.Station.BikeFlag, Equals, tt.want, comment)
+		c.Check(access.Station.BikeStationFlag, Equals, tt.want, comment)
+		c.Check(access.Station.LockerFlag, Equals, tt.want, comment)
+
+		var etd bart.EstimatesResponse
+
+		doc = fmt.Sprintf(`<root><station><etd><limited>%[1]s</limited><estimate><bikeflag>%[1]s</bikeflag></estimate></etd></station></root>`, tt.text)
+		c.Assert(bartapi.Decode(strings.NewReader(doc), &etd), IsNil, comment)
+		c.Check(etd.Stations[0].ETDs[0].Limited, Equals, tt.want, comment)
+		c.Check(etd.Stations[0].ETDs[0].Estimates[0].BikeFlag, Equals, tt.want, comment)
+
+		var sched bart.StationScheduleResponse
+
+		doc = fmt.Sprintf(`<root><station><item bikeflag="%s" /></station></root>`, tt.text)
+		c.Assert(bartapi.Decode(strings.NewReader(doc), &sched), IsNil, comment)
+		c.Check(sched.Station.Trains[0].BikeFlag, Equals, tt.want, comment)
+
+		var trips bart.TripsResponse
+
+		doc = fmt.Sprintf(`<root><schedule><request><trip><leg bikeflag="%s" /></trip></request></schedule></root>`, tt.text)
+		c.Assert(bartapi.Decode(strings.NewReader(doc), &trips), IsNil, comment)
+		c.Check(trips.Trips[0].Legs[0].BikeFlag, Equals, tt.want, comment)
+	}
+
+	var access bart.StationAccessResponse
+
+	err := bartapi.Decode(strings.NewReader(`<root><stations><station parking_flag="maybe" /></stations></root>`), &access)
+	c.Check(err, ErrorMatches, `bart: invalid boolean "maybe"`)
+}
@@ -0,0 +1,119 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// realtimeCmds are the commands whose responses change from moment to
+// moment, which are coalesced when a window is set using SetCoalesceWindow.
+var realtimeCmds = map[string]bool{"etd": true, "bsa": true, "count": true, "elev": true}
+
+// coalesced is a response shared by the calls in a coalescing window.
+type coalesced struct {
+	resp *bartapi.Response
+	at   time.Time
+}
+
+// SetCoalesceWindow sets how long the response of a real-time request, like
+// GetEstimates or GetAdvisories, is shared with identical calls. Calls made
+// while an identical request is in flight wait for it and share its response,
+// as do calls made within d of it finishing. Calls are identical when they
+// use the same command and params. Each call still decodes the response in
+// to its own value, so they don't share memory.
+//
+// This is meant for apps with several views polling the same data, and the
+// window should be short, like a second. Zero, the default, disables it.
+// As the calls share a request, they also share the outcome of the context
+// of the call that made it.
+func (c *Client) SetCoalesceWindow(d time.Duration) {
+	c.mu.Lock()
+	c.coalesceWindow = d
+	c.coalesced = nil
+	c.mu.Unlock()
+}
+
+// pullCoalesced calls pull, sharing the response with identical calls if
+// the command is a real-time one and a coalescing window is set.
+func (c *Client) pullCoalesced(api *bartapi.Client, cmd string, query map[string]string, pull func() (*bartapi.Response, error)) (*bartapi.Response, error) {
+	c.mu.Lock()
+	window := c.coalesceWindow
+	c.mu.Unlock()
+
+	if window <= 0 || !realtimeCmds[cmd] {
+		return pull()
+	}
+
+	key := coalesceKey(api, cmd, query)
+
+	c.mu.Lock()
+	recent, ok := c.coalesced[key]
+	c.mu.Unlock()
+
+	if ok && time.Since(recent.at) < window {
+		return recent.resp, nil
+	}
+
+	v, err, _ := c.flight.Do("coalesce:"+key, func() (interface{}, error) {
+		resp, err := pull()
+
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+
+		c.mu.Lock()
+
+		if c.coalesced == nil {
+			c.coalesced = make(map[string]coalesced)
+		}
+
+		// drop the responses whose windows have passed
+		for k, r := range c.coalesced {
+			if now.Sub(r.at) >= window {
+				delete(c.coalesced, k)
+			}
+		}
+
+		c.coalesced[key] = coalesced{resp: resp, at: now}
+		c.mu.Unlock()
+
+		return resp, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*bartapi.Response), nil
+}
+
+// coalesceKey returns the key of a request, made up of the endpoint,
+// the command, and the params in sorted order.
+func coalesceKey(api *bartapi.Client, cmd string, query map[string]string) string {
+	keys := make([]string, 0, len(query))
+
+	for k := range query {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var b strings.Builder
+
+	b.WriteString(string(api.URL()) + "?cmd=" + cmd)
+
+	for _, k := range keys {
+		b.WriteString("&" + k + "=" + query[k])
+	}
+
+	return b.String()
+}
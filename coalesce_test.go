@@ -0,0 +1,80 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestSetCoalesceWindow(c *C) {
+	fixtures := t.srv.Config.Handler
+	release := make(chan struct{})
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") == "etd" {
+			<-release
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	t.c.SetCoalesceWindow(time.Minute)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			r, err := t.c.GetEstimates(context.Background(), "MCAR")
+			c.Check(err, IsNil)
+			c.Check(r.Stations, HasLen, 1)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	c.Check(t.srv.count("etd"), Equals, 1)
+
+	// within the window the response is reused, and the calls
+	// don't share the decoded values
+	r1, err := t.c.GetEstimates(context.Background(), "MCAR")
+	c.Assert(err, IsNil)
+
+	r1.Stations = nil
+
+	r2, err := t.c.GetEstimates(context.Background(), "MCAR")
+	c.Assert(err, IsNil)
+	c.Check(r2.Stations, HasLen, 1)
+	c.Check(t.srv.count("etd"), Equals, 1)
+
+	// other params aren't coalesced
+	_, err = t.c.GetEstimates(context.Background(), "12TH")
+	c.Assert(err, IsNil)
+	c.Check(t.srv.count("etd"), Equals, 2)
+
+	// nor are commands that aren't real-time
+	for i := 0; i < 2; i++ {
+		_, err = t.c.GetRoutes(context.Background())
+		c.Assert(err, IsNil)
+	}
+
+	c.Check(t.srv.count("routes"), Equals, 2)
+
+	t.c.SetCoalesceWindow(0)
+
+	_, err = t.c.GetEstimates(context.Background(), "MCAR")
+	c.Assert(err, IsNil)
+	c.Check(t.srv.count("etd"), Equals, 3)
+}
@@ -0,0 +1,65 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"sync"
+)
+
+// Dashboard is the advisories, train count, and estimates for a station,
+// fetched together by GetDashboard. Each section is fetched separately, so
+// if one fails its Err field is set and the section is nil, without
+// affecting the others.
+type Dashboard struct {
+	Station string
+
+	Advisories    *AdvisoriesResponse
+	AdvisoriesErr error
+
+	TrainCount    *TrainCountResponse
+	TrainCountErr error
+
+	Estimates    *EstimatesResponse
+	EstimatesErr error
+}
+
+// GetDashboard concurrently fetches the current advisories, the number of
+// active trains, and the estimates for the station with the abbreviation
+// station. The options are passed to GetEstimates.
+//
+// Errors fetching the sections are set on the Dashboard. A MultiError,
+// keyed by the command of each section, is only returned if all of them
+// failed.
+func (c *Client) GetDashboard(ctx context.Context, station string, opts ...Option) (*Dashboard, error) {
+	d := &Dashboard{Station: station}
+
+	var wg sync.WaitGroup
+
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		d.Advisories, d.AdvisoriesErr = c.GetAdvisories(ctx)
+	}()
+
+	go func() {
+		defer wg.Done()
+		d.TrainCount, d.TrainCountErr = c.GetTrainCount(ctx)
+	}()
+
+	go func() {
+		defer wg.Done()
+		d.Estimates, d.EstimatesErr = c.GetEstimates(ctx, station, opts...)
+	}()
+
+	wg.Wait()
+
+	if d.AdvisoriesErr != nil && d.TrainCountErr != nil && d.EstimatesErr != nil {
+		return d, MultiError{"bsa": d.AdvisoriesErr, "count": d.TrainCountErr, "etd": d.EstimatesErr}
+	}
+
+	return d, nil
+}
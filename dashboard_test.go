@@ -0,0 +1,48 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+
+	"github.com/theckman/go-bart"
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestGetDashboard(c *C) {
+	d, err := t.c.GetDashboard(context.Background(), "MCAR", bart.EstimateDirection(bart.North))
+	c.Assert(err, IsNil)
+	c.Check(d.Station, Equals, "MCAR")
+
+	c.Assert(d.AdvisoriesErr, IsNil)
+	c.Check(d.Advisories.Advisories, HasLen, 4)
+
+	c.Assert(d.TrainCountErr, IsNil)
+	c.Check(d.TrainCount.TrainCount, Equals, 52)
+
+	c.Assert(d.EstimatesErr, IsNil)
+	c.Assert(d.Estimates.Stations, HasLen, 1)
+	c.Check(d.Estimates.Stations[0].ETDs, HasLen, 2)
+
+	c.Check(t.srv.query("etd").Get("dir"), Equals, "n")
+}
+
+func (t *TestSuite) TestGetDashboardErrors(c *C) {
+	// there's no fixture for the station
+	d, err := t.c.GetDashboard(context.Background(), "NOPE")
+	c.Assert(err, IsNil)
+	c.Check(d.AdvisoriesErr, IsNil)
+	c.Check(d.TrainCountErr, IsNil)
+	c.Check(d.EstimatesErr, NotNil)
+	c.Check(d.Estimates, IsNil)
+
+	c.Assert(t.c.Close(), IsNil)
+
+	d, err = t.c.GetDashboard(context.Background(), "MCAR")
+	c.Assert(err, FitsTypeOf, bart.MultiError{})
+	c.Check(err.(bart.MultiError), HasLen, 3)
+	c.Check(d.EstimatesErr, Equals, bartapi.ErrClosed)
+}
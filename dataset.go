@@ -0,0 +1,95 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"sync"
+)
+
+// StationData is the combined information about a single station.
+// If fetching the details of the station failed, Err is set and
+// Info and/or Access may be nil.
+type StationData struct {
+	Station Station
+	Info    *StationInfo
+	Access  *StationAccess
+	Err     error
+}
+
+// BuildStationDataset fetches the list of stations, and then the info
+// and access details for each of them. The details are fetched
+// concurrently, subject to any Limiter set on the client and the
+// WithConcurrency option. The options are passed on to each of the calls,
+// and the function set using WithProgress is called after each station.
+// An error is only returned if the station list couldn't be fetched; errors
+// for individual stations are set on their StationData.
+func (c *Client) BuildStationDataset(ctx context.Context, opts ...Option) ([]StationData, error) {
+	o := newOptions(opts)
+
+	stations, err := c.GetStations(ctx, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]StationData, len(stations.Stations))
+	index := make(map[string]*StationData, len(data))
+	abbrs := make([]string, len(data))
+
+	for i, s := range stations.Stations {
+		data[i].Station = s
+		index[s.Abbr] = &data[i]
+		abbrs[i] = s.Abbr
+	}
+
+	// the abbreviations aren't GTFS stop IDs
+	sub := append(append([]Option(nil), opts...), stationAbbrs())
+
+	var mu sync.Mutex
+	var done int
+
+	forEach(abbrs, o.concurrency, func(abbr string) error {
+		d := index[abbr]
+
+		err := c.stationData(ctx, d, abbr, sub)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		done++
+
+		if o.progress != nil {
+			o.progress(done, len(abbrs))
+		}
+
+		return err
+	})
+
+	return data, nil
+}
+
+// stationData fetches the info and access details of the station in to d.
+func (c *Client) stationData(ctx context.Context, d *StationData, abbr string, opts []Option) error {
+	info, err := c.GetStationInfo(ctx, abbr, opts...)
+
+	if err != nil {
+		d.Err = err
+		return err
+	}
+
+	d.Info = &info.Station
+
+	access, err := c.GetStationAccess(ctx, abbr, opts...)
+
+	if err != nil {
+		d.Err = err
+		return err
+	}
+
+	d.Access = &access.Station
+
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/theckman/go-bart"
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestBuildStationDataset(c *C) {
+	t.c.SetLimiter(bartapi.NewLimiter(time.Millisecond))
+
+	data, err := t.c.BuildStationDataset(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(data, HasLen, 3)
+
+	c.Check(data[0].Station.Abbr, Equals, "12TH")
+	c.Check(data[0].Err, IsNil)
+	c.Check(data[0].Info.Abbr, Equals, "12TH")
+	c.Check(data[0].Access.ParkingFlag, Equals, true)
+
+	c.Check(data[1].Station.Abbr, Equals, "MCAR")
+	c.Check(data[1].Err, IsNil)
+	c.Check(data[1].Info.Platforms, HasLen, 4)
+	c.Check(data[1].Access.ParkingFlag, Equals, false)
+
+	// there are no fixtures for West Oakland
+	c.Check(data[2].Station.Abbr, Equals, "WOAK")
+	c.Check(data[2].Err, Not(IsNil))
+	c.Check(data[2].Info, IsNil)
+
+	c.Check(t.srv.count("stninfo"), Equals, 3)
+	c.Check(t.srv.count("stnaccess"), Equals, 2)
+}
+
+func (t *TestSuite) TestBuildStationDatasetOptions(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") == "stnaccess" && req.FormValue("orig") == "MCAR" {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	var calls [][2]int
+
+	progress := func(done, total int) { calls = append(calls, [2]int{done, total}) }
+
+	// the stations from GetStations are looked up by their abbreviations
+	data, err := t.c.BuildStationDataset(context.Background(), bart.WithTimeout(50*time.Millisecond), bart.WithProgress(progress), bart.WithGTFSStopIDs())
+	c.Assert(err, IsNil)
+	c.Assert(data, HasLen, 3)
+
+	c.Check(data[0].Err, IsNil)
+	c.Check(data[0].Access, NotNil)
+
+	// the timeout applies to the calls for each station
+	c.Check(data[1].Info, NotNil)
+	c.Check(data[1].Err, NotNil)
+	c.Check(data[1].Access, IsNil)
+
+	c.Check(calls, DeepEquals, [][2]int{{1, 3}, {2, 3}, {3, 3}})
+}
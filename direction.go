@@ -0,0 +1,160 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoCommonLine is returned by DirectionBetween when no route serves
+// both of the stations.
+var ErrNoCommonLine = errors.New("bart: stations aren't on a common line")
+
+// Direction is the direction of travel of a train.
+type Direction int
+
+const (
+	// DirectionUnknown is used when the direction wasn't provided.
+	DirectionUnknown Direction = iota
+
+	// North is the northbound direction of travel.
+	North
+
+	// South is the southbound direction of travel.
+	South
+)
+
+func (d Direction) String() string {
+	switch d {
+	case North:
+		return "North"
+	case South:
+		return "South"
+	default:
+		return "Unknown"
+	}
+}
+
+// UnmarshalText satisfies the encoding.TextUnmarshaler interface. It accepts
+// the directions as they appear in responses ("North"), and in params ("n").
+// An empty value is DirectionUnknown.
+func (d *Direction) UnmarshalText(b []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(b))) {
+	case "north", "n":
+		*d = North
+	case "south", "s":
+		*d = South
+	case "":
+		*d = DirectionUnknown
+	default:
+		return fmt.Errorf("bart: invalid direction %q", string(b))
+	}
+
+	return nil
+}
+
+// DirectionBetween returns the direction of travel from orig to dest,
+// which are station abbreviations, suitable for filtering estimates. It
+// finds a route stopping at both, using the route information cached by
+// the client, and the positions of orig and dest in its station order.
+// BART names a route's direction by whether its last station is north or
+// south of its first, so going from orig to dest is in that direction if
+// dest comes after orig on the route, and the opposite one otherwise.
+func (c *Client) DirectionBetween(ctx context.Context, orig, dest string) (Direction, error) {
+	orig, dest = strings.ToUpper(orig), strings.ToUpper(dest)
+
+	routes, err := c.routeInfos(ctx)
+
+	if err != nil {
+		return DirectionUnknown, err
+	}
+
+	var lats map[string]float64
+
+	for i := range routes {
+		r := &routes[i]
+
+		o, d := r.stop(orig), r.stop(dest)
+
+		if o < 0 || d < 0 || o == d {
+			continue
+		}
+
+		if lats == nil {
+			lats = c.latitudes(ctx)
+		}
+
+		heading := r.heading(lats)
+
+		switch {
+		case heading == DirectionUnknown:
+			continue
+		case d > o:
+			return heading, nil
+		case heading == North:
+			return South, nil
+		default:
+			return North, nil
+		}
+	}
+
+	return DirectionUnknown, fmt.Errorf("%w: %s and %s", ErrNoCommonLine, orig, dest)
+}
+
+// heading returns the direction the route heads in, going by the latitudes
+// of its first and last stations, or DirectionUnknown if they're missing.
+func (r *RouteInfo) heading(lats map[string]float64) Direction {
+	if len(r.Stations) == 0 {
+		return DirectionUnknown
+	}
+
+	return headingBetween(lats, r.Stations[0], r.Stations[len(r.Stations)-1])
+}
+
+// headingBetween returns the direction from the station first to the
+// station last, going by their latitudes, or DirectionUnknown if they're
+// missing.
+func headingBetween(lats map[string]float64, first, last string) Direction {
+	from, ok1 := lats[strings.ToUpper(first)]
+	to, ok2 := lats[strings.ToUpper(last)]
+
+	switch {
+	case !ok1 || !ok2 || from == to:
+		return DirectionUnknown
+	case to > from:
+		return North
+	default:
+		return South
+	}
+}
+
+// latitudes returns the latitude of each station, by abbreviation. The
+// offline data is used for stations missing from the list returned by
+// GetStations, or if fetching the list fails.
+func (c *Client) latitudes(ctx context.Context) map[string]float64 {
+	stations := Stations()
+
+	if r, err := c.GetStations(ctx); err == nil {
+		stations = append(stations, r.Stations...)
+	}
+
+	return stationLatitudes(stations)
+}
+
+// stationLatitudes returns the latitude of each of the stations, by
+// abbreviation. Later stations replace earlier ones with the same
+// abbreviation.
+func stationLatitudes(stations []Station) map[string]float64 {
+	lats := make(map[string]float64, len(stations))
+
+	for _, s := range stations {
+		lats[strings.ToUpper(s.Abbr)] = s.Latitude
+	}
+
+	return lats
+}
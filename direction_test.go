@@ -0,0 +1,82 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestDirectionBetween(c *C) {
+	ctx := context.Background()
+
+	tests := []struct {
+		orig, dest string
+		dir        bart.Direction
+	}{
+		{"MCAR", "RICH", bart.North},
+		{"MCAR", "orin", bart.North},
+		{"MCAR", "12TH", bart.South},
+		{"12TH", "MCAR", bart.North},
+		{"DALY", "BERY", bart.South},
+		{"COLS", "OAKL", bart.South},
+		{"OAKL", "COLS", bart.North},
+
+		// both in the middle of the route
+		{"ORIN", "19TH", bart.South},
+		{"LAKE", "BAYF", bart.South},
+	}
+
+	for _, tt := range tests {
+		d, err := t.c.DirectionBetween(ctx, tt.orig, tt.dest)
+		c.Assert(err, IsNil, Commentf("%s-%s", tt.orig, tt.dest))
+		c.Check(d, Equals, tt.dir, Commentf("%s-%s", tt.orig, tt.dest))
+	}
+
+	// the route info is only fetched once
+	c.Check(t.srv.count("routes"), Equals, 1)
+
+	d, err := t.c.DirectionBetween(ctx, "MCAR", "OAKL")
+	c.Check(errors.Is(err, bart.ErrNoCommonLine), Equals, true)
+	c.Check(err, ErrorMatches, "bart: stations aren't on a common line: MCAR and OAKL")
+	c.Check(d, Equals, bart.DirectionUnknown)
+}
+
+func (t *TestSuite) TestDirectionBetweenReversed(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	// route 2 is served as a copy of route 1, so no route stops at 12TH
+	// and then ORIN, and the order of route 1 has to be reversed
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") == "routeinfo" && req.FormValue("route") == "2" {
+			body, err := ioutil.ReadFile(filepath.Join("testdata", "routeinfo_1.xml"))
+			c.Assert(err, IsNil)
+			rw.Write(body)
+
+			return
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	ctx := context.Background()
+
+	d, err := t.c.DirectionBetween(ctx, "ORIN", "12TH")
+	c.Assert(err, IsNil)
+	c.Check(d, Equals, bart.South)
+
+	d, err = t.c.DirectionBetween(ctx, "12TH", "ORIN")
+	c.Assert(err, IsNil)
+	c.Check(d, Equals, bart.North)
+
+	_, err = t.c.DirectionBetween(ctx, "ORIN", "ORIN")
+	c.Check(errors.Is(err, bart.ErrNoCommonLine), Equals, true)
+}
@@ -0,0 +1,429 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// Minutes is the number of minutes until a train departs. BART reports
+// trains that are departing as "Leaving", which is decoded as zero.
+type Minutes int
+
+// UnmarshalText satisfies the encoding.TextUnmarshaler interface.
+func (m *Minutes) UnmarshalText(b []byte) error {
+	s := strings.TrimSpace(string(b))
+
+	if strings.EqualFold(s, "leaving") || s == "" {
+		*m = 0
+		return nil
+	}
+
+	n, err := strconv.Atoi(s)
+
+	if err != nil {
+		return err
+	}
+
+	*m = Minutes(n)
+
+	return nil
+}
+
+// Estimate is the estimated departure of a single train.
+type Estimate struct {
+	Minutes   Minutes   `xml:"minutes"`
+	Platform  int       `xml:"platform"`
+	Direction Direction `xml:"direction"`
+	Length    int       `xml:"length"`
+	Color     string    `xml:"color"`
+	HexColor  string    `xml:"hexcolor"`
+	BikeFlag  bool      `xml:"bikeflag"`
+
+	// Delay is how late the train is running. BART reports it in
+	// seconds, with an empty value meaning there's no delay.
+	Delay time.Duration `xml:"-"`
+
+	// Extra is the estimate's attributes that don't have a field, when
+	// the WithExtraAttrs option is used.
+	Extra map[string]string `xml:"-"`
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface. It decodes the
+// estimate, parsing the delay in seconds in to a time.Duration.
+func (e *Estimate) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type estimate Estimate
+
+	v := struct {
+		*estimate
+		BikeFlag xmlBool `xml:"bikeflag"`
+		Delay    string  `xml:"delay"`
+	}{estimate: (*estimate)(e)}
+
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+
+	e.BikeFlag = bool(v.BikeFlag)
+	e.Delay = 0
+
+	if s := strings.TrimSpace(v.Delay); s != "" {
+		n, err := strconv.Atoi(s)
+
+		if err != nil {
+			return fmt.Errorf("bart: invalid delay %q", v.Delay)
+		}
+
+		e.Delay = time.Duration(n) * time.Second
+	}
+
+	return nil
+}
+
+// DelayDuration returns how late the train is running.
+func (e Estimate) DelayDuration() time.Duration {
+	return e.Delay
+}
+
+// Delayed returns whether the train is running late.
+func (e Estimate) Delayed() bool {
+	return e.Delay > 0
+}
+
+// ETD is the estimated departures from a station to a single destination.
+type ETD struct {
+	Destination  string     `xml:"destination"`
+	Abbreviation string     `xml:"abbreviation"`
+	Limited      bool       `xml:"limited"`
+	Estimates    []Estimate `xml:"estimate"`
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+func (e *ETD) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type etd ETD
+
+	v := struct {
+		*etd
+		Limited xmlBool `xml:"limited"`
+	}{etd: (*etd)(e)}
+
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+
+	e.Limited = bool(v.Limited)
+
+	return nil
+}
+
+// StationEstimates is the estimated departures from a single station.
+type StationEstimates struct {
+	Name string `xml:"name"`
+	Abbr string `xml:"abbr"`
+	ETDs []ETD  `xml:"etd"`
+}
+
+// EstimatesResponse is the response of the etd command.
+type EstimatesResponse struct {
+	bartapi.Envelope
+	Meta
+	Date     string             `xml:"date"`
+	Time     string             `xml:"time"`
+	Stations []StationEstimates `xml:"station"`
+}
+
+// Departure is a single estimated departure, flattened out of the
+// station and destination it's nested under in an EstimatesResponse.
+type Departure struct {
+	// Station is the abbreviation of the station the train departs from.
+	Station string
+
+	Destination     string
+	DestinationAbbr string
+
+	// Line is the color of the line the train is on, like "YELLOW".
+	Line string
+
+	Minutes   Minutes
+	Platform  int
+	Direction Direction
+	Delay     time.Duration
+}
+
+// Flatten returns all of the estimated departures in the response as a
+// single list, sorted by the minutes until they depart. Trains that are
+// leaving have zero minutes, so they're first. Departures at the same
+// time keep the order they're in the response.
+func (r *EstimatesResponse) Flatten() []Departure {
+	var deps []Departure
+
+	for _, s := range r.Stations {
+		for _, etd := range s.ETDs {
+			for _, e := range etd.Estimates {
+				deps = append(deps, Departure{
+					Station:         s.Abbr,
+					Destination:     etd.Destination,
+					DestinationAbbr: etd.Abbreviation,
+					Line:            e.Color,
+					Minutes:         e.Minutes,
+					Platform:        e.Platform,
+					Direction:       e.Direction,
+					Delay:           e.Delay,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(deps, func(i, j int) bool { return deps[i].Minutes < deps[j].Minutes })
+
+	return deps
+}
+
+// ByPlatform returns the estimated departures in the response grouped by
+// the number of the platform they leave from, like the signs on each of
+// them. Each group is sorted the same way as Flatten. Departures without
+// a platform are under zero.
+func (r *EstimatesResponse) ByPlatform() map[int][]Departure {
+	platforms := make(map[int][]Departure)
+
+	for _, dep := range r.Flatten() {
+		platforms[dep.Platform] = append(platforms[dep.Platform], dep)
+	}
+
+	return platforms
+}
+
+// GetEstimates returns the real-time departure estimates for the
+// station with the abbreviation orig. The EstimateDirection option
+// can be used to limit the results to one direction of travel, and
+// EstimateBikesOnly to the trains that allow bikes.
+func (c *Client) GetEstimates(ctx context.Context, orig string, opts ...Option) (*EstimatesResponse, error) {
+	o := newOptions(opts)
+	orig, err := c.stationAbbr(o, orig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := map[string]string{"orig": orig}
+
+	switch o.direction {
+	case North:
+		query["dir"] = "n"
+	case South:
+		query["dir"] = "s"
+	}
+
+	r := &EstimatesResponse{}
+
+	if err := c.get(ctx, c.estimates, "etd", query, r, opts...); err != nil {
+		return nil, err
+	}
+
+	if o.direction != DirectionUnknown {
+		r.filter(func(e Estimate) bool { return e.Direction == o.direction })
+	}
+
+	if o.bikesOnly {
+		r.filter(func(e Estimate) bool { return e.BikeFlag })
+	}
+
+	return r, nil
+}
+
+// filter removes the estimates for which keep returns false. ETDs
+// left without any estimates are removed too.
+func (r *EstimatesResponse) filter(keep func(Estimate) bool) {
+	for i := range r.Stations {
+		s := &r.Stations[i]
+
+		etds := s.ETDs[:0]
+
+		for _, etd := range s.ETDs {
+			estimates := etd.Estimates[:0]
+
+			for _, e := range etd.Estimates {
+				if keep(e) {
+					estimates = append(estimates, e)
+				}
+			}
+
+			if len(estimates) > 0 {
+				etd.Estimates = estimates
+				etds = append(etds, etd)
+			}
+		}
+
+		s.ETDs = etds
+	}
+}
+
+// GetAllEstimates returns the real-time departure estimates for every
+// station in a single request, using orig=ALL. Use FilterStations on the
+// response to get the estimates of some of the stations.
+func (c *Client) GetAllEstimates(ctx context.Context, opts ...Option) (*EstimatesResponse, error) {
+	return c.GetEstimates(ctx, "ALL", opts...)
+}
+
+// FilterStations returns a copy of the response with only the estimates of
+// the stations with the abbreviations, which are matched case-insensitively.
+// The stations keep their order in the response, and abbreviations that
+// aren't in it are ignored. The estimates themselves are shared with r.
+func (r *EstimatesResponse) FilterStations(abbrs ...string) *EstimatesResponse {
+	want := make(map[string]bool, len(abbrs))
+
+	for _, abbr := range abbrs {
+		want[strings.ToUpper(strings.TrimSpace(abbr))] = true
+	}
+
+	f := *r
+	f.Stations = make([]StationEstimates, 0, len(abbrs))
+
+	for _, s := range r.Stations {
+		if want[strings.ToUpper(s.Abbr)] {
+			f.Stations = append(f.Stations, s)
+		}
+	}
+
+	return &f
+}
+
+// Dedupe returns a copy of the response with the duplicate estimates of
+// each station merged. BART sometimes lists the same train under more than
+// one destination, like both "Millbrae" and "SF Airport/Millbrae" where the
+// lines share track. Estimates with the same minutes, platform, and
+// direction are treated as the same train, and kept only under the
+// destination with the longest name, as it's the most descriptive. ETDs
+// left without any estimates are removed. r isn't modified.
+func (r *EstimatesResponse) Dedupe() *EstimatesResponse {
+	type train struct {
+		minutes   Minutes
+		platform  int
+		direction Direction
+	}
+
+	d := *r
+	d.Stations = make([]StationEstimates, len(r.Stations))
+
+	for i, s := range r.Stations {
+		// owner is the index of the ETD each train is kept under
+		owner := make(map[train]int)
+
+		for j, etd := range s.ETDs {
+			for _, e := range etd.Estimates {
+				t := train{e.Minutes, e.Platform, e.Direction}
+
+				if k, ok := owner[t]; !ok || len(etd.Destination) > len(s.ETDs[k].Destination) {
+					owner[t] = j
+				}
+			}
+		}
+
+		s.ETDs = nil
+
+		for j, etd := range r.Stations[i].ETDs {
+			var estimates []Estimate
+
+			for _, e := range etd.Estimates {
+				t := train{e.Minutes, e.Platform, e.Direction}
+
+				if k, ok := owner[t]; ok && k == j {
+					estimates = append(estimates, e)
+
+					// only the first of the same train in the ETD is kept
+					delete(owner, t)
+				}
+			}
+
+			if len(estimates) > 0 {
+				etd.Estimates = estimates
+				s.ETDs = append(s.ETDs, etd)
+			}
+		}
+
+		d.Stations[i] = s
+	}
+
+	return &d
+}
+
+// DirectionalEstimates is the estimated departures from a station, split
+// by their direction of travel. Each list is sorted by the minutes until
+// the trains depart, like the one returned by Flatten.
+type DirectionalEstimates struct {
+	// Station and Name are the abbreviation and name of the station.
+	Station string
+	Name    string
+
+	North []Departure
+	South []Departure
+}
+
+// GetEstimatesBothDirections returns the real-time departure estimates for
+// the station with the abbreviation orig, split in to the northbound and
+// southbound departures. At terminal stations, and when service in one of
+// the directions has ended, one of the lists is empty. Estimates without a
+// direction aren't in either list.
+func (c *Client) GetEstimatesBothDirections(ctx context.Context, orig string, opts ...Option) (*DirectionalEstimates, error) {
+	r, err := c.GetEstimates(ctx, orig, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	d := &DirectionalEstimates{Station: strings.ToUpper(orig)}
+
+	if len(r.Stations) > 0 {
+		d.Station, d.Name = r.Stations[0].Abbr, r.Stations[0].Name
+	}
+
+	for _, dep := range r.Flatten() {
+		switch dep.Direction {
+		case North:
+			d.North = append(d.North, dep)
+		case South:
+			d.South = append(d.South, dep)
+		}
+	}
+
+	return d, nil
+}
+
+// GetEstimatesMulti returns the real-time departure estimates for each
+// of the stations, keyed by the station abbreviation. The requests are
+// made concurrently, bounded by the WithConcurrency option. If any of
+// them fail a MultiError is returned along with the other estimates.
+func (c *Client) GetEstimatesMulti(ctx context.Context, origs []string, opts ...Option) (map[string]*EstimatesResponse, error) {
+	o := newOptions(opts)
+
+	var mu sync.Mutex
+
+	estimates := make(map[string]*EstimatesResponse, len(origs))
+
+	err := forEach(origs, o.concurrency, func(orig string) error {
+		r, err := c.GetEstimates(ctx, orig, opts...)
+
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		estimates[orig] = r
+		mu.Unlock()
+
+		return nil
+	})
+
+	return estimates, err
+}
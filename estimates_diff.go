@@ -0,0 +1,124 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+// EstimateChange is a single change between two estimates snapshots.
+// Train is the position of the train amongst those heading to the same
+// destination, starting at zero. Old is nil for added trains, and New is
+// nil for removed ones.
+type EstimateChange struct {
+	Station     string
+	Destination string
+	Train       int
+	Old         *Estimate
+	New         *Estimate
+}
+
+// EstimatesDiff is the difference between two estimates snapshots.
+type EstimatesDiff struct {
+	Added   []EstimateChange
+	Removed []EstimateChange
+	Updated []EstimateChange
+}
+
+// Empty returns whether there were no changes.
+func (d *EstimatesDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Updated) == 0
+}
+
+// DiffEstimates compares two estimates snapshots, such as the results of
+// polling GetEstimates, and returns the trains that were added, removed,
+// or whose minutes changed. Either snapshot may be nil.
+//
+// The API doesn't identify individual trains, so they're matched up by
+// their position for each station and destination: when there are fewer
+// trains than before, the earliest ones are considered to have departed,
+// and when there are more the latest ones are considered to be new.
+// Stations and destinations are matched by their abbreviations, so their
+// order within the snapshots doesn't matter.
+func DiffEstimates(old, new *EstimatesResponse) *EstimatesDiff {
+	type key struct{ station, dest string }
+
+	index := func(r *EstimatesResponse) (map[key][]Estimate, []key) {
+		m := make(map[key][]Estimate)
+
+		var keys []key
+
+		if r == nil {
+			return m, keys
+		}
+
+		for _, s := range r.Stations {
+			for _, etd := range s.ETDs {
+				k := key{s.Abbr, etd.Abbreviation}
+
+				if _, ok := m[k]; !ok {
+					keys = append(keys, k)
+				}
+
+				m[k] = append(m[k], etd.Estimates...)
+			}
+		}
+
+		return m, keys
+	}
+
+	oldIdx, oldKeys := index(old)
+	newIdx, newKeys := index(new)
+
+	diff := &EstimatesDiff{}
+
+	for _, k := range newKeys {
+		o, n := oldIdx[k], newIdx[k]
+
+		// the earliest trains that are gone have departed
+		departed := len(o) - len(n)
+
+		if departed < 0 {
+			departed = 0
+		}
+
+		for i := 0; i < departed; i++ {
+			diff.Removed = append(diff.Removed, EstimateChange{
+				Station: k.station, Destination: k.dest, Train: i, Old: &o[i],
+			})
+		}
+
+		o = o[departed:]
+
+		for i := range n {
+			if i >= len(o) {
+				diff.Added = append(diff.Added, EstimateChange{
+					Station: k.station, Destination: k.dest, Train: i, New: &n[i],
+				})
+
+				continue
+			}
+
+			if o[i].Minutes != n[i].Minutes {
+				diff.Updated = append(diff.Updated, EstimateChange{
+					Station: k.station, Destination: k.dest, Train: i, Old: &o[i], New: &n[i],
+				})
+			}
+		}
+	}
+
+	// destinations no longer in the new snapshot
+	for _, k := range oldKeys {
+		if _, ok := newIdx[k]; ok {
+			continue
+		}
+
+		o := oldIdx[k]
+
+		for i := range o {
+			diff.Removed = append(diff.Removed, EstimateChange{
+				Station: k.station, Destination: k.dest, Train: i, Old: &o[i],
+			})
+		}
+	}
+
+	return diff
+}
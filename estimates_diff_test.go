@@ -0,0 +1,69 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func etdResponse(stations ...bart.StationEstimates) *bart.EstimatesResponse {
+	return &bart.EstimatesResponse{Stations: stations}
+}
+
+func etd(dest string, minutes ...int) bart.ETD {
+	e := bart.ETD{Abbreviation: dest}
+
+	for _, m := range minutes {
+		e.Estimates = append(e.Estimates, bart.Estimate{Minutes: bart.Minutes(m)})
+	}
+
+	return e
+}
+
+func (*TestSuite) TestDiffEstimates(c *C) {
+	old := etdResponse(
+		bart.StationEstimates{Abbr: "MCAR", ETDs: []bart.ETD{etd("ANTC", 0, 14), etd("RICH", 6)}},
+		bart.StationEstimates{Abbr: "12TH", ETDs: []bart.ETD{etd("RICH", 3)}},
+	)
+
+	// the stations are in a different order, the first ANTC train
+	// departed, the RICH train at MCAR is gone, and an SFIA train appeared
+	new := etdResponse(
+		bart.StationEstimates{Abbr: "12TH", ETDs: []bart.ETD{etd("RICH", 3)}},
+		bart.StationEstimates{Abbr: "MCAR", ETDs: []bart.ETD{etd("ANTC", 13), etd("SFIA", 2, 17)}},
+	)
+
+	d := bart.DiffEstimates(old, new)
+	c.Check(d.Empty(), Equals, false)
+
+	c.Assert(d.Removed, HasLen, 2)
+	c.Check(d.Removed[0].Station, Equals, "MCAR")
+	c.Check(d.Removed[0].Destination, Equals, "ANTC")
+	c.Check(d.Removed[0].Old.Minutes, Equals, bart.Minutes(0))
+	c.Check(d.Removed[0].New, IsNil)
+	c.Check(d.Removed[1].Destination, Equals, "RICH")
+	c.Check(d.Removed[1].Old.Minutes, Equals, bart.Minutes(6))
+
+	c.Assert(d.Updated, HasLen, 1)
+	c.Check(d.Updated[0].Station, Equals, "MCAR")
+	c.Check(d.Updated[0].Destination, Equals, "ANTC")
+	c.Check(d.Updated[0].Train, Equals, 0)
+	c.Check(d.Updated[0].Old.Minutes, Equals, bart.Minutes(14))
+	c.Check(d.Updated[0].New.Minutes, Equals, bart.Minutes(13))
+
+	c.Assert(d.Added, HasLen, 2)
+	c.Check(d.Added[0].Destination, Equals, "SFIA")
+	c.Check(d.Added[0].Train, Equals, 0)
+	c.Check(d.Added[0].Old, IsNil)
+	c.Check(d.Added[1].New.Minutes, Equals, bart.Minutes(17))
+
+	c.Check(bart.DiffEstimates(new, new).Empty(), Equals, true)
+	c.Check(bart.DiffEstimates(nil, nil).Empty(), Equals, true)
+
+	d = bart.DiffEstimates(nil, new)
+	c.Check(d.Added, HasLen, 4)
+	c.Check(d.Removed, HasLen, 0)
+}
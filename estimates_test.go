@@ -0,0 +1,311 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestGetEstimates(c *C) {
+	r, err := t.c.GetEstimates(context.Background(), "MCAR")
+	c.Assert(err, IsNil)
+	c.Check(r.Time, Equals, "10:12:33 AM PST")
+	c.Assert(r.Stations, HasLen, 1)
+
+	s := r.Stations[0]
+	c.Check(s.Abbr, Equals, "MCAR")
+	c.Assert(s.ETDs, HasLen, 4)
+	c.Check(s.ETDs[0].Destination, Equals, "Antioch")
+	c.Check(s.ETDs[0].Abbreviation, Equals, "ANTC")
+	c.Check(s.ETDs[0].Limited, Equals, false)
+	c.Assert(s.ETDs[0].Estimates, HasLen, 2)
+
+	c.Check(s.ETDs[0].Estimates[0], DeepEquals, bart.Estimate{
+		Minutes:   0,
+		Platform:  3,
+		Direction: bart.North,
+		Length:    10,
+		Color:     "YELLOW",
+		HexColor:  "#ffff33",
+		BikeFlag:  true,
+		Delay:     0,
+	})
+
+	c.Check(s.ETDs[0].Estimates[1].Minutes, Equals, bart.Minutes(14))
+	c.Check(s.ETDs[2].Estimates[0].Direction, Equals, bart.South)
+}
+
+func (t *TestSuite) TestEstimateDirection(c *C) {
+	// the fixture has both directions, like when the API ignores dir
+	r, err := t.c.GetEstimates(context.Background(), "MCAR", bart.EstimateDirection(bart.South))
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("etd").Get("dir"), Equals, "s")
+
+	etds := r.Stations[0].ETDs
+	c.Assert(etds, HasLen, 2)
+	c.Check(etds[0].Abbreviation, Equals, "SFIA")
+	c.Check(etds[0].Estimates, HasLen, 2)
+	c.Check(etds[1].Abbreviation, Equals, "BERY")
+
+	for _, etd := range etds {
+		for _, e := range etd.Estimates {
+			c.Check(e.Direction, Equals, bart.South)
+		}
+	}
+
+	r, err = t.c.GetEstimates(context.Background(), "MCAR", bart.EstimateDirection(bart.North))
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("etd").Get("dir"), Equals, "n")
+	c.Assert(r.Stations[0].ETDs, HasLen, 2)
+	c.Check(r.Stations[0].ETDs[0].Abbreviation, Equals, "ANTC")
+	c.Check(r.Stations[0].ETDs[1].Abbreviation, Equals, "RICH")
+
+	r, err = t.c.GetEstimates(context.Background(), "MCAR")
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("etd").Get("dir"), Equals, "")
+	c.Check(r.Stations[0].ETDs, HasLen, 4)
+}
+
+func (t *TestSuite) TestEstimateBikesOnly(c *C) {
+	r, err := t.c.GetEstimates(context.Background(), "MCAR", bart.EstimateBikesOnly())
+	c.Assert(err, IsNil)
+
+	etds := r.Stations[0].ETDs
+	c.Assert(etds, HasLen, 4)
+
+	// the first SFIA train doesn't allow bikes
+	c.Check(etds[2].Abbreviation, Equals, "SFIA")
+	c.Assert(etds[2].Estimates, HasLen, 1)
+	c.Check(etds[2].Estimates[0].Minutes, Equals, bart.Minutes(17))
+
+	for _, etd := range etds {
+		for _, e := range etd.Estimates {
+			c.Check(e.BikeFlag, Equals, true)
+		}
+	}
+
+	// both of the southbound destinations have a train allowing bikes
+	r, err = t.c.GetEstimates(context.Background(), "MCAR", bart.EstimateBikesOnly(), bart.EstimateDirection(bart.South))
+	c.Assert(err, IsNil)
+	c.Assert(r.Stations[0].ETDs, HasLen, 2)
+	c.Check(r.Stations[0].ETDs[1].Abbreviation, Equals, "BERY")
+
+	// the 12th St. fixture only has a northbound train
+	r, err = t.c.GetEstimates(context.Background(), "12TH", bart.EstimateBikesOnly(), bart.EstimateDirection(bart.South))
+	c.Assert(err, IsNil)
+	c.Assert(r.Stations, HasLen, 1)
+	c.Check(r.Stations[0].ETDs, HasLen, 0)
+}
+
+func (t *TestSuite) TestEstimateDelay(c *C) {
+	r, err := t.c.GetEstimates(context.Background(), "MCAR")
+	c.Assert(err, IsNil)
+
+	etds := r.Stations[0].ETDs
+
+	// "0"
+	c.Check(etds[0].Estimates[0].DelayDuration(), Equals, time.Duration(0))
+	c.Check(etds[0].Estimates[0].Delayed(), Equals, false)
+
+	// "134"
+	c.Check(etds[0].Estimates[1].Delay, Equals, 134*time.Second)
+	c.Check(etds[0].Estimates[1].DelayDuration(), Equals, 134*time.Second)
+	c.Check(etds[0].Estimates[1].Delayed(), Equals, true)
+
+	// empty
+	c.Check(etds[2].Estimates[1].Delay, Equals, time.Duration(0))
+	c.Check(etds[2].Estimates[1].Delayed(), Equals, false)
+
+	var e bart.Estimate
+	err = xml.Unmarshal([]byte("<estimate><delay>soon</delay></estimate>"), &e)
+	c.Check(err, ErrorMatches, `bart: invalid delay "soon"`)
+}
+
+func (t *TestSuite) TestGetEstimatesMulti(c *C) {
+	r, err := t.c.GetEstimatesMulti(context.Background(), []string{"MCAR", "12TH", "NOPE"}, bart.WithConcurrency(2))
+	c.Assert(err, Not(IsNil))
+
+	merr, ok := err.(bart.MultiError)
+	c.Assert(ok, Equals, true)
+	c.Check(merr, HasLen, 1)
+	c.Check(merr["NOPE"], Not(IsNil))
+
+	c.Assert(r, HasLen, 2)
+	c.Check(r["MCAR"].Stations[0].Abbr, Equals, "MCAR")
+	c.Check(r["12TH"].Stations[0].Abbr, Equals, "12TH")
+
+	// the unknown station isn't requested
+	c.Check(errors.Is(merr["NOPE"], bart.ErrUnknownStation), Equals, true)
+	c.Check(t.srv.count("etd"), Equals, 2)
+
+	r, err = t.c.GetEstimatesMulti(context.Background(), []string{"MCAR"})
+	c.Assert(err, IsNil)
+	c.Check(r, HasLen, 1)
+}
+
+func (t *TestSuite) TestWithConcurrency(c *C) {
+	var mu sync.Mutex
+	var inFlight, max int
+
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > max {
+			max = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+		fixtures.ServeHTTP(rw, req)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	})
+
+	origs := []string{"MCAR", "12TH", "MCAR", "12TH", "MCAR", "12TH"}
+
+	_, err := t.c.GetEstimatesMulti(context.Background(), origs, bart.WithConcurrency(2))
+	c.Assert(err, IsNil)
+	c.Check(max <= 2, Equals, true)
+	c.Check(max > 0, Equals, true)
+}
+
+func (t *TestSuite) TestGetAllEstimates(c *C) {
+	r, err := t.c.GetAllEstimates(context.Background(), bart.WithGTFSStopIDs())
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("etd").Get("orig"), Equals, "ALL")
+	c.Assert(r.Stations, HasLen, 3)
+
+	f := r.FilterStations("mcar", "XXXX", "12TH")
+	c.Check(f.Time, Equals, r.Time)
+	c.Assert(f.Stations, HasLen, 2)
+	c.Check(f.Stations[0].Abbr, Equals, "12TH")
+	c.Check(f.Stations[1].Abbr, Equals, "MCAR")
+
+	// the original response isn't changed
+	c.Check(r.Stations, HasLen, 3)
+
+	c.Check(r.FilterStations().Stations, HasLen, 0)
+}
+
+func (t *TestSuite) TestFlatten(c *C) {
+	r, err := t.c.GetAllEstimates(context.Background())
+	c.Assert(err, IsNil)
+
+	deps := r.Flatten()
+	c.Assert(deps, HasLen, 8)
+
+	c.Check(deps[0], DeepEquals, bart.Departure{
+		Station: "EMBR", Destination: "Daly City", DestinationAbbr: "DALY", Line: "GREEN",
+		Minutes: 0, Platform: 1, Direction: bart.South,
+	})
+
+	var got []string
+
+	for _, d := range deps {
+		got = append(got, fmt.Sprintf("%s>%s %d", d.Station, d.DestinationAbbr, d.Minutes))
+	}
+
+	c.Check(got, DeepEquals, []string{
+		"EMBR>DALY 0", "MCAR>ANTC 0", "MCAR>SFIA 2", "12TH>RICH 3",
+		"MCAR>RICH 6", "MCAR>BERY 9", "MCAR>ANTC 14", "MCAR>SFIA 17",
+	})
+
+	c.Check((&bart.EstimatesResponse{}).Flatten(), HasLen, 0)
+}
+
+func (t *TestSuite) TestGetEstimatesBothDirections(c *C) {
+	d, err := t.c.GetEstimatesBothDirections(context.Background(), "mcar")
+	c.Assert(err, IsNil)
+	c.Check(d.Station, Equals, "MCAR")
+	c.Check(d.Name, Equals, "MacArthur")
+
+	abbrs := func(deps []bart.Departure) []string {
+		var s []string
+
+		for _, dep := range deps {
+			s = append(s, fmt.Sprintf("%s:%d", dep.DestinationAbbr, dep.Minutes))
+		}
+
+		return s
+	}
+
+	c.Check(abbrs(d.North), DeepEquals, []string{"ANTC:0", "RICH:6", "ANTC:14"})
+	c.Check(abbrs(d.South), DeepEquals, []string{"SFIA:2", "BERY:9", "SFIA:17"})
+
+	// Richmond is a terminal, so there are only southbound trains
+	d, err = t.c.GetEstimatesBothDirections(context.Background(), "RICH")
+	c.Assert(err, IsNil)
+	c.Check(d.North, HasLen, 0)
+	c.Check(abbrs(d.South), DeepEquals, []string{"MLBR:0", "BERY:4"})
+}
+
+func (t *TestSuite) TestByPlatform(c *C) {
+	r, err := t.c.GetEstimates(context.Background(), "MCAR")
+	c.Assert(err, IsNil)
+
+	p := r.ByPlatform()
+	c.Assert(p, HasLen, 4)
+	c.Assert(p[3], HasLen, 2)
+	c.Check(p[3][0].Minutes, Equals, bart.Minutes(0))
+	c.Check(p[3][1].Minutes, Equals, bart.Minutes(14))
+	c.Check(p[1], HasLen, 1)
+	c.Check(p[2], HasLen, 2)
+	c.Check(p[4][0].DestinationAbbr, Equals, "BERY")
+
+	// departures without a platform are under zero
+	r.Stations[0].ETDs[0].Estimates[1].Platform = 0
+
+	p = r.ByPlatform()
+	c.Check(p[3], HasLen, 1)
+	c.Assert(p[0], HasLen, 1)
+	c.Check(p[0][0].Minutes, Equals, bart.Minutes(14))
+}
+
+func (t *TestSuite) TestDedupe(c *C) {
+	r, err := t.c.GetEstimates(context.Background(), "BALB")
+	c.Assert(err, IsNil)
+	c.Assert(r.Flatten(), HasLen, 5)
+
+	d := r.Dedupe()
+	c.Assert(d.Stations, HasLen, 1)
+
+	etds := d.Stations[0].ETDs
+	c.Assert(etds, HasLen, 4)
+
+	// the train at 5 minutes is kept under the longer destination
+	c.Check(etds[0].Destination, Equals, "Millbrae")
+	c.Assert(etds[0].Estimates, HasLen, 1)
+	c.Check(etds[0].Estimates[0].Minutes, Equals, bart.Minutes(20))
+
+	c.Check(etds[2].Destination, Equals, "SF Airport/Millbrae")
+	c.Assert(etds[2].Estimates, HasLen, 1)
+	c.Check(etds[2].Estimates[0].Minutes, Equals, bart.Minutes(5))
+
+	// the same minutes on another platform is a different train
+	c.Check(etds[3].Destination, Equals, "Daly City")
+	c.Check(etds[3].Estimates, HasLen, 1)
+
+	c.Check(d.Flatten(), HasLen, 4)
+
+	// the response isn't modified
+	c.Check(r.Stations[0].ETDs[0].Estimates, HasLen, 2)
+
+	// an ETD left without estimates is removed
+	r.Stations[0].ETDs[0].Estimates = r.Stations[0].ETDs[0].Estimates[:1]
+	c.Check(r.Dedupe().Stations[0].ETDs, HasLen, 3)
+}
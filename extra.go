@@ -0,0 +1,150 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// WithExtraAttrs sets whether the attributes BART sends that the types in
+// this package don't have fields for are kept in the Extra field of the
+// elements that have one, like Estimate, ScheduledTrain, and RouteTrain.
+// This is for getting at new attributes before the package supports them.
+// It's off by default, as finding them means decoding the response twice.
+func WithExtraAttrs() Option {
+	return func(o *options) { o.extraAttrs = true }
+}
+
+// extraAttrs returns the attributes of each element at path, which is the
+// element names from the root joined by ">", that aren't in known. There
+// is an entry for each of the elements, in the order they're in the body,
+// and it's nil if the element has no unknown attributes.
+func extraAttrs(body []byte, path string, known map[string]bool) ([]map[string]string, error) {
+	d := bartapi.NewDecoder(bytes.NewReader(body))
+
+	var (
+		stack []string
+		extra []map[string]string
+	)
+
+	for {
+		tok, err := d.Token()
+
+		if err == io.EOF {
+			return extra, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, tok.Name.Local)
+
+			if strings.Join(stack, ">") != path {
+				continue
+			}
+
+			var m map[string]string
+
+			for _, a := range tok.Attr {
+				if !known[a.Name.Local] {
+					if m == nil {
+						m = make(map[string]string)
+					}
+
+					m[a.Name.Local] = a.Value
+				}
+			}
+
+			extra = append(extra, m)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// attrNames returns the names of the attributes the struct v decodes.
+func attrNames(v interface{}) map[string]bool {
+	names := make(map[string]bool)
+	t := reflect.TypeOf(v)
+
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("xml")
+
+		if strings.Contains(tag, ",attr") {
+			names[strings.Split(tag, ",")[0]] = true
+		}
+	}
+
+	return names
+}
+
+var (
+	estimateAttrs       = attrNames(Estimate{})
+	scheduledTrainAttrs = attrNames(ScheduledTrain{})
+	routeTrainAttrs     = attrNames(RouteTrain{})
+)
+
+func (r *EstimatesResponse) setExtra(body []byte) error {
+	extra, err := extraAttrs(body, "root>station>etd>estimate", estimateAttrs)
+
+	if err != nil {
+		return err
+	}
+
+	for i := range r.Stations {
+		for j := range r.Stations[i].ETDs {
+			for k := range r.Stations[i].ETDs[j].Estimates {
+				if len(extra) == 0 {
+					return nil
+				}
+
+				r.Stations[i].ETDs[j].Estimates[k].Extra, extra = extra[0], extra[1:]
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *StationScheduleResponse) setExtra(body []byte) error {
+	extra, err := extraAttrs(body, "root>station>item", scheduledTrainAttrs)
+
+	if err != nil {
+		return err
+	}
+
+	for i := range r.Station.Trains {
+		if i < len(extra) {
+			r.Station.Trains[i].Extra = extra[i]
+		}
+	}
+
+	return nil
+}
+
+func (r *RouteScheduleResponse) setExtra(body []byte) error {
+	extra, err := extraAttrs(body, "root>route>train", routeTrainAttrs)
+
+	if err != nil {
+		return err
+	}
+
+	for i := range r.Trains {
+		if i < len(extra) {
+			r.Trains[i].Extra = extra[i]
+		}
+	}
+
+	return nil
+}
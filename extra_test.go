@@ -0,0 +1,97 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestWithExtraAttrs(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.FormValue("cmd") {
+		case "etd":
+			rw.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<root>
+  <station>
+    <abbr>MCAR</abbr>
+    <etd>
+      <abbreviation>ANTC</abbreviation>
+      <estimate cars="ten" crowding="high">
+        <minutes>4</minutes>
+        <direction>North</direction>
+      </estimate>
+      <estimate>
+        <minutes>Leaving</minutes>
+        <direction>South</direction>
+      </estimate>
+    </etd>
+    <etd>
+      <abbreviation>SFIA</abbreviation>
+      <estimate crowding="low">
+        <minutes>9</minutes>
+        <direction>South</direction>
+      </estimate>
+    </etd>
+  </station>
+</root>`))
+		case "stnsched":
+			rw.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<root>
+  <date>02/04/2019</date>
+  <station>
+    <abbr>12TH</abbr>
+    <item line="ROUTE 7" origTime="4:36 AM" trainIdx="1" bikeflag="1" />
+    <item line="ROUTE 2" origTime="4:41 AM" trainIdx="2" bikeflag="1" platform="2" />
+  </station>
+</root>`))
+		default:
+			fixtures.ServeHTTP(rw, req)
+		}
+	})
+
+	ctx := context.Background()
+
+	// not kept by default
+	r, err := t.c.GetEstimates(ctx, "MCAR")
+	c.Assert(err, IsNil)
+	c.Check(r.Stations[0].ETDs[0].Estimates[0].Extra, IsNil)
+
+	r, err = t.c.GetEstimates(ctx, "MCAR", bart.WithExtraAttrs())
+	c.Assert(err, IsNil)
+
+	etds := r.Stations[0].ETDs
+	c.Check(etds[0].Estimates[0].Extra, DeepEquals, map[string]string{"cars": "ten", "crowding": "high"})
+	c.Check(etds[0].Estimates[1].Extra, IsNil)
+	c.Check(etds[1].Estimates[0].Extra, DeepEquals, map[string]string{"crowding": "low"})
+
+	// they're kept with the estimates when some are filtered out
+	r, err = t.c.GetEstimates(ctx, "MCAR", bart.WithExtraAttrs(), bart.EstimateDirection(bart.South))
+	c.Assert(err, IsNil)
+	c.Check(r.Stations[0].ETDs[0].Estimates[0].Extra, IsNil)
+	c.Check(r.Stations[0].ETDs[1].Estimates[0].Extra, DeepEquals, map[string]string{"crowding": "low"})
+
+	s, err := t.c.GetStationSchedule(ctx, "12TH", time.Time{}, bart.WithExtraAttrs())
+	c.Assert(err, IsNil)
+	c.Assert(s.Station.Trains, HasLen, 2)
+	c.Check(s.Station.Trains[0].Extra, IsNil)
+	c.Check(s.Station.Trains[1].Extra, DeepEquals, map[string]string{"platform": "2"})
+}
+
+func (t *TestSuite) TestWithExtraAttrsRouteSchedule(c *C) {
+	r, err := t.c.GetRouteSchedule(context.Background(), 6, time.Time{}, bart.WithExtraAttrs())
+	c.Assert(err, IsNil)
+	c.Assert(r.Trains, HasLen, 2)
+
+	// the index attribute doesn't have a field
+	c.Check(r.Trains[0].Extra, DeepEquals, map[string]string{"index": "1"})
+	c.Check(r.Trains[1].Extra, DeepEquals, map[string]string{"index": "2"})
+}
@@ -0,0 +1,211 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// FareCurrency is the ISO 4217 code of the currency of the fares.
+const FareCurrency = "USD"
+
+// ErrFareUnavailable is returned by FareSavings when the API doesn't
+// include both the cash and Clipper fares of a trip.
+var ErrFareUnavailable = errors.New("bart: fare unavailable")
+
+// Fares are the fares of a trip, by the type of fare. The amounts are in
+// cents. A nil amount means the API didn't include the fare type, which is
+// different from a free fare.
+type Fares struct {
+	// Level is the level of the fares, like "normal".
+	Level string
+
+	Cash     *int
+	Clipper  *int
+	Senior   *int
+	Youth    *int
+	Disabled *int
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface. It decodes the
+// <fare> elements of a <fares> element, using their class to pick the
+// field. BART combines the senior and disabled fares in a single class,
+// in which case both fields are set.
+func (f *Fares) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v struct {
+		Level string `xml:"level,attr"`
+		Fares []struct {
+			Amount string `xml:"amount,attr"`
+			Class  string `xml:"class,attr"`
+		} `xml:"fare"`
+	}
+
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+
+	*f = Fares{Level: v.Level}
+
+	for _, fare := range v.Fares {
+		cents, err := parseCents(fare.Amount)
+
+		if err != nil {
+			return err
+		}
+
+		switch strings.ToLower(fare.Class) {
+		case "cash":
+			f.Cash = &cents
+		case "clipper":
+			f.Clipper = &cents
+		case "rtcclipper":
+			senior, disabled := cents, cents
+			f.Senior, f.Disabled = &senior, &disabled
+		case "senior":
+			f.Senior = &cents
+		case "disabled":
+			f.Disabled = &cents
+		case "student", "youth":
+			f.Youth = &cents
+		}
+	}
+
+	return nil
+}
+
+// parseCents parses a dollar amount, like "3.45", in to cents.
+func parseCents(s string) (int, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "$")
+
+	dollars, cents := s, "00"
+
+	if i := strings.Index(s, "."); i >= 0 {
+		dollars, cents = s[:i], (s[i+1:] + "00")[:2]
+	}
+
+	if dollars == "" {
+		dollars = "0"
+	}
+
+	d, err := strconv.Atoi(dollars)
+
+	if err != nil {
+		return 0, fmt.Errorf("bart: invalid fare amount %q", s)
+	}
+
+	c, err := strconv.Atoi(cents)
+
+	if err != nil || c < 0 {
+		return 0, fmt.Errorf("bart: invalid fare amount %q", s)
+	}
+
+	return d*100 + c, nil
+}
+
+// FareResponse is the response of the fare command.
+type FareResponse struct {
+	bartapi.Envelope
+	Meta
+	Origin      string `xml:"origin"`
+	Destination string `xml:"destination"`
+	SchedNum    int    `xml:"sched_num"`
+	Fares       Fares  `xml:"fares"`
+}
+
+// GetFare returns the fares for a trip from orig to dest. Both are
+// station abbreviations, which are normalized the same as those of the
+// station methods, like GetEstimates.
+func (c *Client) GetFare(ctx context.Context, orig, dest string, opts ...Option) (*FareResponse, error) {
+	o := newOptions(opts)
+
+	orig, err := c.stationAbbr(o, orig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dest, err = c.stationAbbr(o, dest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.fare(ctx, o, orig, dest, opts)
+}
+
+// fare gets the fares from orig to dest, which have already been
+// resolved to station abbreviations.
+func (c *Client) fare(ctx context.Context, o *options, orig, dest string, opts []Option) (*FareResponse, error) {
+	if err := c.connecting(ctx, o, orig, dest); err != nil {
+		return nil, err
+	}
+
+	query := o.query(map[string]string{"orig": orig, "dest": dest})
+
+	r := &FareResponse{}
+
+	if err := c.get(ctx, c.schedule, "fare", query, r, opts...); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// ClipperSavings is how much cheaper a trip is when paying with Clipper
+// rather than cash. The amounts are in cents.
+type ClipperSavings struct {
+	Cash    int
+	Clipper int
+
+	// Amount is the cash fare minus the Clipper fare.
+	Amount int
+
+	// Percent is the Amount as a percentage of the cash fare,
+	// or zero if the cash fare is free.
+	Percent float64
+}
+
+// ClipperSavings returns how much cheaper the fare is when paying with
+// Clipper rather than cash, and whether both fares are known.
+func (f Fares) ClipperSavings() (ClipperSavings, bool) {
+	if f.Cash == nil || f.Clipper == nil {
+		return ClipperSavings{}, false
+	}
+
+	s := ClipperSavings{Cash: *f.Cash, Clipper: *f.Clipper, Amount: *f.Cash - *f.Clipper}
+
+	if s.Cash > 0 {
+		s.Percent = float64(s.Amount) * 100 / float64(s.Cash)
+	}
+
+	return s, true
+}
+
+// FareSavings returns how much cheaper a trip from orig to dest is when
+// paying with Clipper rather than cash. Both are station abbreviations.
+// The fares are fetched using GetFare, and ErrFareUnavailable is returned
+// if the API doesn't include both of them.
+func (c *Client) FareSavings(ctx context.Context, orig, dest string) (*ClipperSavings, error) {
+	r, err := c.GetFare(ctx, orig, dest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := r.Fares.ClipperSavings()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: cash or Clipper fare from %s to %s", ErrFareUnavailable, orig, dest)
+	}
+
+	return &s, nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/theckman/go-bart"
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+func cents(n int) *int { return &n }
+
+func (t *TestSuite) TestGetFare(c *C) {
+	r, err := t.c.GetFare(context.Background(), "12TH", "EMBR")
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("fare").Get("orig"), Equals, "12TH")
+	c.Check(t.srv.query("fare").Get("dest"), Equals, "EMBR")
+
+	c.Check(r.Fares, DeepEquals, bart.Fares{
+		Level:    "normal",
+		Cash:     cents(345),
+		Clipper:  cents(330),
+		Senior:   cents(130),
+		Youth:    cents(165),
+		Disabled: cents(130),
+	})
+
+	// the senior and disabled fares don't share memory
+	*r.Fares.Senior = 0
+	c.Check(*r.Fares.Disabled, Equals, 130)
+}
+
+func (t *TestSuite) TestRequireConnectingRoute(c *C) {
+	ctx := context.Background()
+
+	_, err := t.c.GetFare(ctx, "MCAR", "OAKL", bart.RequireConnectingRoute())
+	c.Check(errors.Is(err, bart.ErrNoConnectingRoute), Equals, true)
+	c.Check(err, ErrorMatches, "bart: no route connects the stations: MCAR and OAKL")
+	c.Check(t.srv.count("fare"), Equals, 0)
+	c.Check(t.srv.count("routeinfo"), Equals, 12)
+
+	_, err = t.c.GetDepartures(ctx, "oakl", "rich", time.Time{}, bart.RequireConnectingRoute())
+	c.Check(errors.Is(err, bart.ErrNoConnectingRoute), Equals, true)
+	c.Check(t.srv.count("depart"), Equals, 0)
+
+	// the route information is cached, and the order doesn't matter
+	_, err = t.c.GetFare(ctx, "EMBR", "12TH", bart.RequireConnectingRoute())
+	c.Assert(err, IsNil)
+	c.Check(t.srv.count("fare"), Equals, 1)
+	c.Check(t.srv.count("routeinfo"), Equals, 12)
+
+	// without the option no route information is needed
+	_, err = t.c.GetFare(ctx, "MCAR", "OAKL")
+	c.Assert(err, IsNil)
+	c.Check(t.srv.count("fare"), Equals, 2)
+}
+
+func (t *TestSuite) TestFareSavings(c *C) {
+	s, err := t.c.FareSavings(context.Background(), "12TH", "EMBR")
+	c.Assert(err, IsNil)
+	c.Check(s.Cash, Equals, 345)
+	c.Check(s.Clipper, Equals, 330)
+	c.Check(s.Amount, Equals, 15)
+	c.Check(s.Percent > 4.34 && s.Percent < 4.35, Equals, true)
+
+	// a fare without a Clipper amount
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(`<root><fares level="normal"><fare amount="3.45" class="cash" /></fares><message /></root>`))
+	})
+
+	_, err = t.c.FareSavings(context.Background(), "12TH", "EMBR")
+	c.Check(errors.Is(err, bart.ErrFareUnavailable), Equals, true)
+	c.Check(err, ErrorMatches, "bart: fare unavailable: cash or Clipper fare from 12TH to EMBR")
+
+	free := bart.Fares{Cash: cents(0), Clipper: cents(0)}
+
+	fs, ok := free.ClipperSavings()
+	c.Check(ok, Equals, true)
+	c.Check(fs, DeepEquals, bart.ClipperSavings{})
+}
+
+func (t *TestSuite) TestTripFares(c *C) {
+	r, err := t.c.GetDepartures(context.Background(), "ASHB", "CIVC", time.Time{})
+	c.Assert(err, IsNil)
+
+	// only the cash and Clipper fares are included
+	c.Check(r.Trips[0].Fares, DeepEquals, bart.Fares{Level: "normal", Cash: cents(435), Clipper: cents(420)})
+	c.Check(r.Trips[1].Fares, DeepEquals, bart.Fares{})
+}
+
+func (*TestSuite) TestFaresDecode(c *C) {
+	tests := []struct {
+		amount string
+		cents  int
+	}{
+		{"3.45", 345},
+		{"0.00", 0},
+		{"2", 200},
+		{"$1.5", 150},
+		{".65", 65},
+		{" 10.10 ", 1010},
+	}
+
+	for _, tt := range tests {
+		var f struct {
+			Fares bart.Fares `xml:"fares"`
+		}
+
+		err := bartapi.Decode(strings.NewReader(`<root><fares><fare amount="`+tt.amount+`" class="cash" /></fares></root>`), &f)
+		c.Assert(err, IsNil, Commentf("%s", tt.amount))
+		c.Assert(f.Fares.Cash, NotNil)
+		c.Check(*f.Fares.Cash, Equals, tt.cents, Commentf("%s", tt.amount))
+		c.Check(f.Fares.Clipper, IsNil)
+	}
+
+	var f struct {
+		Fares bart.Fares `xml:"fares"`
+	}
+
+	err := bartapi.Decode(strings.NewReader(`<root><fares><fare amount="free" class="cash" /></fares></root>`), &f)
+	c.Check(err, ErrorMatches, `bart: invalid fare amount "free"`)
+}
+
+func (t *TestSuite) TestFareTripStationCodes(c *C) {
+	ctx := context.Background()
+
+	_, err := t.c.GetFare(ctx, " powl", "Sfia")
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("fare").Get("orig"), Equals, "POWL")
+	c.Check(t.srv.query("fare").Get("dest"), Equals, "SFIA")
+
+	_, err = t.c.GetDepartures(ctx, "mcar", "Powl ", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("depart").Get("orig"), Equals, "MCAR")
+	c.Check(t.srv.query("depart").Get("dest"), Equals, "POWL")
+
+	_, err = t.c.GetArrivals(ctx, "mcar", "powel", time.Time{})
+	c.Check(errors.Is(err, bart.ErrUnknownStation), Equals, true)
+	c.Check(t.srv.count("arrive"), Equals, 0)
+
+	fares, err := t.c.GetFaresFrom(ctx, "12th")
+	c.Assert(err, IsNil)
+	c.Check(fares, HasLen, 2)
+	c.Check(t.srv.query("fare").Get("orig"), Equals, "12TH")
+
+	_, err = t.c.GetFare(ctx, "powl", "Sfia", bart.WithRawStationCodes())
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("fare").Get("orig"), Equals, "powl")
+}
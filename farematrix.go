@@ -0,0 +1,178 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FareMatrix is the fares between every pair of stations. It's keyed by
+// the abbreviations of the origin, and then of the destination.
+type FareMatrix struct {
+	// Stations is the abbreviations of the stations, sorted.
+	Stations []string
+
+	Fares map[string]map[string]Fares
+}
+
+// Fare returns the fares from orig to dest, and whether they're in the
+// matrix. The abbreviations are matched case-insensitively.
+func (m *FareMatrix) Fare(orig, dest string) (Fares, bool) {
+	f, ok := m.Fares[strings.ToUpper(orig)][strings.ToUpper(dest)]
+	return f, ok
+}
+
+// Missing returns the pairs of stations that aren't in the matrix, as
+// "ORIG-DEST", sorted by origin and then destination.
+func (m *FareMatrix) Missing() []string {
+	stations := append([]string(nil), m.Stations...)
+	sort.Strings(stations)
+
+	var missing []string
+
+	for _, orig := range stations {
+		for _, dest := range stations {
+			if orig == dest {
+				continue
+			}
+
+			if _, ok := m.Fares[orig][dest]; !ok {
+				missing = append(missing, orig+"-"+dest)
+			}
+		}
+	}
+
+	return missing
+}
+
+// GetFareMatrixWithProgress returns the fares between every pair of the
+// stations returned by GetStations, for building a fare table. There are
+// a lot of pairs, so this makes thousands of requests and takes minutes.
+// They're made concurrently, bounded by the WithConcurrency option, and
+// the client's Limiter applies to them. The pairs are fetched in the order
+// of Missing, and the function set using the WithProgress option is called
+// after each of them with how many are done out of the total.
+//
+// If any of the pairs fail a MultiError, keyed by "ORIG-DEST", is returned along with
+// the matrix of the fares that were fetched. Pass the matrix to
+// ResumeFareMatrix to fetch the rest, like after ctx is canceled.
+func (c *Client) GetFareMatrixWithProgress(ctx context.Context, opts ...Option) (*FareMatrix, error) {
+	r, err := c.GetStations(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	m := &FareMatrix{Fares: make(map[string]map[string]Fares, len(r.Stations))}
+
+	for _, s := range r.Stations {
+		m.Stations = append(m.Stations, strings.ToUpper(s.Abbr))
+	}
+
+	sort.Strings(m.Stations)
+
+	return c.ResumeFareMatrix(ctx, m, opts...)
+}
+
+// ResumeFareMatrix fetches the fares of the pairs of stations that are
+// missing from m, which was returned by GetFareMatrixWithProgress, and
+// adds them to it. The pairs already in m count as done for WithProgress.
+func (c *Client) ResumeFareMatrix(ctx context.Context, m *FareMatrix, opts ...Option) (*FareMatrix, error) {
+	o := newOptions(opts)
+
+	if m.Fares == nil {
+		m.Fares = make(map[string]map[string]Fares, len(m.Stations))
+	}
+
+	missing := m.Missing()
+
+	total := len(m.Stations) * (len(m.Stations) - 1)
+	done := total - len(missing)
+
+	var mu sync.Mutex
+
+	err := forEach(missing, o.concurrency, func(pair string) error {
+		i := strings.Index(pair, "-")
+		orig, dest := pair[:i], pair[i+1:]
+
+		r, err := c.fare(ctx, o, orig, dest, opts)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		done++
+
+		if o.progress != nil {
+			o.progress(done, total)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if m.Fares[orig] == nil {
+			m.Fares[orig] = make(map[string]Fares, len(m.Stations)-1)
+		}
+
+		m.Fares[orig][dest] = r.Fares
+
+		return nil
+	})
+
+	return m, err
+}
+
+// GetFaresFrom returns the fares from orig to every other station returned
+// by GetStations, keyed by the abbreviation of the destination, like for a
+// station's fare board. The requests are made concurrently, bounded by the
+// WithConcurrency option, and the client's Limiter applies to them. If any
+// of the destinations fail a MultiError keyed by their abbreviations is
+// returned, along with the fares that were fetched.
+func (c *Client) GetFaresFrom(ctx context.Context, orig string, opts ...Option) (map[string]*Fares, error) {
+	r, err := c.GetStations(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
+
+	orig, err = c.stationAbbr(o, orig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var dests []string
+
+	for _, s := range r.Stations {
+		if abbr := strings.ToUpper(s.Abbr); abbr != orig {
+			dests = append(dests, abbr)
+		}
+	}
+
+	fares := make(map[string]*Fares, len(dests))
+
+	var mu sync.Mutex
+
+	err = forEach(dests, o.concurrency, func(dest string) error {
+		r, err := c.fare(ctx, o, orig, dest, opts)
+
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		fares[dest] = &r.Fares
+		mu.Unlock()
+
+		return nil
+	})
+
+	return fares, err
+}
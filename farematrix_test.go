@@ -0,0 +1,95 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestGetFareMatrixWithProgress(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	fail := true
+
+	var pairs []string
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") == "fare" {
+			pairs = append(pairs, req.FormValue("orig")+"-"+req.FormValue("dest"))
+		}
+
+		if fail && req.FormValue("cmd") == "fare" && req.FormValue("orig") == "MCAR" {
+			http.Error(rw, "down", http.StatusInternalServerError)
+			return
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	var calls [][2]int
+
+	progress := func(done, total int) { calls = append(calls, [2]int{done, total}) }
+
+	m, err := t.c.GetFareMatrixWithProgress(context.Background(), bart.WithProgress(progress), bart.WithConcurrency(1))
+	c.Assert(err, FitsTypeOf, bart.MultiError{})
+	c.Check(err.(bart.MultiError), HasLen, 2)
+	c.Check(err.(bart.MultiError)["MCAR-WOAK"], NotNil)
+
+	c.Check(m.Stations, DeepEquals, []string{"12TH", "MCAR", "WOAK"})
+	c.Check(m.Missing(), DeepEquals, []string{"MCAR-12TH", "MCAR-WOAK"})
+	c.Check(calls, HasLen, 6)
+	c.Check(calls[5], Equals, [2]int{6, 6})
+
+	// the pairs are fetched in a stable order
+	c.Check(pairs, DeepEquals, []string{"12TH-MCAR", "12TH-WOAK", "MCAR-12TH", "MCAR-WOAK", "WOAK-12TH", "WOAK-MCAR"})
+
+	f, ok := m.Fare("12th", "mcar")
+	c.Assert(ok, Equals, true)
+	c.Check(f.Clipper, NotNil)
+
+	_, ok = m.Fare("MCAR", "WOAK")
+	c.Check(ok, Equals, false)
+
+	// only the missing pairs are fetched when resuming
+	fail, calls = false, nil
+	before := t.srv.count("fare")
+
+	m, err = t.c.ResumeFareMatrix(context.Background(), m, bart.WithProgress(progress))
+	c.Assert(err, IsNil)
+	c.Check(m.Missing(), HasLen, 0)
+	c.Check(t.srv.count("fare")-before, Equals, 2)
+	c.Check(calls, DeepEquals, [][2]int{{5, 6}, {6, 6}})
+}
+
+func (t *TestSuite) TestGetFaresFrom(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") == "fare" && req.FormValue("dest") == "WOAK" {
+			http.Error(rw, "down", http.StatusInternalServerError)
+			return
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	fares, err := t.c.GetFaresFrom(context.Background(), "mcar")
+	c.Assert(err, FitsTypeOf, bart.MultiError{})
+	c.Check(err.(bart.MultiError), HasLen, 1)
+	c.Check(err.(bart.MultiError)["WOAK"], NotNil)
+
+	// the origin isn't requested
+	c.Check(t.srv.count("fare"), Equals, 1)
+	c.Check(t.srv.query("fare").Get("orig"), Equals, "MCAR")
+	c.Check(t.srv.query("fare").Get("dest"), Equals, "12TH")
+
+	c.Assert(fares, HasLen, 1)
+	c.Assert(fares["12TH"], NotNil)
+	c.Check(*fares["12TH"].Clipper, Equals, 330)
+}
@@ -0,0 +1,47 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	gtfsOnce  sync.Once
+	gtfsStops map[string]string
+)
+
+// GTFSStopToStation returns the abbreviation of the station with the GTFS
+// stop ID id. BART's GTFS feed uses the station abbreviations as the IDs
+// of its parent stations, with the platforms and entrances of a station
+// adding a suffix after an underscore or a dash (e.g., "MCAR_1"), so both
+// are accepted. The IDs are matched case-insensitively against the offline
+// station data, and ErrUnknownStation is returned if there's no match.
+func GTFSStopToStation(id string) (string, error) {
+	gtfsOnce.Do(func() {
+		stations := Stations()
+		gtfsStops = make(map[string]string, len(stations))
+
+		for _, s := range stations {
+			gtfsStops[strings.ToUpper(s.Abbr)] = s.Abbr
+		}
+	})
+
+	key := strings.ToUpper(strings.TrimSpace(id))
+
+	if abbr, ok := gtfsStops[key]; ok {
+		return abbr, nil
+	}
+
+	if i := strings.IndexAny(key, "_-"); i > 0 {
+		if abbr, ok := gtfsStops[key[:i]]; ok {
+			return abbr, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: GTFS stop %q", ErrUnknownStation, id)
+}
@@ -0,0 +1,70 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (*TestSuite) TestGTFSStopToStation(c *C) {
+	tests := map[string]string{
+		"MCAR":   "MCAR",
+		"mcar":   "MCAR",
+		"12TH":   "12TH",
+		"12TH_1": "12TH",
+		"POWL-2": "POWL",
+	}
+
+	for id, want := range tests {
+		abbr, err := bart.GTFSStopToStation(id)
+		c.Check(err, IsNil, Commentf("%s", id))
+		c.Check(abbr, Equals, want, Commentf("%s", id))
+	}
+
+	for _, id := range []string{"", "XXXX", "_MCAR", "place_MCAR"} {
+		_, err := bart.GTFSStopToStation(id)
+		c.Check(errors.Is(err, bart.ErrUnknownStation), Equals, true, Commentf("%s", id))
+	}
+}
+
+func (t *TestSuite) TestWithGTFSStopIDs(c *C) {
+	r, err := t.c.GetStationInfo(context.Background(), "mcar_1", bart.WithGTFSStopIDs())
+	c.Assert(err, IsNil)
+	c.Check(r.Station.Abbr, Equals, "MCAR")
+	c.Check(t.srv.query("stninfo").Get("orig"), Equals, "MCAR")
+
+	_, err = t.c.GetEstimates(context.Background(), "XXXX", bart.WithGTFSStopIDs())
+	c.Check(errors.Is(err, bart.ErrUnknownStation), Equals, true)
+	c.Check(t.srv.count("etd"), Equals, 0)
+}
+
+func (t *TestSuite) TestWithGTFSStopIDsFareTrip(c *C) {
+	ctx := context.Background()
+
+	_, err := t.c.GetFare(ctx, "12TH_1", "POWL-2", bart.WithGTFSStopIDs())
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("fare").Get("orig"), Equals, "12TH")
+	c.Check(t.srv.query("fare").Get("dest"), Equals, "POWL")
+
+	_, err = t.c.GetDepartures(ctx, "mcar_1", "12TH_2", time.Time{}, bart.WithGTFSStopIDs())
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("depart").Get("orig"), Equals, "MCAR")
+	c.Check(t.srv.query("depart").Get("dest"), Equals, "12TH")
+
+	_, err = t.c.GetArrivals(ctx, "place_MCAR", "12TH", time.Time{}, bart.WithGTFSStopIDs())
+	c.Check(errors.Is(err, bart.ErrUnknownStation), Equals, true)
+	c.Check(t.srv.count("arrive"), Equals, 0)
+
+	// the destinations come from the station list, so aren't mapped
+	fares, err := t.c.GetFaresFrom(ctx, "mcar_1", bart.WithGTFSStopIDs())
+	c.Assert(err, IsNil)
+	c.Check(fares, HasLen, 2)
+	c.Check(t.srv.query("fare").Get("orig"), Equals, "MCAR")
+}
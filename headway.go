@@ -0,0 +1,86 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	routeDirectionsOnce sync.Once
+	routeDirections     map[int]Direction
+)
+
+// offlineRouteDirections returns the direction of each route in the
+// offline data, by number. The ends of a route are in its abbreviation,
+// like "ANTC-SFIA", and its heading between them is worked out the same
+// way as for DirectionBetween.
+func offlineRouteDirections() map[int]Direction {
+	routeDirectionsOnce.Do(func() {
+		lats := stationLatitudes(Stations())
+
+		routeDirections = make(map[int]Direction)
+
+		for _, r := range Routes() {
+			ends := strings.Split(strings.ToUpper(r.Abbr), "-")
+
+			if len(ends) == 2 {
+				routeDirections[r.Number] = headingBetween(lats, ends[0], ends[1])
+			}
+		}
+	})
+
+	return routeDirections
+}
+
+// Direction returns the direction the train is heading in, based on its
+// line and the offline route data returned by Routes. It's DirectionUnknown
+// for lines that aren't in the offline data.
+func (t ScheduledTrain) Direction() Direction {
+	nums, err := routeNumbers([]string{t.Line})
+
+	if err != nil {
+		return DirectionUnknown
+	}
+
+	return offlineRouteDirections()[nums[0]]
+}
+
+// Headways returns the time between consecutive trains departing the
+// station, for each direction, in the order the trains depart. The
+// direction of each train is from its Direction method. Directions with
+// fewer than two trains don't have any headways, so they're left out,
+// and trains with invalid departure times are ignored.
+func (r *StationScheduleResponse) Headways() map[Direction][]time.Duration {
+	departs := make(map[Direction][]time.Time)
+
+	for i, t := range r.departures(r.EffectiveDate) {
+		if t.IsZero() {
+			continue
+		}
+
+		d := r.Station.Trains[i].Direction()
+		departs[d] = append(departs[d], t)
+	}
+
+	headways := make(map[Direction][]time.Duration)
+
+	for d, times := range departs {
+		if len(times) < 2 {
+			continue
+		}
+
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+		for i := 1; i < len(times); i++ {
+			headways[d] = append(headways[d], times[i].Sub(times[i-1]))
+		}
+	}
+
+	return headways
+}
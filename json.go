@@ -0,0 +1,166 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ToJSON marshals v, usually one of the response types, to JSON with a
+// consistent shape for use with tools like jq. The keys are the lowercased
+// field names, and zero values are omitted. The fields of embedded structs,
+// like the response envelope, are promoted in to the parent object.
+// Durations are strings (e.g., "1.5s"), errors are their messages, and
+// types with a String method, like Direction, use it.
+func ToJSON(v interface{}) ([]byte, error) {
+	return json.Marshal(jsonValue(reflect.ValueOf(v)))
+}
+
+// JSONResult is ToJSON for the results of the typed methods, so that they
+// can be converted in one line:
+//
+//	b, err := bart.JSONResult(c.GetEstimates(ctx, "MCAR"))
+//
+// If err is not nil, it's returned without marshaling v.
+func JSONResult(v interface{}, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return ToJSON(v)
+}
+
+var (
+	durationType      = reflect.TypeOf(time.Duration(0))
+	xmlNameType       = reflect.TypeOf(xml.Name{})
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// jsonValue converts v in to a value that encoding/json marshals
+// in the shape described by ToJSON.
+func jsonValue(v reflect.Value) interface{} {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return nil
+		}
+
+		if v.Type().Implements(errorType) {
+			return v.Interface().(error).Error()
+		}
+
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return nil
+	}
+
+	t := v.Type()
+
+	switch {
+	case t == durationType:
+		return v.Interface().(time.Duration).String()
+	case t.Implements(jsonMarshalerType), t.Implements(textMarshalerType):
+		return v.Interface()
+	case t.Implements(errorType):
+		return v.Interface().(error).Error()
+	case t.Kind() != reflect.Struct && t.Kind() != reflect.String && t.Implements(stringerType):
+		return v.Interface().(fmt.Stringer).String()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		addJSONFields(m, v)
+		return m
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+
+		s := make([]interface{}, v.Len())
+
+		for i := range s {
+			s[i] = jsonValue(v.Index(i))
+		}
+
+		return s
+
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = jsonValue(iter.Value())
+		}
+
+		return m
+	}
+
+	return v.Interface()
+}
+
+// addJSONFields adds the non-zero exported fields of the struct v to m.
+func addJSONFields(m map[string]interface{}, v reflect.Value) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		if f.Anonymous {
+			for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+
+			if fv.Kind() == reflect.Struct {
+				addJSONFields(m, fv)
+				continue
+			}
+		}
+
+		if f.Type == xmlNameType || jsonEmpty(fv) {
+			continue
+		}
+
+		m[strings.ToLower(f.Name)] = jsonValue(fv)
+	}
+}
+
+// jsonEmpty returns whether v is omitted from the JSON.
+func jsonEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Struct:
+		if v.Type().Implements(stringerType) || v.Type().Implements(textMarshalerType) {
+			return v.IsZero()
+		}
+
+		// empty objects are omitted, like the message of a response without one
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); (f.PkgPath == "" || f.Anonymous) && f.Type != xmlNameType && !jsonEmpty(v.Field(i)) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return v.IsZero()
+	}
+}
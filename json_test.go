@@ -0,0 +1,85 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestToJSON(c *C) {
+	r, err := t.c.GetEstimates(context.Background(), "MCAR")
+	c.Assert(err, IsNil)
+
+	r.Latency = 1500 * time.Millisecond
+
+	b, err := bart.ToJSON(r)
+	c.Assert(err, IsNil)
+
+	var v map[string]interface{}
+	c.Assert(json.Unmarshal(b, &v), IsNil)
+
+	c.Check(v["uri"], Equals, "http://api.bart.gov/api/etd.aspx?cmd=etd&orig=MCAR")
+	c.Check(v["date"], Equals, "02/04/2019")
+	c.Check(v["latency"], Equals, "1.5s")
+
+	// empty fields are omitted
+	_, ok := v["message"]
+	c.Check(ok, Equals, false)
+	_, ok = v["legend"]
+	c.Check(ok, Equals, false)
+	_, ok = v["xmlname"]
+	c.Check(ok, Equals, false)
+
+	stations := v["stations"].([]interface{})
+	c.Assert(stations, HasLen, 1)
+
+	etd := stations[0].(map[string]interface{})["etds"].([]interface{})[1].(map[string]interface{})
+	c.Check(etd["abbreviation"], Equals, "RICH")
+	_, ok = etd["limited"]
+	c.Check(ok, Equals, false)
+
+	est := etd["estimates"].([]interface{})[0].(map[string]interface{})
+	c.Check(est, DeepEquals, map[string]interface{}{
+		"minutes":   float64(6),
+		"platform":  float64(1),
+		"direction": "North",
+		"length":    float64(6),
+		"color":     "ORANGE",
+		"hexcolor":  "#ff9933",
+		"bikeflag":  true,
+	})
+}
+
+func (t *TestSuite) TestToJSONValues(c *C) {
+	d := &bart.Dashboard{Station: "MCAR", EstimatesErr: errors.New("oops")}
+
+	b, err := bart.ToJSON(d)
+	c.Assert(err, IsNil)
+	c.Check(string(b), Equals, `{"estimateserr":"oops","station":"MCAR"}`)
+
+	five := 500
+
+	b, err = bart.ToJSON([]bart.Fares{{Cash: &five}, {}})
+	c.Assert(err, IsNil)
+	c.Check(string(b), Equals, `[{"cash":500},{}]`)
+
+	b, err = bart.JSONResult(t.c.GetTrainCount(context.Background()))
+	c.Assert(err, IsNil)
+	c.Check(string(b), Matches, `\{.*"traincount":52.*\}`)
+
+	b, err = bart.JSONResult(t.c.GetEstimates(context.Background(), "NOPE"))
+	c.Check(err, NotNil)
+	c.Check(b, IsNil)
+
+	b, err = bart.ToJSON(nil)
+	c.Assert(err, IsNil)
+	c.Check(string(b), Equals, `null`)
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// Legend maps the codes used in responses to their descriptions. It's keyed
+// by the lowercased field name (e.g., "load"), and then by the code.
+type Legend map[string]map[string]string
+
+var (
+	legendFieldRegexp = regexp.MustCompile(`([A-Za-z_]+):\s`)
+	legendCodeRegexp  = regexp.MustCompile(`(\w+)\s*=\s*([^.;]+)`)
+)
+
+// ParseLegend parses the legend text BART includes in the response message
+// when it's requested, which is in the form:
+//
+//	bikeflag: 1 = bikes allowed. 0 = no bikes allowed. load: 1 = light. 2 = medium.
+func ParseLegend(text string) Legend {
+	l := make(Legend)
+
+	fields := legendFieldRegexp.FindAllStringSubmatchIndex(text, -1)
+
+	for i, f := range fields {
+		end := len(text)
+
+		if i+1 < len(fields) {
+			end = fields[i+1][0]
+		}
+
+		name := strings.ToLower(text[f[2]:f[3]])
+
+		for _, code := range legendCodeRegexp.FindAllStringSubmatch(text[f[1]:end], -1) {
+			if l[name] == nil {
+				l[name] = make(map[string]string)
+			}
+
+			l[name][code[1]] = strings.TrimSpace(code[2])
+		}
+	}
+
+	return l
+}
+
+// Resolve returns the description of the code used in the field.
+func (l Legend) Resolve(field, code string) (string, bool) {
+	desc, ok := l[strings.ToLower(field)][strings.TrimSpace(code)]
+	return desc, ok
+}
+
+// legendStation is the station whose schedule GetLegend requests. The
+// legend doesn't depend on the station, so any would do.
+const legendStation = "12TH"
+
+// GetLegend returns the legend describing the codes used in responses,
+// like the load and bike flag of a train. BART doesn't have a command for
+// only the legend, so it's taken from a station schedule requested with
+// it. The legend is cached by the client after the first call, and the
+// returned Legend is shared so it shouldn't be modified. Concurrent calls
+// share the request, which isn't canceled with their contexts.
+func (c *Client) GetLegend(ctx context.Context) (Legend, error) {
+	c.mu.Lock()
+	cached := c.legend
+	c.mu.Unlock()
+
+	if cached != nil {
+		return cached, nil
+	}
+
+	v, err := c.shared(ctx, "legend", func(ctx context.Context) (interface{}, error) {
+		r := &StationScheduleResponse{}
+
+		if err := c.get(ctx, c.schedule, "stnsched", map[string]string{"orig": legendStation, "l": "1"}, r); err != nil {
+			return nil, err
+		}
+
+		l := r.Legend
+
+		if l == nil {
+			l = make(Legend)
+		}
+
+		c.mu.Lock()
+		c.legend = l
+		c.mu.Unlock()
+
+		return l, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(Legend), nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (*TestSuite) TestParseLegend(c *C) {
+	l := bart.ParseLegend("bikeflag: 1 = bikes allowed. 0 = no bikes allowed. load: 1 = light. 2 = medium. 3 = heavy.")
+
+	c.Check(l, DeepEquals, bart.Legend{
+		"bikeflag": {"1": "bikes allowed", "0": "no bikes allowed"},
+		"load":     {"1": "light", "2": "medium", "3": "heavy"},
+	})
+
+	desc, ok := l.Resolve("Load", "2")
+	c.Check(ok, Equals, true)
+	c.Check(desc, Equals, "medium")
+
+	_, ok = l.Resolve("load", "9")
+	c.Check(ok, Equals, false)
+
+	c.Check(bart.ParseLegend(""), HasLen, 0)
+}
+
+func (t *TestSuite) TestWithLegend(c *C) {
+	r, err := t.c.GetStationSchedule(context.Background(), "DUBL", time.Time{}, bart.WithLegend(true))
+	c.Assert(err, IsNil)
+
+	desc, ok := r.Legend.Resolve("load", strconv.Itoa(r.Station.Trains[0].Load))
+	c.Check(ok, Equals, true)
+	c.Check(desc, Equals, "medium")
+	c.Check(t.srv.query("stnsched").Get("l"), Equals, "1")
+
+	// the legend isn't parsed unless it's requested
+	r, err = t.c.GetStationSchedule(context.Background(), "DUBL", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(r.Legend, IsNil)
+	c.Check(t.srv.query("stnsched").Get("l"), Equals, "")
+}
+
+func (t *TestSuite) TestGetLegend(c *C) {
+	l, err := t.c.GetLegend(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("stnsched").Get("l"), Equals, "1")
+
+	desc, ok := l.Resolve("bikeflag", "0")
+	c.Check(ok, Equals, true)
+	c.Check(desc, Equals, "no bikes allowed")
+
+	// the legend is cached
+	_, err = t.c.GetLegend(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(t.srv.count("stnsched"), Equals, 1)
+}
+
+func (t *TestSuite) TestGetLegendCanceled(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	arrived, release := make(chan struct{}), make(chan struct{})
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		close(arrived)
+		<-release
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+
+	go func() {
+		_, err := t.c.GetLegend(ctx)
+		first <- err
+	}()
+
+	<-arrived
+
+	second := make(chan bart.Legend, 1)
+
+	go func() {
+		l, err := t.c.GetLegend(context.Background())
+		c.Check(err, IsNil)
+		second <- l
+	}()
+
+	// let the second call join the request before the first is canceled
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	c.Check(<-first, Equals, context.Canceled)
+
+	close(release)
+	_, ok := (<-second).Resolve("bikeflag", "0")
+	c.Check(ok, Equals, true)
+	c.Check(t.srv.count("stnsched"), Equals, 1)
+}
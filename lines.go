@@ -0,0 +1,91 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"strings"
+)
+
+// Line is a BART route in a normalized form, with the stations it serves,
+// for exporting to other formats like GTFS. BART calls each direction of
+// a line a separate route, so there are two Lines for most colors. Use
+// ToJSON to serialize them.
+type Line struct {
+	// Number is the route number, like 1, and ID is BART's ID for the
+	// route, like "ROUTE 1".
+	Number int
+	ID     string
+
+	Name string
+	Abbr string
+
+	// Color is the name of the color, like "YELLOW", and HexColor is
+	// the color with the "#" removed, like "ffff33", as GTFS uses.
+	Color    string
+	HexColor string
+
+	Direction Direction
+
+	// Stations is the abbreviations of the stations the line serves, in
+	// order, starting with the origin and ending with the destination.
+	Stations []string
+}
+
+// Origin returns the abbreviation of the first station of the line.
+func (l Line) Origin() string {
+	if len(l.Stations) == 0 {
+		return ""
+	}
+
+	return l.Stations[0]
+}
+
+// Destination returns the abbreviation of the last station of the line.
+func (l Line) Destination() string {
+	if len(l.Stations) == 0 {
+		return ""
+	}
+
+	return l.Stations[len(l.Stations)-1]
+}
+
+// ExportLines returns every route as a Line, sorted by route number. It
+// uses the route information cached by the client, fetching it using
+// GetAllRouteInfo the first time. The station list is used to work out
+// the direction of each line, as DirectionBetween does.
+func (c *Client) ExportLines(ctx context.Context) ([]Line, error) {
+	routes, err := c.routeInfos(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lats := c.latitudes(ctx)
+	lines := make([]Line, 0, len(routes))
+
+	for i := range routes {
+		r := &routes[i]
+
+		stations := make([]string, len(r.Stations))
+
+		for j, s := range r.Stations {
+			stations[j] = strings.ToUpper(s)
+		}
+
+		lines = append(lines, Line{
+			Number:    r.Number,
+			ID:        r.RouteID,
+			Name:      r.Name,
+			Abbr:      r.Abbr,
+			Color:     strings.ToUpper(r.Color),
+			HexColor:  strings.ToLower(strings.TrimPrefix(r.HexColor, "#")),
+			Direction: r.heading(lats),
+			Stations:  stations,
+		})
+	}
+
+	return lines, nil
+}
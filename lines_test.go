@@ -0,0 +1,40 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestExportLines(c *C) {
+	lines, err := t.c.ExportLines(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(lines, HasLen, 12)
+
+	l := lines[0]
+	c.Check(l.Number, Equals, 1)
+	c.Check(l.ID, Equals, "ROUTE 1")
+	c.Check(l.Color, Equals, "YELLOW")
+	c.Check(l.HexColor, Equals, "ffff33")
+	c.Check(l.Direction, Equals, bart.South)
+	c.Check(l.Origin(), Equals, "ANTC")
+	c.Check(l.Destination(), Equals, "MLBR")
+	c.Check(l.Stations, HasLen, 28)
+
+	c.Check(lines[1].Number, Equals, 2)
+	c.Check(lines[1].Direction, Equals, bart.North)
+
+	for _, l := range lines {
+		c.Check(l.Direction, Not(Equals), bart.DirectionUnknown, Commentf("route %d", l.Number))
+	}
+
+	// the route info is cached
+	_, err = t.c.ExportLines(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(t.srv.count("routes"), Equals, 1)
+}
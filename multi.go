@@ -0,0 +1,87 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultConcurrency is the number of requests the methods
+// that fan out to many requests make at the same time.
+const defaultConcurrency = 8
+
+// MultiError is returned by the methods that make many requests when some
+// of them fail. It's keyed by what the failed request was for, like a
+// station abbreviation. The results of the successful requests are still
+// returned alongside it.
+type MultiError map[string]error
+
+func (m MultiError) Error() string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	msgs := make([]string, len(keys))
+
+	for i, k := range keys {
+		msgs[i] = fmt.Sprintf("%s: %s", k, m[k])
+	}
+
+	return fmt.Sprintf("bart: %d request(s) failed: %s", len(m), strings.Join(msgs, "; "))
+}
+
+// forEach calls fn for each of the keys, in order, from n goroutines, so
+// at most n calls run at the same time. Any errors are returned as a
+// MultiError keyed by the key that failed, otherwise the error is nil.
+func forEach(keys []string, n int, fn func(key string) error) error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	errs := make(MultiError)
+	work := make(chan string)
+
+	if n > len(keys) {
+		n = len(keys)
+	} else if n < 1 {
+		n = 1
+	}
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for k := range work {
+				if err := fn(k); err != nil {
+					mu.Lock()
+					errs[k] = err
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, k := range keys {
+		work <- k
+	}
+
+	close(work)
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
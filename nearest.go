@@ -0,0 +1,59 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"math"
+)
+
+// earthRadius is the mean radius of the Earth, in meters.
+const earthRadius = 6371008.8
+
+// Distance returns the great-circle distance, in meters, between two
+// points given in degrees, using the haversine formula.
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dlat, dlng := rad(lat2-lat1), rad(lng2-lng1)
+
+	a := math.Sin(dlat/2)*math.Sin(dlat/2) +
+		math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dlng/2)*math.Sin(dlng/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// NearestStation returns the station closest to the point at lat and lng,
+// in degrees, along with its distance in meters. The station list is
+// fetched using GetStations if it hasn't been already, and if that fails
+// the offline data returned by Stations is used instead.
+func (c *Client) NearestStation(ctx context.Context, lat, lng float64) (*Station, float64, error) {
+	var stations []Station
+
+	if r, err := c.GetStations(ctx); err == nil {
+		stations = r.Stations
+	} else {
+		stations = Stations()
+	}
+
+	var nearest *Station
+
+	min := math.Inf(1)
+
+	for i, s := range stations {
+		if d := Distance(lat, lng, s.Latitude, s.Longitude); d < min {
+			nearest, min = &stations[i], d
+		}
+	}
+
+	if nearest == nil {
+		return nil, 0, ErrUnknownStation
+	}
+
+	// don't return a pointer in to the cached list
+	s := *nearest
+
+	return &s, min, nil
+}
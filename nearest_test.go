@@ -0,0 +1,47 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (*TestSuite) TestDistance(c *C) {
+	c.Check(bart.Distance(37.8, -122.2, 37.8, -122.2), Equals, 0.0)
+
+	// 12th St. to MacArthur is about 2.8km
+	d := bart.Distance(37.803768, -122.271450, 37.829065, -122.267040)
+	c.Check(d > 2800 && d < 2850, Equals, true, Commentf("%f", d))
+}
+
+func (t *TestSuite) TestNearestStation(c *C) {
+	// Oakland City Hall
+	s, d, err := t.c.NearestStation(context.Background(), 37.805302, -122.272590)
+	c.Assert(err, IsNil)
+	c.Check(s.Abbr, Equals, "12TH")
+	c.Check(d > 150 && d < 250, Equals, true, Commentf("%f", d))
+	c.Check(t.srv.count("stns"), Equals, 1)
+
+	// the cached list is used
+	s, _, err = t.c.NearestStation(context.Background(), 37.83, -122.26)
+	c.Assert(err, IsNil)
+	c.Check(s.Abbr, Equals, "MCAR")
+	c.Check(t.srv.count("stns"), Equals, 1)
+}
+
+func (t *TestSuite) TestNearestStationOffline(c *C) {
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		http.Error(rw, "unavailable", http.StatusServiceUnavailable)
+	})
+
+	// SFO International Airport
+	s, _, err := t.c.NearestStation(context.Background(), 37.6155, -122.3923)
+	c.Assert(err, IsNil)
+	c.Check(s.Abbr, Equals, "SFIA")
+}
@@ -0,0 +1,81 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoTrain is returned when there's no train departing soon enough
+// to be in the estimates.
+var ErrNoTrain = errors.New("bart: no train found")
+
+// NextTrainTo returns the soonest estimated departure from orig of a train
+// that stops at dest, without transferring. Both are station abbreviations.
+// A train stops at dest if one of the routes serves orig, then dest, and
+// then the train's destination. The route information is fetched using
+// GetRouteInfo the first time it's needed, and then cached by the client.
+//
+// The estimates only cover roughly the next hour, so ErrNoTrain is
+// returned if there's no train to dest in them. The returned estimate
+// may be of a train that's leaving now (zero Minutes).
+func (c *Client) NextTrainTo(ctx context.Context, orig, dest string) (*Estimate, error) {
+	orig, dest = strings.ToUpper(orig), strings.ToUpper(dest)
+
+	routes, err := c.routeInfos(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := c.GetEstimates(ctx, orig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var next *Estimate
+
+	for i := range r.Stations {
+		for j := range r.Stations[i].ETDs {
+			etd := &r.Stations[i].ETDs[j]
+
+			if !reaches(routes, orig, dest, strings.ToUpper(etd.Abbreviation)) {
+				continue
+			}
+
+			for k := range etd.Estimates {
+				if e := &etd.Estimates[k]; next == nil || e.Minutes < next.Minutes {
+					next = e
+				}
+			}
+		}
+	}
+
+	if next == nil {
+		return nil, fmt.Errorf("%w from %s to %s", ErrNoTrain, orig, dest)
+	}
+
+	return next, nil
+}
+
+// reaches returns whether a train from orig to the terminal station
+// stops at dest on any of the routes.
+func reaches(routes []RouteInfo, orig, dest, terminal string) bool {
+	if dest == terminal {
+		return orig != dest
+	}
+
+	for i := range routes {
+		if routes[i].Serves(orig, dest) && routes[i].Serves(dest, terminal) {
+			return true
+		}
+	}
+
+	return false
+}
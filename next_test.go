@@ -0,0 +1,59 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestNextTrainTo(c *C) {
+	ctx := context.Background()
+
+	tests := []struct {
+		dest    string
+		minutes bart.Minutes
+		color   string
+	}{
+		// the terminal station
+		{"RICH", 6, "ORANGE"},
+
+		// served by the Richmond trains
+		{"DBRK", 6, "ORANGE"},
+
+		// served by the SFIA and Berryessa trains
+		{"12TH", 2, "YELLOW"},
+		{"powl", 2, "YELLOW"},
+		{"FTVL", 9, "ORANGE"},
+
+		{"ORIN", 0, "YELLOW"},
+	}
+
+	for _, tt := range tests {
+		e, err := t.c.NextTrainTo(ctx, "MCAR", tt.dest)
+		c.Assert(err, IsNil, Commentf("%s", tt.dest))
+		c.Check(e.Minutes, Equals, tt.minutes, Commentf("%s", tt.dest))
+		c.Check(e.Color, Equals, tt.color, Commentf("%s", tt.dest))
+	}
+
+	// the route info is only fetched once
+	c.Check(t.srv.count("routes"), Equals, 1)
+	c.Check(t.srv.count("routeinfo"), Equals, 12)
+}
+
+func (t *TestSuite) TestNextTrainToNone(c *C) {
+	ctx := context.Background()
+
+	// needs a transfer at Coliseum
+	_, err := t.c.NextTrainTo(ctx, "MCAR", "OAKL")
+	c.Check(errors.Is(err, bart.ErrNoTrain), Equals, true)
+	c.Check(err, ErrorMatches, "bart: no train found from MCAR to OAKL")
+
+	_, err = t.c.NextTrainTo(ctx, "MCAR", "MCAR")
+	c.Check(errors.Is(err, bart.ErrNoTrain), Equals, true)
+}
@@ -0,0 +1,67 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"bytes"
+	_ "embed" // for the offline data
+	"sync"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// The offline data is a snapshot of the raw API responses. To regenerate
+// it, run `go generate` in this package and commit the changes to data/.
+
+//go:generate sh -c "curl -fsS 'http://api.bart.gov/api/stn.aspx?cmd=stns&key=MW9S-E7SL-26DU-VV8V' > data/stations.xml"
+//go:generate sh -c "curl -fsS 'http://api.bart.gov/api/route.aspx?cmd=routes&key=MW9S-E7SL-26DU-VV8V' > data/routes.xml"
+
+var (
+	//go:embed data/stations.xml
+	stationsXML []byte
+
+	//go:embed data/routes.xml
+	routesXML []byte
+
+	offlineOnce     sync.Once
+	offlineStations *StationsResponse
+	offlineRoutes   *RoutesResponse
+)
+
+// loadOffline decodes the embedded data. The data is part of the package,
+// so failing to decode it is a bug rather than an error to be handled.
+func loadOffline() {
+	offlineOnce.Do(func() {
+		offlineStations = &StationsResponse{}
+
+		if err := bartapi.Decode(bytes.NewReader(stationsXML), offlineStations); err != nil {
+			panic("bart: decoding embedded station data: " + err.Error())
+		}
+
+		offlineRoutes = &RoutesResponse{}
+
+		if err := bartapi.Decode(bytes.NewReader(routesXML), offlineRoutes); err != nil {
+			panic("bart: decoding embedded route data: " + err.Error())
+		}
+	})
+}
+
+// Stations returns the stations from the snapshot embedded in the package.
+// They're the same as the ones returned by GetStations, but don't need a
+// network request, so may be out of date if BART has changed them since
+// the snapshot was taken. The returned slice can be modified by the caller.
+func Stations() []Station {
+	loadOffline()
+	return append([]Station(nil), offlineStations.Stations...)
+}
+
+// Routes returns the routes from the snapshot embedded in the package.
+// They're the same as the ones returned by GetRoutes, but don't need a
+// network request, so may be out of date if BART has changed them since
+// the snapshot was taken. The returned slice can be modified by the caller.
+func Routes() []Route {
+	loadOffline()
+	return append([]Route(nil), offlineRoutes.Routes...)
+}
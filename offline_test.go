@@ -0,0 +1,41 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (*TestSuite) TestOfflineStations(c *C) {
+	stations := bart.Stations()
+	c.Assert(stations, HasLen, 50)
+
+	seen := make(map[string]bool)
+
+	for _, s := range stations {
+		c.Check(s.Abbr, HasLen, 4)
+		c.Check(s.Name, Not(Equals), "")
+		c.Check(s.Latitude > 37 && s.Latitude < 39, Equals, true, Commentf("%s", s.Abbr))
+		c.Check(s.Longitude > -123 && s.Longitude < -121, Equals, true, Commentf("%s", s.Abbr))
+		c.Check(seen[s.Abbr], Equals, false)
+		seen[s.Abbr] = true
+	}
+
+	c.Check(stations[25].Abbr, Equals, "MCAR")
+	c.Check(stations[25].Name, Equals, "MacArthur")
+
+	// callers get their own copy
+	stations[0].Name = "changed"
+	c.Check(bart.Stations()[0].Name, Equals, "12th St. Oakland City Center")
+}
+
+func (*TestSuite) TestOfflineRoutes(c *C) {
+	routes := bart.Routes()
+	c.Assert(routes, HasLen, 12)
+	c.Check(routes[0].Number, Equals, 1)
+	c.Check(routes[0].Abbr, Equals, "ANTC-SFIA")
+	c.Check(routes[11].Number, Equals, 20)
+}
@@ -0,0 +1,177 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"fmt"
+	"time"
+)
+
+// Option is an optional setting for a single method call. Options that
+// don't apply to the method they're passed to are ignored.
+type Option func(*options)
+
+type options struct {
+	concurrency int
+	legend      bool
+	direction   Direction
+	before      *int
+	after       *int
+	routeDate   time.Time
+	bikesOnly   bool
+	timeout     *time.Duration
+	gtfs        bool
+	progress    func(done, total int)
+	sinkDir     string
+	extraAttrs  bool
+	connecting  bool
+	rawStations bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{concurrency: defaultConcurrency}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithConcurrency sets the maximum number of requests that methods which
+// fan out to many requests, like GetEstimatesMulti, have in flight at
+// once. The default is 8, and values less than 1 are treated as 1.
+//
+// This is separate from any Limiter set on the client, and both apply:
+// the concurrency bounds how many requests are open at the same time,
+// while the Limiter bounds how often new requests are started.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		if n < 1 {
+			n = 1
+		}
+
+		o.concurrency = n
+	}
+}
+
+// WithTimeout overrides the timeout set using SetTimeout for a single
+// call, for the requests that take longer than most. Zero means that
+// there's no timeout. The call is still bound to its context, so the
+// shorter of its deadline and the timeout wins.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = &d }
+}
+
+// WithProgress sets a function that methods making many requests, like
+// GetSystemSchedule and GetFareMatrixWithProgress, call after each of them
+// finishes with how many are done out of the total. The calls aren't
+// concurrent, but may be made from different goroutines.
+func WithProgress(fn func(done, total int)) Option {
+	return func(o *options) { o.progress = fn }
+}
+
+// WithLegend sets whether the schedule, fare, and trip methods request the
+// legend describing the codes used in the response. When it's requested the
+// legend is parsed in to the response's Legend field.
+func WithLegend(legend bool) Option {
+	return func(o *options) { o.legend = legend }
+}
+
+// EstimateDirection limits the results of GetEstimates to trains heading
+// in the direction d. The direction is sent to the API, and the results are
+// also filtered by the client as the API doesn't always respect it.
+func EstimateDirection(d Direction) Option {
+	return func(o *options) { o.direction = d }
+}
+
+// EstimateBikesOnly limits the results of GetEstimates to trains that
+// allow bikes. The bike flag of each estimate already accounts for BART's
+// time-based bike rules, like the ones for rush hour, so the schedule
+// isn't checked. If no trains allowing bikes are coming the response has
+// no estimates, rather than an error being returned.
+func EstimateBikesOnly() Option {
+	return func(o *options) { o.bikesOnly = true }
+}
+
+// WithGTFSStopIDs makes the methods taking stations, like GetEstimates,
+// GetFare, and GetDepartures, take GTFS stop IDs instead of station
+// abbreviations. The IDs are mapped to stations using GTFSStopToStation,
+// and the methods return its error if one can't be.
+func WithGTFSStopIDs() Option {
+	return func(o *options) { o.gtfs = true }
+}
+
+// stationAbbrs undoes WithGTFSStopIDs, for passing the options of a method
+// on to the methods it calls with the abbreviations from GetStations.
+func stationAbbrs() Option {
+	return func(o *options) { o.gtfs = false }
+}
+
+// WithRawStationCodes makes the methods taking stations, like GetEstimates,
+// GetFare, and GetDepartures, pass the station codes they're given to the
+// API as is. By default the codes are trimmed, uppercased, and checked
+// against the known stations, so "powl" works and typos return
+// ErrUnknownStation rather than an empty response.
+func WithRawStationCodes() Option {
+	return func(o *options) { o.rawStations = true }
+}
+
+// Before sets the number of trips before the requested time that the trip
+// planning methods, like GetDepartures, return. BART allows 0 to 4, and
+// the methods return ErrInvalidTripCount for other values.
+func Before(n int) Option {
+	return func(o *options) { o.before = &n }
+}
+
+// After sets the number of trips after the requested time that the trip
+// planning methods, like GetDepartures, return. BART allows 0 to 4, and
+// the methods return ErrInvalidTripCount for other values.
+func After(n int) Option {
+	return func(o *options) { o.after = &n }
+}
+
+// RouteWithDate sets the date GetRoutes returns the routes for, instead
+// of the current schedule's. The routes, and their names and colors, can
+// change with each new schedule.
+func RouteWithDate(t time.Time) Option {
+	return func(o *options) { o.routeDate = t }
+}
+
+// RequireConnectingRoute makes GetFare, GetDepartures and GetArrivals
+// check that a single route serves both the origin and the destination
+// before making the request, and return ErrNoConnectingRoute if none
+// does. Trips that need a transfer between routes fail the check too,
+// so it's off by default.
+//
+// The check uses the route information, which is fetched on first use
+// with one request per route and then cached by the client. Once it's
+// cached the check doesn't make any requests.
+func RequireConnectingRoute() Option {
+	return func(o *options) { o.connecting = true }
+}
+
+// query adds the params for the options to q.
+func (o *options) query(q map[string]string) map[string]string {
+	if o.legend {
+		q["l"] = "1"
+	}
+
+	return q
+}
+
+// checkTrips returns ErrInvalidTripCount if the Before or After
+// options are out of range.
+func (o *options) checkTrips() error {
+	if o.before != nil && (*o.before < 0 || *o.before > maxTripCount) {
+		return fmt.Errorf("%w: before is %d", ErrInvalidTripCount, *o.before)
+	}
+
+	if o.after != nil && (*o.after < 0 || *o.after > maxTripCount) {
+		return fmt.Errorf("%w: after is %d", ErrInvalidTripCount, *o.after)
+	}
+
+	return nil
+}
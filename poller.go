@@ -0,0 +1,105 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// AdaptivePoller polls more often while there are disruptions, like
+// advisories or delays, and less often while service is normal. This keeps
+// the results fresh when they are changing, without making more requests
+// than needed when they're not.
+//
+// The interval drops to the minimum as soon as a poll shows a disruption,
+// and doubles after each poll that doesn't, up to the maximum. It starts at
+// the minimum, until the first poll shows whether there's a disruption.
+type AdaptivePoller struct {
+	min time.Duration
+	max time.Duration
+
+	mu       sync.Mutex
+	interval time.Duration
+}
+
+// NewAdaptivePoller returns a new AdaptivePoller with an interval between
+// min and max. If max is less than min, the interval is always min.
+func NewAdaptivePoller(min, max time.Duration) *AdaptivePoller {
+	if max < min {
+		max = min
+	}
+
+	return &AdaptivePoller{min: min, max: max, interval: min}
+}
+
+// Interval returns the current interval between polls.
+func (p *AdaptivePoller) Interval() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.interval
+}
+
+// Observe adjusts the interval after a poll, based on whether it showed a
+// disruption, and returns the new interval. It's called by Run, and only
+// needs to be called directly when polling some other way.
+func (p *AdaptivePoller) Observe(disrupted bool) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if disrupted {
+		p.interval = p.min
+	} else if p.interval *= 2; p.interval > p.max || p.interval <= 0 {
+		p.interval = p.max
+	}
+
+	return p.interval
+}
+
+// Run calls fn, and then waits for the interval before calling it again,
+// until the context is done or fn returns an error. fn returns whether the
+// poll showed a disruption, such as by using the Disrupted method of an
+// AdvisoriesResponse or the Delayed method of an EstimatesResponse. The
+// error from fn, or the context's error, is returned.
+func (p *AdaptivePoller) Run(ctx context.Context, fn func(ctx context.Context) (bool, error)) error {
+	for {
+		disrupted, err := fn(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		t := time.NewTimer(p.Observe(disrupted))
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Disrupted returns whether any of the advisories are for a disruption,
+// which is the case when their OverallStatusOf isn't StatusNormal.
+func (r *AdvisoriesResponse) Disrupted() bool {
+	return OverallStatusOf(r.Advisories) != StatusNormal
+}
+
+// Delayed returns whether any of the trains are running late.
+func (r *EstimatesResponse) Delayed() bool {
+	for _, s := range r.Stations {
+		for _, etd := range s.ETDs {
+			for _, e := range etd.Estimates {
+				if e.Delayed() {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,81 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (*TestSuite) TestAdaptivePoller(c *C) {
+	p := bart.NewAdaptivePoller(10*time.Second, time.Minute)
+	c.Check(p.Interval(), Equals, 10*time.Second)
+
+	c.Check(p.Observe(false), Equals, 20*time.Second)
+	c.Check(p.Observe(false), Equals, 40*time.Second)
+	c.Check(p.Observe(false), Equals, time.Minute)
+	c.Check(p.Observe(false), Equals, time.Minute)
+	c.Check(p.Interval(), Equals, time.Minute)
+
+	c.Check(p.Observe(true), Equals, 10*time.Second)
+	c.Check(p.Interval(), Equals, 10*time.Second)
+
+	p = bart.NewAdaptivePoller(time.Minute, time.Second)
+	c.Check(p.Observe(false), Equals, time.Minute)
+}
+
+func (t *TestSuite) TestAdaptivePollerRun(c *C) {
+	p := bart.NewAdaptivePoller(time.Millisecond, 4*time.Millisecond)
+	stop := errors.New("stop")
+
+	var intervals []time.Duration
+
+	err := p.Run(context.Background(), func(ctx context.Context) (bool, error) {
+		intervals = append(intervals, p.Interval())
+
+		if len(intervals) == 5 {
+			return false, stop
+		}
+
+		r, err := t.c.GetAdvisories(ctx)
+
+		if err != nil {
+			return false, err
+		}
+
+		return r.Disrupted() && len(intervals) == 3, nil
+	})
+	c.Check(err, Equals, stop)
+	c.Check(intervals, DeepEquals, []time.Duration{
+		time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, time.Millisecond, 2 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = p.Run(ctx, func(ctx context.Context) (bool, error) { return false, nil })
+	c.Check(err, Equals, context.Canceled)
+}
+
+func (t *TestSuite) TestDisrupted(c *C) {
+	r, err := t.c.GetAdvisories(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(r.Disrupted(), Equals, true)
+
+	r.Advisories = []bart.Advisory{{Description: "No delays reported."}}
+	c.Check(r.Disrupted(), Equals, false)
+
+	e, err := t.c.GetEstimates(context.Background(), "MCAR")
+	c.Assert(err, IsNil)
+	c.Check(e.Delayed(), Equals, true)
+
+	e, err = t.c.GetEstimates(context.Background(), "12TH")
+	c.Assert(err, IsNil)
+	c.Check(e.Delayed(), Equals, false)
+}
@@ -0,0 +1,252 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAdvisoryHistory is the number of events an AdvisoryRecorder keeps
+// by default. See SetHistoryLimit.
+const DefaultAdvisoryHistory = 1000
+
+// AdvisoryEvent is the period an advisory was in effect for, as seen by an
+// AdvisoryRecorder. End is the zero value while the advisory is active.
+type AdvisoryEvent struct {
+	// ID is the advisory's ID, or its station and description if BART
+	// didn't give it one.
+	ID string
+
+	// Advisory is the advisory as it was last seen.
+	Advisory Advisory
+
+	Start time.Time
+	End   time.Time
+}
+
+// Active returns whether the advisory was still in effect the last time
+// the advisories were recorded.
+func (e AdvisoryEvent) Active() bool {
+	return e.End.IsZero()
+}
+
+// Duration returns how long the advisory was in effect for, or has been
+// so far if it's active, as of now.
+func (e AdvisoryEvent) Duration(now time.Time) time.Duration {
+	if e.Active() {
+		return now.Sub(e.Start)
+	}
+
+	return e.End.Sub(e.Start)
+}
+
+// AdvisoryStore persists the events of an AdvisoryRecorder. SaveAdvisoryEvent
+// is called with each event when it starts, and again when it ends, so it
+// should replace any event with the same ID and Start.
+type AdvisoryStore interface {
+	SaveAdvisoryEvent(e AdvisoryEvent) error
+}
+
+// AdvisoryRecorder polls the advisories, recording when each of them
+// appeared and cleared, to build a timeline of them. BART's "No delays
+// reported." advisory isn't recorded. It's safe for concurrent use.
+type AdvisoryRecorder struct {
+	c *Client
+
+	// saveMu is held while saving the events to the store, so they're
+	// saved in the order they were recorded without holding mu
+	saveMu sync.Mutex
+
+	mu     sync.Mutex
+	store  AdvisoryStore
+	limit  int
+	events []AdvisoryEvent
+
+	// active is the index in events of each active advisory, by ID
+	active map[string]int
+}
+
+// NewAdvisoryRecorder returns an AdvisoryRecorder fetching the advisories
+// using c.
+func NewAdvisoryRecorder(c *Client) *AdvisoryRecorder {
+	return &AdvisoryRecorder{c: c, limit: DefaultAdvisoryHistory, active: make(map[string]int)}
+}
+
+// SetHistoryLimit sets the number of events the recorder keeps in memory,
+// which is DefaultAdvisoryHistory by default. When there are more, the ones
+// that ended the longest ago are dropped; active events are always kept.
+// Zero or less means there's no limit. The events in the AdvisoryStore
+// aren't affected.
+func (r *AdvisoryRecorder) SetHistoryLimit(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.limit = n
+	r.trim()
+}
+
+// SetStore sets the AdvisoryStore the events are saved to. The default is
+// nil, which keeps them in memory only.
+func (r *AdvisoryRecorder) SetStore(s AdvisoryStore) {
+	r.mu.Lock()
+	r.store = s
+	r.mu.Unlock()
+}
+
+// Poll fetches the current advisories using GetAdvisories and records
+// them, returning whether they show a disruption. It has the signature
+// AdaptivePoller.Run expects, so the advisories can be recorded using:
+//
+//	err := bart.NewAdaptivePoller(time.Minute, 10*time.Minute).Run(ctx, r.Poll)
+func (r *AdvisoryRecorder) Poll(ctx context.Context) (bool, error) {
+	resp, err := r.c.GetAdvisories(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	return resp.Disrupted(), r.Record(resp, r.c.now())
+}
+
+// Record records the advisories in resp as being in effect at now. The
+// ones that were active and aren't in resp have ended at now. The events
+// that started or ended are then saved to the AdvisoryStore, sorted by ID,
+// without blocking History. The error is from the AdvisoryStore; the
+// advisories are recorded regardless.
+func (r *AdvisoryRecorder) Record(resp *AdvisoriesResponse, now time.Time) error {
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+
+	r.mu.Lock()
+
+	var changed []int
+
+	seen := make(map[string]bool, len(resp.Advisories))
+
+	for _, a := range resp.Advisories {
+		if AdvisoryStatus(a) == StatusNormal {
+			continue
+		}
+
+		id := advisoryID(a)
+		seen[id] = true
+
+		if i, ok := r.active[id]; ok {
+			r.events[i].Advisory = a
+			continue
+		}
+
+		r.active[id] = len(r.events)
+		r.events = append(r.events, AdvisoryEvent{ID: id, Advisory: a, Start: now})
+		changed = append(changed, len(r.events)-1)
+	}
+
+	for id, i := range r.active {
+		if !seen[id] {
+			r.events[i].End = now
+			delete(r.active, id)
+			changed = append(changed, i)
+		}
+	}
+
+	store := r.store
+	saves := make([]AdvisoryEvent, len(changed))
+
+	for j, i := range changed {
+		saves[j] = r.events[i]
+	}
+
+	r.trim()
+	r.mu.Unlock()
+
+	if store == nil {
+		return nil
+	}
+
+	sort.SliceStable(saves, func(i, j int) bool { return saves[i].ID < saves[j].ID })
+
+	var err error
+
+	for _, e := range saves {
+		if serr := store.SaveAdvisoryEvent(e); serr != nil && err == nil {
+			err = serr
+		}
+	}
+
+	return err
+}
+
+// trim drops the events that ended the longest ago while there are more
+// than the limit. r.mu must be held.
+func (r *AdvisoryRecorder) trim() {
+	drop := len(r.events) - r.limit
+
+	if r.limit <= 0 || drop <= 0 {
+		return
+	}
+
+	ended := make([]int, 0, len(r.events)-len(r.active))
+
+	for i, e := range r.events {
+		if !e.Active() {
+			ended = append(ended, i)
+		}
+	}
+
+	sort.SliceStable(ended, func(i, j int) bool { return r.events[ended[i]].End.Before(r.events[ended[j]].End) })
+
+	if drop > len(ended) {
+		drop = len(ended)
+	}
+
+	dropped := make(map[int]bool, drop)
+
+	for _, i := range ended[:drop] {
+		dropped[i] = true
+	}
+
+	events := r.events[:0]
+
+	for i, e := range r.events {
+		if dropped[i] {
+			continue
+		}
+
+		if e.Active() {
+			r.active[e.ID] = len(events)
+		}
+
+		events = append(events, e)
+	}
+
+	// clear the dropped events at the end, so they can be collected
+	for i := len(events); i < len(r.events); i++ {
+		r.events[i] = AdvisoryEvent{}
+	}
+
+	r.events = events
+}
+
+// History returns the recorded events, in the order they started. It's
+// limited to the most recent ones, see SetHistoryLimit.
+func (r *AdvisoryRecorder) History() []AdvisoryEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]AdvisoryEvent(nil), r.events...)
+}
+
+// advisoryID returns the ID identifying the advisory across polls.
+func advisoryID(a Advisory) string {
+	if id := strings.TrimSpace(a.ID); id != "" {
+		return id
+	}
+
+	return strings.ToUpper(strings.TrimSpace(a.Station)) + ":" + strings.TrimSpace(a.Description)
+}
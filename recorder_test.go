@@ -0,0 +1,168 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+type storeFunc func(e bart.AdvisoryEvent) error
+
+func (f storeFunc) SaveAdvisoryEvent(e bart.AdvisoryEvent) error { return f(e) }
+
+func (t *TestSuite) TestAdvisoryRecorder(c *C) {
+	r := bart.NewAdvisoryRecorder(t.c)
+
+	var saved []bart.AdvisoryEvent
+
+	r.SetStore(storeFunc(func(e bart.AdvisoryEvent) error {
+		saved = append(saved, e)
+		return nil
+	}))
+
+	start := time.Date(2019, 2, 4, 9, 0, 0, 0, bart.Pacific)
+
+	delay := bart.Advisory{ID: "1", Station: "BART", Description: "10-minute delay at West Oakland."}
+	closed := bart.Advisory{Station: "MCAR", Description: "Platform 3 is closed."}
+	none := bart.Advisory{Description: "No delays reported."}
+
+	snapshots := [][]bart.Advisory{
+		{none},
+		{delay},
+		{delay, closed},
+		{closed},
+		{none},
+	}
+
+	for i, s := range snapshots {
+		err := r.Record(&bart.AdvisoriesResponse{Advisories: s}, start.Add(time.Duration(i)*time.Minute))
+		c.Assert(err, IsNil)
+	}
+
+	h := r.History()
+	c.Assert(h, HasLen, 2)
+
+	c.Check(h[0].ID, Equals, "1")
+	c.Check(h[0].Start, Equals, start.Add(time.Minute))
+	c.Check(h[0].End, Equals, start.Add(3*time.Minute))
+	c.Check(h[0].Duration(time.Time{}), Equals, 2*time.Minute)
+
+	// advisories without an ID are keyed by their station and description
+	c.Check(h[1].ID, Equals, "MCAR:Platform 3 is closed.")
+	c.Check(h[1].Start, Equals, start.Add(2*time.Minute))
+	c.Check(h[1].End, Equals, start.Add(4*time.Minute))
+
+	// each event is saved when it starts and ends
+	c.Check(saved, HasLen, 4)
+	c.Check(saved[0].Active(), Equals, true)
+	c.Check(saved[3].Active(), Equals, false)
+
+	// store errors are returned, but the advisories are still recorded
+	r.SetStore(storeFunc(func(e bart.AdvisoryEvent) error { return errors.New("full") }))
+
+	err := r.Record(&bart.AdvisoriesResponse{Advisories: []bart.Advisory{delay}}, start.Add(time.Hour))
+	c.Check(err, ErrorMatches, "full")
+
+	h = r.History()
+	c.Assert(h, HasLen, 3)
+	c.Check(h[2].Active(), Equals, true)
+	c.Check(h[2].Duration(start.Add(2*time.Hour)), Equals, time.Hour)
+}
+
+func (t *TestSuite) TestAdvisoryRecorderHistoryLimit(c *C) {
+	r := bart.NewAdvisoryRecorder(t.c)
+	r.SetHistoryLimit(2)
+
+	start := time.Date(2019, 2, 4, 9, 0, 0, 0, bart.Pacific)
+	active := bart.Advisory{ID: "A", Description: "Platform 3 is closed."}
+
+	for i := 0; i < 4; i++ {
+		a := bart.Advisory{ID: strconv.Itoa(i), Description: "Delays."}
+
+		// each advisory ends when the next one starts
+		err := r.Record(&bart.AdvisoriesResponse{Advisories: []bart.Advisory{active, a}}, start.Add(time.Duration(i)*time.Minute))
+		c.Assert(err, IsNil)
+	}
+
+	// the active events are kept, along with the most recently ended
+	h := r.History()
+	c.Assert(h, HasLen, 2)
+	c.Check(h[0].ID, Equals, "A")
+	c.Check(h[1].ID, Equals, "3")
+
+	err := r.Record(&bart.AdvisoriesResponse{}, start.Add(time.Hour))
+	c.Assert(err, IsNil)
+
+	h = r.History()
+	c.Assert(h, HasLen, 2)
+	c.Check(h[0].End, Equals, start.Add(time.Hour))
+	c.Check(h[1].End, Equals, start.Add(time.Hour))
+
+	r.SetHistoryLimit(1)
+	c.Check(r.History(), HasLen, 1)
+}
+
+func (t *TestSuite) TestAdvisoryRecorderStore(c *C) {
+	r := bart.NewAdvisoryRecorder(t.c)
+
+	var ids []string
+
+	saving, release := make(chan struct{}), make(chan struct{})
+
+	r.SetStore(storeFunc(func(e bart.AdvisoryEvent) error {
+		if len(ids) == 0 {
+			close(saving)
+			<-release
+		}
+
+		ids = append(ids, e.ID)
+		return nil
+	}))
+
+	var advisories []bart.Advisory
+
+	for _, id := range []string{"C", "A", "D", "B"} {
+		advisories = append(advisories, bart.Advisory{ID: id, Description: "Delays."})
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- r.Record(&bart.AdvisoriesResponse{Advisories: advisories}, time.Now())
+	}()
+
+	// the history can be read while the store is saving
+	<-saving
+	c.Check(r.History(), HasLen, 4)
+	close(release)
+	c.Assert(<-done, IsNil)
+
+	// the ended events are saved in the same order every time
+	c.Assert(r.Record(&bart.AdvisoriesResponse{}, time.Now()), IsNil)
+	c.Check(ids, DeepEquals, []string{"A", "B", "C", "D", "A", "B", "C", "D"})
+}
+
+func (t *TestSuite) TestAdvisoryRecorderPoll(c *C) {
+	now := time.Date(2019, 2, 4, 10, 0, 0, 0, bart.Pacific)
+	t.c.SetClock(func() time.Time { return now })
+
+	r := bart.NewAdvisoryRecorder(t.c)
+
+	disrupted, err := r.Poll(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(disrupted, Equals, true)
+
+	h := r.History()
+	c.Assert(h, HasLen, 4)
+	c.Check(h[0].ID, Equals, "134")
+	c.Check(h[0].Start, Equals, now)
+	c.Check(h[0].Active(), Equals, true)
+}
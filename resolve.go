@@ -0,0 +1,157 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownStation is returned when a station can't be found.
+var ErrUnknownStation = errors.New("bart: unknown station")
+
+// ErrAmbiguousStation is returned when a station name
+// matches more than one station.
+var ErrAmbiguousStation = errors.New("bart: ambiguous station")
+
+// ResolveStation returns the abbreviation of the station with the human
+// name, such as "Powell Street". The name is matched case-insensitively
+// against the station abbreviations and names, ignoring punctuation and
+// common abbreviations like "St" for "Street". If there's no exact match a
+// partial one is used, and ErrAmbiguousStation is returned if the name
+// partially matches more than one station.
+//
+// The station list from the last call to GetStations is used if there has
+// been one, otherwise the offline data from Stations is used.
+func (c *Client) ResolveStation(name string) (string, error) {
+	return resolveStation(c.stationList(), name)
+}
+
+// stationList returns the cached station list, or the offline one.
+func (c *Client) stationList() []Station {
+	c.mu.Lock()
+	stations := c.stations
+	c.mu.Unlock()
+
+	if stations != nil {
+		return stations.Stations
+	}
+
+	return Stations()
+}
+
+// stationAbbr returns the abbreviation of the station id refers to, for all
+// of the methods taking stations. It's a GTFS stop ID if the WithGTFSStopIDs option is
+// set. Otherwise it's normalized to uppercase, unless WithRawStationCodes
+// is set, and must be one of the stations from Stations or the cached
+// station list. "ALL", used to get the estimates of every station, is
+// passed through.
+func (c *Client) stationAbbr(o *options, id string) (string, error) {
+	if strings.EqualFold(strings.TrimSpace(id), "ALL") {
+		return "ALL", nil
+	}
+
+	if o.gtfs {
+		return GTFSStopToStation(id)
+	}
+
+	if o.rawStations {
+		return id, nil
+	}
+
+	abbr := strings.ToUpper(strings.TrimSpace(id))
+
+	if !c.knownStation(abbr) {
+		return "", fmt.Errorf("%w: %q", ErrUnknownStation, id)
+	}
+
+	return abbr, nil
+}
+
+// knownStation returns whether abbr is the abbreviation of a station in
+// the offline data or the cached station list, so stations added since
+// the offline snapshot are known once GetStations has been called.
+func (c *Client) knownStation(abbr string) bool {
+	c.mu.Lock()
+	stations := c.stations
+	c.mu.Unlock()
+
+	if stations != nil {
+		for _, s := range stations.Stations {
+			if strings.EqualFold(s.Abbr, abbr) {
+				return true
+			}
+		}
+	}
+
+	for _, s := range Stations() {
+		if strings.EqualFold(s.Abbr, abbr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func resolveStation(stations []Station, name string) (string, error) {
+	query := normalizeStationName(name)
+
+	if query == "" {
+		return "", fmt.Errorf("%w: %q", ErrUnknownStation, name)
+	}
+
+	for _, s := range stations {
+		if strings.EqualFold(s.Abbr, strings.TrimSpace(name)) || normalizeStationName(s.Name) == query {
+			return s.Abbr, nil
+		}
+	}
+
+	var matches []string
+
+	for _, s := range stations {
+		if strings.Contains(" "+normalizeStationName(s.Name)+" ", " "+query) {
+			matches = append(matches, s.Abbr)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: %q", ErrUnknownStation, name)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%w: %q matches %s", ErrAmbiguousStation, name, strings.Join(matches, ", "))
+	}
+}
+
+// stationWords are replacements for words that are commonly
+// written differently than they are in the station names.
+var stationWords = map[string]string{
+	"street":        "st",
+	"saint":         "st",
+	"international": "intl",
+	"int'l":         "intl",
+	"sf":            "san francisco",
+}
+
+// normalizeStationName lowercases the name, removes the punctuation,
+// and replaces the words in stationWords.
+func normalizeStationName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer("/", " ", "-", " ", ".", "", ",", "").Replace(name)
+
+	words := strings.Fields(name)
+
+	for i, w := range words {
+		if r, ok := stationWords[w]; ok {
+			words[i] = r
+		}
+
+		words[i] = strings.Replace(words[i], "'", "", -1)
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,111 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestResolveStation(c *C) {
+	tests := []struct {
+		name, abbr string
+	}{
+		{"Powell Street", "POWL"},
+		{"powell st.", "POWL"},
+		{"POWL", "POWL"},
+		{"powl", "POWL"},
+		{"MacArthur", "MCAR"},
+		{"macarthur", "MCAR"},
+		{"Civic Center", "CIVC"},
+		{"16th St", "16TH"},
+		{"16th street mission", "16TH"},
+		{"Downtown Berkeley", "DBRK"},
+		{"north berkeley", "NBRK"},
+		{"San Francisco International Airport", "SFIA"},
+		{"Pleasant Hill", "PHIL"},
+		{"Warm Springs", "WARM"},
+	}
+
+	for _, tt := range tests {
+		abbr, err := t.c.ResolveStation(tt.name)
+		c.Check(err, IsNil, Commentf("%s", tt.name))
+		c.Check(abbr, Equals, tt.abbr, Commentf("%s", tt.name))
+	}
+}
+
+func (t *TestSuite) TestResolveStationErrors(c *C) {
+	_, err := t.c.ResolveStation("Mission")
+	c.Check(errors.Is(err, bart.ErrAmbiguousStation), Equals, true)
+	c.Check(err, ErrorMatches, `bart: ambiguous station: "Mission" matches 16TH, 24TH`)
+
+	_, err = t.c.ResolveStation("Berkeley")
+	c.Check(errors.Is(err, bart.ErrAmbiguousStation), Equals, true)
+
+	_, err = t.c.ResolveStation("Hogwarts")
+	c.Check(errors.Is(err, bart.ErrUnknownStation), Equals, true)
+
+	_, err = t.c.ResolveStation("  ")
+	c.Check(errors.Is(err, bart.ErrUnknownStation), Equals, true)
+}
+
+func (t *TestSuite) TestResolveStationCached(c *C) {
+	// the fixture only has three stations, none of which are Powell
+	_, err := t.c.GetStations(context.Background())
+	c.Assert(err, IsNil)
+
+	_, err = t.c.ResolveStation("Powell Street")
+	c.Check(errors.Is(err, bart.ErrUnknownStation), Equals, true)
+
+	abbr, err := t.c.ResolveStation("west oakland")
+	c.Check(err, IsNil)
+	c.Check(abbr, Equals, "WOAK")
+}
+
+func (t *TestSuite) TestStationCodes(c *C) {
+	ctx := context.Background()
+
+	_, err := t.c.GetStationInfo(ctx, " mcar ")
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("stninfo").Get("orig"), Equals, "MCAR")
+
+	_, err = t.c.GetEstimates(ctx, "MCRA")
+	c.Check(errors.Is(err, bart.ErrUnknownStation), Equals, true)
+	c.Check(err, ErrorMatches, `bart: unknown station: "MCRA"`)
+	c.Check(t.srv.count("etd"), Equals, 0)
+
+	// stations from the cached list are known, even if they aren't
+	// in the offline data
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") == "stns" {
+			rw.Write([]byte(`<root><stations><station><abbr>NEWS</abbr><name>New Station</name></station></stations><message /></root>`))
+			return
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	_, err = t.c.GetStations(ctx)
+	c.Assert(err, IsNil)
+
+	_, err = t.c.GetStationSchedule(ctx, "news", time.Time{})
+	c.Check(errors.Is(err, bart.ErrUnknownStation), Equals, false)
+	c.Check(t.srv.query("stnsched").Get("orig"), Equals, "NEWS")
+
+	// raw codes are passed through as is
+	t.c.GetEstimates(ctx, "mcra", bart.WithRawStationCodes())
+	c.Check(t.srv.query("etd").Get("orig"), Equals, "mcra")
+
+	abbr, err := t.c.ResolveStation(" news ")
+	c.Assert(err, IsNil)
+	c.Check(abbr, Equals, "NEWS")
+}
@@ -0,0 +1,275 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// ErrNoConnectingRoute is returned when the RequireConnectingRoute
+// option is set and no route serves both of the stations.
+var ErrNoConnectingRoute = errors.New("bart: no route connects the stations")
+
+// Route is the general information about a BART route.
+type Route struct {
+	Name     string `xml:"name"`
+	Abbr     string `xml:"abbr"`
+	RouteID  string `xml:"routeID"`
+	Number   int    `xml:"number"`
+	HexColor string `xml:"hexcolor"`
+	Color    string `xml:"color"`
+}
+
+// RoutesResponse is the response of the routes command. SchedNum is the
+// ID of the schedule the routes are from, which can be used to find its
+// effective date in the list returned by GetScheduleList.
+type RoutesResponse struct {
+	bartapi.Envelope
+	Meta
+	SchedNum int     `xml:"sched_num"`
+	Routes   []Route `xml:"routes>route"`
+}
+
+// RouteInfo is the detailed information about a BART route, including
+// the stations it serves.
+type RouteInfo struct {
+	Route
+	Origin      string `xml:"origin"`
+	Destination string `xml:"destination"`
+	NumStations int    `xml:"num_stns"`
+
+	// Stations is the abbreviations of the stations served by the
+	// route, in the order they are served.
+	Stations []string `xml:"config>station"`
+}
+
+// Serves returns whether the route stops at orig and then, later,
+// at dest. Both are station abbreviations.
+func (r *RouteInfo) Serves(orig, dest string) bool {
+	o, d := r.stop(orig), r.stop(dest)
+	return o >= 0 && d > o
+}
+
+// stop returns the index of the station in Stations, or -1.
+func (r *RouteInfo) stop(abbr string) int {
+	for i, s := range r.Stations {
+		if s == abbr {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// connecting returns ErrNoConnectingRoute if the RequireConnectingRoute
+// option is set and none of the routes stop at both orig and dest, in
+// either order.
+func (c *Client) connecting(ctx context.Context, o *options, orig, dest string) error {
+	if !o.connecting {
+		return nil
+	}
+
+	routes, err := c.routeInfos(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	orig, dest = strings.ToUpper(orig), strings.ToUpper(dest)
+
+	for i := range routes {
+		if routes[i].stop(orig) >= 0 && routes[i].stop(dest) >= 0 {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s and %s", ErrNoConnectingRoute, orig, dest)
+}
+
+// RouteInfoResponse is the response of the routeinfo command.
+type RouteInfoResponse struct {
+	bartapi.Envelope
+	Meta
+	SchedNum int       `xml:"sched_num"`
+	Route    RouteInfo `xml:"routes>route"`
+}
+
+// GetRoutes returns the list of current BART routes. Use the RouteWithDate
+// option to get the routes in effect on another date.
+func (c *Client) GetRoutes(ctx context.Context, opts ...Option) (*RoutesResponse, error) {
+	o := newOptions(opts)
+	query := make(map[string]string)
+
+	if !o.routeDate.IsZero() {
+		if err := c.checkDate(o.routeDate); err != nil {
+			return nil, err
+		}
+
+		query["date"] = formatDate(o.routeDate)
+	}
+
+	r := &RoutesResponse{}
+
+	if err := c.get(ctx, c.route, "routes", query, r, opts...); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// StationStop is a station served by a route, with its location.
+type StationStop struct {
+	Abbr      string
+	Name      string
+	Latitude  float64
+	Longitude float64
+}
+
+// RouteStops returns the stations served by the route with the number, in
+// the order they're served, along with their names and locations. It's
+// built from the route's info and the station list, which is fetched using
+// GetStations if it hasn't been already. Stations missing from the list,
+// like those that opened since it was cached, are looked up in the offline
+// data returned by Stations.
+func (c *Client) RouteStops(ctx context.Context, number int) ([]StationStop, error) {
+	info, err := c.GetRouteInfo(ctx, number)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stations, err := c.GetStations(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]Station)
+
+	for _, list := range [][]Station{Stations(), stations.Stations} {
+		for _, s := range list {
+			index[s.Abbr] = s
+		}
+	}
+
+	stops := make([]StationStop, len(info.Route.Stations))
+
+	for i, abbr := range info.Route.Stations {
+		s, ok := index[abbr]
+
+		if !ok {
+			return nil, fmt.Errorf("%w: %s on route %d", ErrUnknownStation, abbr, number)
+		}
+
+		stops[i] = StationStop{Abbr: s.Abbr, Name: s.Name, Latitude: s.Latitude, Longitude: s.Longitude}
+	}
+
+	return stops, nil
+}
+
+// GetRouteInfo returns the detailed information for the route
+// with the number.
+func (c *Client) GetRouteInfo(ctx context.Context, number int, opts ...Option) (*RouteInfoResponse, error) {
+	r := &RouteInfoResponse{}
+
+	if err := c.get(ctx, c.route, "routeinfo", map[string]string{"route": strconv.Itoa(number)}, r, opts...); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetAllRouteInfo returns the detailed information for every route, keyed
+// by route number. The routes are fetched using GetRoutes, and then their
+// info is fetched concurrently, bounded by the WithConcurrency option. If
+// any of them fail a MultiError, keyed by route number, is returned along
+// with the info of the other routes.
+func (c *Client) GetAllRouteInfo(ctx context.Context, opts ...Option) (map[int]*RouteInfoResponse, error) {
+	o := newOptions(opts)
+
+	routes, err := c.GetRoutes(ctx, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nums := make([]string, len(routes.Routes))
+
+	for i, r := range routes.Routes {
+		nums[i] = strconv.Itoa(r.Number)
+	}
+
+	var mu sync.Mutex
+
+	infos := make(map[int]*RouteInfoResponse, len(nums))
+
+	err = forEach(nums, o.concurrency, func(num string) error {
+		n, _ := strconv.Atoi(num)
+
+		r, err := c.GetRouteInfo(ctx, n, opts...)
+
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		infos[n] = r
+		mu.Unlock()
+
+		return nil
+	})
+
+	return infos, err
+}
+
+// routeInfos returns the detailed information for all of the routes,
+// ordered by route number. It's fetched once and then cached by the client,
+// as the routes only change when BART publishes a new schedule. Concurrent
+// calls share the requests, which aren't canceled with their contexts.
+func (c *Client) routeInfos(ctx context.Context) ([]RouteInfo, error) {
+	c.mu.Lock()
+	cached := c.routes
+	c.mu.Unlock()
+
+	if cached != nil {
+		return cached, nil
+	}
+
+	v, err := c.shared(ctx, "routeinfo", func(ctx context.Context) (interface{}, error) {
+		all, err := c.GetAllRouteInfo(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		infos := make([]RouteInfo, 0, len(all))
+
+		for _, r := range all {
+			infos = append(infos, r.Route)
+		}
+
+		sort.Slice(infos, func(i, j int) bool { return infos[i].Number < infos[j].Number })
+
+		c.mu.Lock()
+		c.routes = infos
+		c.mu.Unlock()
+
+		return infos, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]RouteInfo), nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestGetRoutes(c *C) {
+	r, err := t.c.GetRoutes(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(r.SchedNum, Equals, 47)
+	c.Assert(r.Routes, HasLen, 12)
+	c.Check(r.Routes[6], DeepEquals, bart.Route{
+		Name:     "Richmond - Daly City/Millbrae",
+		Abbr:     "RICH-MLBR",
+		RouteID:  "ROUTE 7",
+		Number:   7,
+		HexColor: "#ff0000",
+		Color:    "RED",
+	})
+}
+
+func (t *TestSuite) TestRouteWithDate(c *C) {
+	var date string
+
+	fixtures := t.srv.Config.Handler
+
+	// serve the routes from before the Berryessa extension opened
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") == "routes" && req.FormValue("date") != "" {
+			date = req.FormValue("date")
+			http.ServeFile(rw, req, "testdata/routes_46.xml")
+			return
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	ctx := context.Background()
+	past := time.Date(2019, 1, 15, 0, 0, 0, 0, bart.Pacific)
+
+	r, err := t.c.GetRoutes(ctx, bart.RouteWithDate(past))
+	c.Assert(err, IsNil)
+	c.Check(date, Equals, "01/15/2019")
+	c.Check(r.SchedNum, Equals, 46)
+	c.Assert(r.Routes, HasLen, 2)
+	c.Check(r.Routes[0].Name, Equals, "Warm Springs/South Fremont - Richmond")
+
+	scheds, err := t.c.GetScheduleList(ctx)
+	c.Assert(err, IsNil)
+
+	s, ok := scheds.Schedule(r.SchedNum)
+	c.Assert(ok, Equals, true)
+	c.Check(s.EffectiveDate, Equals, "01/14/2019 12:00 AM")
+
+	_, ok = scheds.Schedule(1)
+	c.Check(ok, Equals, false)
+
+	// the current routes
+	r, err = t.c.GetRoutes(ctx)
+	c.Assert(err, IsNil)
+	c.Check(r.SchedNum, Equals, 47)
+	c.Check(t.srv.query("routes").Get("date"), Equals, "")
+
+	// the date is validated against the schedules
+	_, err = t.c.GetRoutes(ctx, bart.RouteWithDate(past.AddDate(-1, 0, 0)))
+	c.Check(err, Equals, bart.ErrDateOutOfRange)
+}
+
+func (t *TestSuite) TestGetRouteInfo(c *C) {
+	r, err := t.c.GetRouteInfo(context.Background(), 7)
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("routeinfo").Get("route"), Equals, "7")
+
+	c.Check(r.Route.Name, Equals, "Richmond - Daly City/Millbrae")
+	c.Check(r.Route.Origin, Equals, "RICH")
+	c.Check(r.Route.Destination, Equals, "MLBR")
+	c.Check(r.Route.NumStations, Equals, 23)
+	c.Assert(r.Route.Stations, HasLen, 23)
+	c.Check(r.Route.Stations[6], Equals, "MCAR")
+
+	c.Check(r.Route.Serves("MCAR", "POWL"), Equals, true)
+	c.Check(r.Route.Serves("POWL", "MCAR"), Equals, false)
+	c.Check(r.Route.Serves("MCAR", "MCAR"), Equals, false)
+	c.Check(r.Route.Serves("MCAR", "ANTC"), Equals, false)
+}
+
+func (t *TestSuite) TestRouteStops(c *C) {
+	stops, err := t.c.RouteStops(context.Background(), 7)
+	c.Assert(err, IsNil)
+	c.Assert(stops, HasLen, 23)
+	c.Check(stops[0].Abbr, Equals, "RICH")
+	c.Check(stops[0].Name, Equals, "Richmond")
+	c.Check(stops[22].Abbr, Equals, "MLBR")
+
+	// from the station list
+	c.Check(stops[6], DeepEquals, bart.StationStop{
+		Abbr:      "MCAR",
+		Name:      "MacArthur",
+		Latitude:  37.829065,
+		Longitude: -122.26704,
+	})
+
+	for _, s := range stops {
+		c.Check(s.Latitude > 37 && s.Latitude < 38.1, Equals, true, Commentf("%s", s.Abbr))
+		c.Check(s.Longitude > -122.6 && s.Longitude < -121.8, Equals, true, Commentf("%s", s.Abbr))
+	}
+
+	c.Check(t.srv.count("stns"), Equals, 1)
+
+	_, err = t.c.RouteStops(context.Background(), 99)
+	c.Check(err, NotNil)
+}
+
+func (t *TestSuite) TestGetAllRouteInfo(c *C) {
+	infos, err := t.c.GetAllRouteInfo(context.Background(), bart.WithConcurrency(2))
+	c.Assert(err, IsNil)
+	c.Check(infos, HasLen, 12)
+	c.Check(t.srv.count("routeinfo"), Equals, 12)
+	c.Check(infos[3].Route.Number, Equals, 3)
+	c.Check(infos[3].SchedNum, Equals, 47)
+
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") == "routeinfo" && req.FormValue("route") == "7" {
+			http.Error(rw, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	infos, err = t.c.GetAllRouteInfo(context.Background())
+	c.Assert(err, FitsTypeOf, bart.MultiError{})
+	c.Check(err.(bart.MultiError), HasLen, 1)
+	c.Check(err.(bart.MultiError)["7"], NotNil)
+	c.Check(infos, HasLen, 11)
+}
+
+func (t *TestSuite) TestRouteInfosCanceled(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	var once sync.Once
+
+	arrived, release := make(chan struct{}), make(chan struct{})
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") == "routes" {
+			once.Do(func() { close(arrived) })
+			<-release
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+
+	go func() {
+		_, err := t.c.ExportLines(ctx)
+		first <- err
+	}()
+
+	<-arrived
+
+	second := make(chan []bart.Line, 1)
+
+	go func() {
+		lines, err := t.c.ExportLines(context.Background())
+		c.Check(err, IsNil)
+		second <- lines
+	}()
+
+	// let the second call join the requests before the first is canceled
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	c.Check(<-first, Equals, context.Canceled)
+
+	close(release)
+	c.Check(<-second, HasLen, 12)
+	c.Check(t.srv.count("routes"), Equals, 1)
+}
@@ -0,0 +1,220 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// ErrUnknownTrain is returned by FollowTrain when the route's schedule
+// doesn't have a train with the index.
+var ErrUnknownTrain = errors.New("bart: unknown train")
+
+// TrainStop is a stop made by a train in a route's schedule.
+type TrainStop struct {
+	Station  string `xml:"station,attr"`
+	OrigTime string `xml:"origTime,attr"`
+	BikeFlag bool   `xml:"bikeflag,attr"`
+	Load     int    `xml:"load,attr"`
+	Level    string `xml:"level,attr"`
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+func (s *TrainStop) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type trainStop TrainStop
+
+	v := struct {
+		*trainStop
+		BikeFlag xmlBool `xml:"bikeflag,attr"`
+	}{trainStop: (*trainStop)(s)}
+
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+
+	s.BikeFlag = bool(v.BikeFlag)
+
+	return nil
+}
+
+// RouteTrain is a single train in a route's schedule, with the stops it
+// makes. Stops the train doesn't make are omitted.
+type RouteTrain struct {
+	ID    string      `xml:"trainId,attr"`
+	Index int         `xml:"trainIdx,attr"`
+	Stops []TrainStop `xml:"stop"`
+
+	// Extra is the train's attributes that don't have a field, when the
+	// WithExtraAttrs option is used.
+	Extra map[string]string `xml:"-"`
+}
+
+// RouteScheduleResponse is the response of the routesched command.
+type RouteScheduleResponse struct {
+	bartapi.Envelope
+	Meta
+	Date     string       `xml:"date"`
+	SchedNum int          `xml:"sched_num"`
+	Trains   []RouteTrain `xml:"route>train"`
+
+	// EffectiveDate is the Date parsed as the start of the day in
+	// Pacific time, or the zero value if it's invalid.
+	EffectiveDate time.Time `xml:"-"`
+}
+
+// GetRouteSchedule returns the schedule of the route with the number on
+// the given date. If date is the zero value, today's schedule is returned.
+// If it doesn't have any trains the response is returned along with
+// ErrNoScheduleData.
+func (c *Client) GetRouteSchedule(ctx context.Context, number int, date time.Time, opts ...Option) (*RouteScheduleResponse, error) {
+	query := newOptions(opts).query(map[string]string{"route": strconv.Itoa(number)})
+
+	if !date.IsZero() {
+		if err := c.checkDate(date); err != nil {
+			return nil, err
+		}
+
+		query["date"] = formatDate(date)
+	}
+
+	r := &RouteScheduleResponse{}
+
+	if err := c.get(ctx, c.schedule, "routesched", query, r, opts...); err != nil {
+		return nil, err
+	}
+
+	r.EffectiveDate = parseServiceDate(r.Date)
+
+	if len(r.Trains) == 0 {
+		return r, noScheduleData("routesched", r.Date)
+	}
+
+	return r, nil
+}
+
+// Train returns the train in the schedule with the index, which is the
+// trainIdx of the train in a route or station schedule, and whether
+// it was found.
+func (r *RouteScheduleResponse) Train(index int) (RouteTrain, bool) {
+	for _, t := range r.Trains {
+		if t.Index == index {
+			return t, true
+		}
+	}
+
+	return RouteTrain{}, false
+}
+
+// FollowTrain returns the stops made by the train with the index trainIdx
+// on the route with the number, in order, from today's schedule of the
+// route. The index is the trainIdx of the train in a route or station
+// schedule. ErrUnknownTrain is returned if the route doesn't have a train
+// with the index.
+func (c *Client) FollowTrain(ctx context.Context, routeNum, trainIdx int, opts ...Option) ([]TrainStop, error) {
+	r, err := c.GetRouteSchedule(ctx, routeNum, time.Time{}, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := r.Train(trainIdx)
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %d on route %d", ErrUnknownTrain, trainIdx, routeNum)
+	}
+
+	return t.Stops, nil
+}
+
+// SystemSchedule is the schedule of every route on a single day.
+type SystemSchedule struct {
+	Date     string
+	SchedNum int
+
+	// Routes is the schedule of each route, keyed by route number.
+	Routes map[int]*RouteScheduleResponse
+}
+
+// GetSystemSchedule returns the schedule of every route on the given date,
+// or today's if date is the zero value. The routes are fetched using
+// GetRoutes, and then their schedules are fetched concurrently, bounded by
+// the WithConcurrency option.
+//
+// The responses aren't decoded as they're read: each route's response is
+// read in full and then decoded, like the other methods, so memory peaks
+// at a response per request in flight on top of the decoded schedules.
+// The responses aren't kept once they're decoded, unless they're cached
+// using SetScheduleCache or SetStaleIfError.
+//
+// This makes a request for each route, so can take a while. The WithProgress
+// option can be used to report how many of the routes have been fetched. If
+// any of them fail a MultiError, keyed by route number, is returned along
+// with the schedules of the other routes. Routes without any trains are
+// in the schedule, and their ErrNoScheduleData in the MultiError.
+func (c *Client) GetSystemSchedule(ctx context.Context, date time.Time, opts ...Option) (*SystemSchedule, error) {
+	o := newOptions(opts)
+
+	routes, err := c.GetRoutes(ctx, append(append([]Option(nil), opts...), RouteWithDate(date))...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nums := make([]string, len(routes.Routes))
+
+	for i, r := range routes.Routes {
+		nums[i] = strconv.Itoa(r.Number)
+	}
+
+	var mu sync.Mutex
+	var done int
+
+	s := &SystemSchedule{SchedNum: routes.SchedNum, Routes: make(map[int]*RouteScheduleResponse, len(nums))}
+
+	err = forEach(nums, o.concurrency, func(num string) error {
+		n, _ := strconv.Atoi(num)
+
+		r, err := c.GetRouteSchedule(ctx, n, date, opts...)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		done++
+
+		if o.progress != nil {
+			o.progress(done, len(nums))
+		}
+
+		// routes without any trains that day are kept,
+		// along with the ErrNoScheduleData
+		if r == nil {
+			return err
+		}
+
+		s.Routes[n] = r
+
+		if s.Date == "" {
+			s.Date = r.Date
+		}
+
+		return err
+	})
+
+	return s, err
+}
+
+// IsStale returns whether the route schedule is stale at now. See
+// StationScheduleResponse.IsStale for details.
+func (r *RouteScheduleResponse) IsStale(now time.Time) bool {
+	return isStale(r.EffectiveDate, now, r.staleAge)
+}
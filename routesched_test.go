@@ -0,0 +1,113 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestGetRouteSchedule(c *C) {
+	date := time.Date(2019, 2, 11, 0, 0, 0, 0, bart.Pacific)
+
+	r, err := t.c.GetRouteSchedule(context.Background(), 6, date)
+	c.Assert(err, IsNil)
+
+	q := t.srv.query("routesched")
+	c.Check(q.Get("route"), Equals, "6")
+	c.Check(q.Get("date"), Equals, "02/11/2019")
+
+	c.Check(r.SchedNum, Equals, 47)
+	c.Assert(r.Trains, HasLen, 2)
+	c.Check(r.Trains[1].ID, Equals, "6-2")
+	c.Check(r.Trains[1].Index, Equals, 2)
+	c.Assert(r.Trains[1].Stops, HasLen, 3)
+	c.Check(r.Trains[0].Stops[2], DeepEquals, bart.TrainStop{
+		Station: "WARM", OrigTime: "5:52 am", BikeFlag: true, Level: "normal",
+	})
+	c.Check(r.Trains[1].Stops[0].BikeFlag, Equals, false)
+	c.Check(r.Trains[1].Stops[0].Load, Equals, 2)
+}
+
+func (t *TestSuite) TestGetSystemSchedule(c *C) {
+	var calls [][2]int
+
+	s, err := t.c.GetSystemSchedule(context.Background(), time.Time{}, bart.WithProgress(func(done, total int) {
+		calls = append(calls, [2]int{done, total})
+	}))
+	c.Assert(err, IsNil)
+
+	c.Check(s.Date, Equals, "02/11/2019")
+	c.Check(s.SchedNum, Equals, 47)
+	c.Check(s.Routes, HasLen, 12)
+	c.Check(s.Routes[20].Trains, HasLen, 2)
+	c.Check(t.srv.count("routesched"), Equals, 12)
+
+	c.Assert(calls, HasLen, 12)
+
+	for i, call := range calls {
+		c.Check(call, Equals, [2]int{i + 1, 12})
+	}
+}
+
+func (t *TestSuite) TestGetSystemScheduleError(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") == "routesched" && req.FormValue("route") == "20" {
+			http.Error(rw, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	s, err := t.c.GetSystemSchedule(context.Background(), time.Time{})
+	c.Assert(err, FitsTypeOf, bart.MultiError{})
+	c.Check(err.(bart.MultiError), HasLen, 1)
+	c.Check(err.(bart.MultiError)["20"], NotNil)
+
+	c.Check(s.Routes, HasLen, 11)
+	c.Check(s.Routes[20], IsNil)
+}
+
+func (t *TestSuite) TestGetSystemScheduleRoutesOptions(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") == "routes" {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	// the options apply to the routes request as well
+	_, err := t.c.GetSystemSchedule(context.Background(), time.Time{}, bart.WithTimeout(50*time.Millisecond))
+	c.Assert(err, NotNil)
+	c.Check(err, Not(FitsTypeOf), bart.MultiError{})
+	c.Check(t.srv.count("routesched"), Equals, 0)
+}
+
+func (t *TestSuite) TestFollowTrain(c *C) {
+	stops, err := t.c.FollowTrain(context.Background(), 6, 2)
+	c.Assert(err, IsNil)
+	c.Check(t.srv.query("routesched").Get("route"), Equals, "6")
+	c.Check(t.srv.query("routesched").Get("date"), Equals, "")
+
+	c.Assert(stops, HasLen, 3)
+	c.Check(stops[0].Station, Equals, "DALY")
+	c.Check(stops[0].OrigTime, Equals, "5:33 am")
+	c.Check(stops[2].Station, Equals, "WARM")
+
+	_, err = t.c.FollowTrain(context.Background(), 6, 3)
+	c.Check(errors.Is(err, bart.ErrUnknownTrain), Equals, true)
+	c.Check(err, ErrorMatches, "bart: unknown train: 3 on route 6")
+}
@@ -0,0 +1,154 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// ServiceDayStartHour is the hour, in Pacific time, that BART's service
+// day starts. Trains running after midnight are part of the previous
+// day's schedule until then.
+const ServiceDayStartHour = 3
+
+// scheduleCmds are the commands whose responses are cached, when enabled
+// using SetScheduleCache, until the end of the service day they're for.
+var scheduleCmds = map[string]bool{"stnsched": true, "routesched": true}
+
+// scheduleEntry is a cached schedule response.
+type scheduleEntry struct {
+	resp    *bartapi.Response
+	expires time.Time
+}
+
+// ServiceDayEnd returns the end of the service day of date, which is
+// ServiceDayStartHour on the following day in Pacific time. Only the
+// date, in Pacific time, of date is used.
+func ServiceDayEnd(date time.Time) time.Time {
+	date = date.In(Pacific)
+	return time.Date(date.Year(), date.Month(), date.Day()+1, ServiceDayStartHour, 0, 0, 0, Pacific)
+}
+
+// serviceDay returns the start of the service day that t is in, which
+// is the previous day before ServiceDayStartHour.
+func serviceDay(t time.Time) time.Time {
+	t = t.In(Pacific)
+
+	if t.Hour() < ServiceDayStartHour {
+		t = t.AddDate(0, 0, -1)
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, Pacific)
+}
+
+// SetScheduleCache sets whether the responses of GetStationSchedule and
+// GetRouteSchedule are cached. A schedule is for a date, so rather than
+// using a fixed TTL each response is cached until the end of the service
+// day it's for, as returned by ServiceDayEnd, using the clock set with
+// SetClock. Calls for the same schedule share the cached response until
+// then. Responses with a status other than 200, or an error in their
+// envelope, aren't cached. It's disabled by default, and disabling it
+// empties the cache.
+func (c *Client) SetScheduleCache(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !enabled {
+		c.scheduleCache = nil
+	} else if c.scheduleCache == nil {
+		c.scheduleCache = make(map[string]scheduleEntry)
+	}
+}
+
+// pullCached calls pull, or returns the cached response if the schedule
+// cache is enabled and has an unexpired one for the request. Requests that
+// aren't for a schedule are coalesced instead.
+func (c *Client) pullCached(api *bartapi.Client, cmd string, query map[string]string, pull func() (*bartapi.Response, error)) (*bartapi.Response, error) {
+	c.mu.Lock()
+	enabled := c.scheduleCache != nil
+	c.mu.Unlock()
+
+	if !enabled || !scheduleCmds[cmd] {
+		return c.pullCoalesced(api, cmd, query, pull)
+	}
+
+	key := coalesceKey(api, cmd, query)
+	now := c.now()
+
+	c.mu.Lock()
+	e, ok := c.scheduleCache[key]
+	c.mu.Unlock()
+
+	if ok && now.Before(e.expires) {
+		return e.resp, nil
+	}
+
+	resp, err := pull()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// failures are returned without being cached, so the next call
+	// tries again
+	if responseErr(resp) != nil {
+		return resp, nil
+	}
+
+	expires := ServiceDayEnd(scheduleDate(resp.Body, now))
+
+	c.mu.Lock()
+
+	// the cache may have been disabled during the request
+	if c.scheduleCache != nil {
+		for k, e := range c.scheduleCache {
+			if !now.Before(e.expires) {
+				delete(c.scheduleCache, k)
+			}
+		}
+
+		if now.Before(expires) {
+			c.scheduleCache[key] = scheduleEntry{resp: resp, expires: expires}
+		}
+	}
+
+	c.mu.Unlock()
+
+	return resp, nil
+}
+
+// responseErr returns the error of a response that PullResponse doesn't
+// treat as one: a status other than 200, or an error in its envelope.
+func responseErr(resp *bartapi.Response) error {
+	if resp.StatusCode != http.StatusOK {
+		return &bartapi.StatusError{StatusCode: resp.StatusCode}
+	}
+
+	if e, err := bartapi.DecodeEnvelope(resp.Body); err == nil {
+		return e.Err()
+	}
+
+	return nil
+}
+
+// scheduleDate returns the date the schedule response in body is for, or
+// the service day of now if it doesn't have a valid one.
+func scheduleDate(body []byte, now time.Time) time.Time {
+	var v struct {
+		Date string `xml:"date"`
+	}
+
+	if err := bartapi.Decode(bytes.NewReader(body), &v); err == nil {
+		if d := parseServiceDate(v.Date); !d.IsZero() {
+			return d
+		}
+	}
+
+	return serviceDay(now)
+}
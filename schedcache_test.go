@@ -0,0 +1,115 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (*TestSuite) TestServiceDayEnd(c *C) {
+	c.Check(bart.ServiceDayEnd(time.Date(2019, 1, 1, 23, 0, 0, 0, bart.Pacific)), Equals, time.Date(2019, 1, 2, 3, 0, 0, 0, bart.Pacific))
+
+	// the date is in Pacific time, and the next day may be a DST change
+	end := bart.ServiceDayEnd(time.Date(2019, 3, 10, 6, 0, 0, 0, time.UTC))
+	c.Check(end, Equals, time.Date(2019, 3, 10, 3, 0, 0, 0, bart.Pacific))
+	c.Check(end.Format(time.RFC3339), Equals, "2019-03-10T03:00:00-07:00")
+}
+
+func (t *TestSuite) TestSetScheduleCache(c *C) {
+	ctx := context.Background()
+
+	now := time.Date(2019, 1, 1, 10, 0, 0, 0, bart.Pacific)
+	t.c.SetClock(func() time.Time { return now })
+	t.c.SetScheduleCache(true)
+
+	get := func() {
+		r, err := t.c.GetStationSchedule(ctx, "MCAR", time.Time{})
+		c.Assert(err, IsNil)
+		c.Check(r.Station.Abbr, Equals, "MCAR")
+	}
+
+	get()
+	get()
+	c.Check(t.srv.count("stnsched"), Equals, 1)
+
+	// the fixture is for 01/01/2019, so it's cached until 3am the next day
+	now = time.Date(2019, 1, 2, 2, 59, 0, 0, bart.Pacific)
+	get()
+	c.Check(t.srv.count("stnsched"), Equals, 1)
+
+	now = time.Date(2019, 1, 2, 3, 0, 0, 0, bart.Pacific)
+	get()
+	c.Check(t.srv.count("stnsched"), Equals, 2)
+
+	// responses for a service day that has ended aren't cached
+	get()
+	c.Check(t.srv.count("stnsched"), Equals, 3)
+
+	// other stations are cached separately
+	_, err := t.c.GetStationSchedule(ctx, "POWL", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(t.srv.count("stnsched"), Equals, 4)
+
+	t.c.SetScheduleCache(false)
+
+	now = time.Date(2019, 1, 1, 10, 0, 0, 0, bart.Pacific)
+	get()
+	get()
+	c.Check(t.srv.count("stnsched"), Equals, 6)
+}
+
+func (t *TestSuite) TestSetScheduleCacheErrors(c *C) {
+	ctx := context.Background()
+
+	now := time.Date(2019, 1, 1, 10, 0, 0, 0, bart.Pacific)
+	t.c.SetClock(func() time.Time { return now })
+	t.c.SetScheduleCache(true)
+
+	fixtures := t.srv.Config.Handler
+
+	var hits int
+
+	// status is the status of the next response; zero serves the fixture
+	var status int
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") != "stnsched" {
+			fixtures.ServeHTTP(rw, req)
+			return
+		}
+
+		hits++
+
+		if status == 0 {
+			fixtures.ServeHTTP(rw, req)
+			return
+		}
+
+		rw.WriteHeader(status)
+		rw.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><root><message><error><text>Internal</text></error></message></root>`))
+	})
+
+	for _, status = range []int{http.StatusInternalServerError, http.StatusOK} {
+		_, err := t.c.GetStationSchedule(ctx, "MCAR", time.Time{})
+		c.Check(err, ErrorMatches, "bartapi: Internal", Commentf("%d", status))
+	}
+
+	status = 0
+
+	r, err := t.c.GetStationSchedule(ctx, "MCAR", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(r.Station.Abbr, Equals, "MCAR")
+	c.Check(hits, Equals, 3)
+
+	// the good response is cached
+	_, err = t.c.GetStationSchedule(ctx, "MCAR", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(hits, Equals, 3)
+}
@@ -0,0 +1,499 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// ScheduleDataStart is the earliest date the API has schedule data for.
+// Dates before it are rejected with ErrDateOutOfRange.
+var ScheduleDataStart = time.Date(2009, time.January, 1, 0, 0, 0, 0, Pacific)
+
+// ErrDateOutOfRange is returned by the schedule methods when the date
+// requested is outside of the range the API has schedules for.
+var ErrDateOutOfRange = errors.New("bart: date is outside of the published schedules")
+
+// ErrNoScheduleData is returned by the schedule methods when BART responds
+// without any trains, which it does for dates it has no timetable for
+// rather than returning an error. The response is returned along with it,
+// for callers that expect some of their requests to have no trains, like
+// for a route that doesn't run on weekends.
+var ErrNoScheduleData = errors.New("bart: no schedule data")
+
+// ErrUnknownSchedule is returned by LookupSchedule when BART hasn't
+// published a schedule with the number.
+var ErrUnknownSchedule = errors.New("bart: unknown schedule")
+
+// Schedule is one of the schedules published by BART.
+type Schedule struct {
+	ID            int    `xml:"id,attr"`
+	EffectiveDate string `xml:"effectivedate,attr"`
+}
+
+// Effective returns the parsed EffectiveDate.
+func (s Schedule) Effective() (time.Time, error) {
+	return parseDateTime(s.EffectiveDate)
+}
+
+// ScheduleListResponse is the response of the scheds command.
+type ScheduleListResponse struct {
+	bartapi.Envelope
+	Meta
+	Schedules []Schedule `xml:"schedules>schedule"`
+}
+
+// ScheduledTrain is a single train in a station's schedule.
+type ScheduledTrain struct {
+	Line        string `xml:"line,attr"`
+	HeadStation string `xml:"trainHeadStation,attr"`
+	OrigTime    string `xml:"origTime,attr"`
+	DestTime    string `xml:"destTime,attr"`
+	TrainIdx    int    `xml:"trainIdx,attr"`
+	BikeFlag    bool   `xml:"bikeflag,attr"`
+	Load        int    `xml:"load,attr"`
+
+	// Departs is the parsed OrigTime on the date of the schedule, in the
+	// client's display location. It's zero if the time or the date of
+	// the schedule is invalid.
+	Departs time.Time `xml:"-"`
+
+	// Extra is the train's attributes that don't have a field, when the
+	// WithExtraAttrs option is used.
+	Extra map[string]string `xml:"-"`
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+func (t *ScheduledTrain) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type scheduledTrain ScheduledTrain
+
+	v := struct {
+		*scheduledTrain
+		BikeFlag xmlBool `xml:"bikeflag,attr"`
+	}{scheduledTrain: (*scheduledTrain)(t)}
+
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+
+	t.BikeFlag = bool(v.BikeFlag)
+
+	return nil
+}
+
+// The types of schedule BART runs on a given day.
+const (
+	WeekdaySchedule  = "Weekday"
+	SaturdaySchedule = "Saturday"
+	SundaySchedule   = "Sunday"
+)
+
+// Holiday is a holiday on which BART runs a different schedule.
+type Holiday struct {
+	Name         string `xml:"name"`
+	Date         string `xml:"date"`
+	ScheduleType string `xml:"schedule_type"`
+}
+
+// HolidaysResponse is the response of the holiday command.
+type HolidaysResponse struct {
+	bartapi.Envelope
+	Meta
+	Holidays []Holiday `xml:"holidays>holiday"`
+}
+
+// StationScheduleResponse is the response of the stnsched command.
+//
+// SpecialSchedule is set when the schedule is not the regular one for the
+// day of the week, like on a holiday, and ScheduleType is the schedule that
+// is in effect instead (e.g., "Sunday"). If the holidays have been fetched
+// using GetHolidays they are also used to set these fields, in case BART
+// doesn't indicate the special schedule in the response itself.
+type StationScheduleResponse struct {
+	bartapi.Envelope
+	Meta
+	Date            string `xml:"date"`
+	SchedNum        int    `xml:"sched_num"`
+	SpecialSchedule bool   `xml:"special_schedule"`
+	ScheduleType    string `xml:"schedule_type"`
+	Station         struct {
+		Name   string           `xml:"name"`
+		Abbr   string           `xml:"abbr"`
+		Trains []ScheduledTrain `xml:"item"`
+	} `xml:"station"`
+
+	// EffectiveDate is the Date parsed as the start of the day in
+	// Pacific time, or the zero value if it's invalid.
+	EffectiveDate time.Time `xml:"-"`
+}
+
+// Schedule returns the schedule with the ID, like the SchedNum of a
+// response, and whether it was found.
+func (r *ScheduleListResponse) Schedule(id int) (Schedule, bool) {
+	for _, s := range r.Schedules {
+		if s.ID == id {
+			return s, true
+		}
+	}
+
+	return Schedule{}, false
+}
+
+// GetScheduleList returns the schedules BART has published. The result
+// is cached by the client, and is used to validate the dates passed to
+// the other schedule methods.
+func (c *Client) GetScheduleList(ctx context.Context, opts ...Option) (*ScheduleListResponse, error) {
+	r := &ScheduleListResponse{}
+
+	if err := c.get(ctx, c.schedule, "scheds", nil, r, opts...); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.schedules = r
+	c.mu.Unlock()
+
+	return r, nil
+}
+
+// LookupSchedule returns the schedule with the number num, such as the
+// SchedNum of a schedule, route, trip, or fare response, which includes
+// the date it took effect. The schedule list is fetched using
+// GetScheduleList if it hasn't been already, or if it doesn't include
+// the schedule, as it may have been published since the list was cached.
+// ErrUnknownSchedule is returned if BART hasn't published the schedule.
+func (c *Client) LookupSchedule(ctx context.Context, num int) (Schedule, error) {
+	c.mu.Lock()
+	cached := c.schedules
+	c.mu.Unlock()
+
+	if cached != nil {
+		if s, ok := cached.Schedule(num); ok {
+			return s, nil
+		}
+	}
+
+	r, err := c.GetScheduleList(ctx)
+
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	if s, ok := r.Schedule(num); ok {
+		return s, nil
+	}
+
+	return Schedule{}, fmt.Errorf("%w: %d", ErrUnknownSchedule, num)
+}
+
+// GetHolidays returns the holidays on which BART runs a special
+// schedule. The result is cached by the client, and used to flag
+// special schedules in the responses of the other schedule methods.
+func (c *Client) GetHolidays(ctx context.Context, opts ...Option) (*HolidaysResponse, error) {
+	r := &HolidaysResponse{}
+
+	if err := c.get(ctx, c.schedule, "holiday", nil, r, opts...); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.holidays = r
+	c.mu.Unlock()
+
+	return r, nil
+}
+
+// holiday returns the cached holiday on the date, a string in the
+// API's date format. Nil is returned if it's not a holiday, or the
+// holidays haven't been fetched.
+func (c *Client) holiday(date string) *Holiday {
+	c.mu.Lock()
+	holidays := c.holidays
+	c.mu.Unlock()
+
+	if holidays == nil {
+		return nil
+	}
+
+	for i, h := range holidays.Holidays {
+		if strings.TrimSpace(h.Date) == strings.TrimSpace(date) {
+			return &holidays.Holidays[i]
+		}
+	}
+
+	return nil
+}
+
+// ScheduleType returns the type of schedule BART runs on the date of t in
+// Pacific time: WeekdaySchedule, SaturdaySchedule, or SundaySchedule. On
+// holidays it's the schedule type of the holiday, otherwise it's based on
+// the day of the week. If t is the zero value, the current time is used.
+// The holidays are fetched using GetHolidays if they haven't been already.
+func (c *Client) ScheduleType(ctx context.Context, t time.Time) (string, error) {
+	if t.IsZero() {
+		t = c.now()
+	}
+
+	c.mu.Lock()
+	cached := c.holidays != nil
+	c.mu.Unlock()
+
+	if !cached {
+		if _, err := c.GetHolidays(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	if h := c.holiday(formatDate(t)); h != nil && h.ScheduleType != "" {
+		return h.ScheduleType, nil
+	}
+
+	switch t.In(Pacific).Weekday() {
+	case time.Saturday:
+		return SaturdaySchedule, nil
+	case time.Sunday:
+		return SundaySchedule, nil
+	default:
+		return WeekdaySchedule, nil
+	}
+}
+
+// IsWeekendSchedule returns whether BART runs a weekend schedule on the
+// date of t in Pacific time. This is the case on Saturdays and Sundays,
+// as well as holidays like New Year's Day. If t is the zero value, the
+// current time is used. Use ScheduleType to get the schedule in effect.
+func (c *Client) IsWeekendSchedule(ctx context.Context, t time.Time) (bool, error) {
+	st, err := c.ScheduleType(ctx, t)
+
+	if err != nil {
+		return false, err
+	}
+
+	return st != WeekdaySchedule, nil
+}
+
+// GetStationSchedule returns the schedule for the station with the
+// abbreviation abbr on the given date. If date is the zero value,
+// today's schedule is returned. If it doesn't have any trains the response
+// is returned along with ErrNoScheduleData.
+func (c *Client) GetStationSchedule(ctx context.Context, abbr string, date time.Time, opts ...Option) (*StationScheduleResponse, error) {
+	o := newOptions(opts)
+	abbr, err := c.stationAbbr(o, abbr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := o.query(map[string]string{"orig": abbr})
+
+	if !date.IsZero() {
+		if err := c.checkDate(date); err != nil {
+			return nil, err
+		}
+
+		query["date"] = formatDate(date)
+	}
+
+	r := &StationScheduleResponse{}
+
+	if err := c.get(ctx, c.schedule, "stnsched", query, r, opts...); err != nil {
+		return nil, err
+	}
+
+	r.EffectiveDate = parseServiceDate(r.Date)
+
+	if !r.EffectiveDate.IsZero() {
+		loc := c.displayLocation()
+
+		for i, t := range r.departures(r.EffectiveDate) {
+			if !t.IsZero() {
+				r.Station.Trains[i].Departs = t.In(loc)
+			}
+		}
+	}
+
+	if h := c.holiday(r.Date); h != nil {
+		r.SpecialSchedule = true
+
+		if r.ScheduleType == "" {
+			r.ScheduleType = h.ScheduleType
+		}
+	}
+
+	if len(r.Station.Trains) == 0 {
+		return r, noScheduleData("stnsched", r.Date)
+	}
+
+	return r, nil
+}
+
+// noScheduleData returns an ErrNoScheduleData for the response of cmd.
+func noScheduleData(cmd, date string) error {
+	if date == "" {
+		return fmt.Errorf("%w: %s response has no trains", ErrNoScheduleData, cmd)
+	}
+
+	return fmt.Errorf("%w: %s response for %s has no trains", ErrNoScheduleData, cmd, date)
+}
+
+// checkDate returns ErrDateOutOfRange if there's no schedule data for
+// the date. If the schedule list has been cached, the earliest effective
+// date is used as the start of the range. Otherwise ScheduleDataStart is.
+func (c *Client) checkDate(date time.Time) error {
+	start := ScheduleDataStart
+
+	c.mu.Lock()
+	schedules := c.schedules
+	c.mu.Unlock()
+
+	if schedules != nil {
+		var found bool
+
+		for _, s := range schedules.Schedules {
+			t, err := s.Effective()
+
+			if err != nil {
+				continue
+			}
+
+			if !found || t.Before(start) {
+				start, found = t, true
+			}
+		}
+	}
+
+	// the effective dates include a time, but schedules apply to
+	// the whole service day so only compare the dates
+	y, m, d := start.In(Pacific).Date()
+
+	if date.In(Pacific).Before(time.Date(y, m, d, 0, 0, 0, 0, Pacific)) {
+		return ErrDateOutOfRange
+	}
+
+	return nil
+}
+
+// IsStale returns whether the schedule is stale at now, which is the case
+// once now is more than 27 hours, or the age set using the client's
+// SetStaleScheduleAge, after the start of its date. It's also stale if its
+// date is invalid.
+func (r *StationScheduleResponse) IsStale(now time.Time) bool {
+	return isStale(r.EffectiveDate, now, r.staleAge)
+}
+
+// After returns the trains in the schedule that depart the station after t,
+// in the order they depart. The departure times are on the schedule's
+// EffectiveDate, or the day of t in Pacific time if the response doesn't
+// have a valid date. Trains listed after midnight, at the end of the
+// schedule, depart the next day.
+func (r *StationScheduleResponse) After(t time.Time) []ScheduledTrain {
+	var trains []ScheduledTrain
+
+	departs := r.departures(t)
+
+	for i, train := range r.Station.Trains {
+		if departs[i].After(t) {
+			trains = append(trains, train)
+		}
+	}
+
+	return trains
+}
+
+// NextN returns the first n trains that depart the station after t. Fewer
+// are returned if the schedule doesn't have n trains after t. See After.
+func (r *StationScheduleResponse) NextN(t time.Time, n int) []ScheduledTrain {
+	trains := r.After(t)
+
+	if n < 0 {
+		n = 0
+	}
+
+	if len(trains) > n {
+		trains = trains[:n:n]
+	}
+
+	return trains
+}
+
+// departures returns the time each of the trains departs the station. t is
+// used for the date if the schedule doesn't have one. Trains with invalid
+// times have the zero time, so they never depart after t.
+func (r *StationScheduleResponse) departures(t time.Time) []time.Time {
+	date := r.EffectiveDate
+
+	if date.IsZero() {
+		y, m, d := t.In(Pacific).Date()
+		date = time.Date(y, m, d, 0, 0, 0, 0, Pacific)
+	}
+
+	departs := make([]time.Time, len(r.Station.Trains))
+
+	var prev time.Time
+
+	for i, train := range r.Station.Trains {
+		clock, err := time.Parse(clockFormat, strings.TrimSpace(train.OrigTime))
+
+		if err != nil {
+			continue
+		}
+
+		d := time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, Pacific)
+
+		// the schedule is in order, so a time earlier than the one
+		// before it is after midnight
+		for d.Before(prev) {
+			d = d.AddDate(0, 0, 1)
+		}
+
+		departs[i], prev = d, d
+	}
+
+	return departs
+}
+
+// ScheduleChangedBetween returns whether the schedule of the station with
+// the abbreviation abbr differs between the dates a and b, for warning
+// riders about an upcoming service change. The schedules differ when a
+// train is in one and not the other, going by its line, destination, and
+// departure time. The order of the trains, and details like their load,
+// are ignored. A date without any trains differs from one with trains.
+func (c *Client) ScheduleChangedBetween(ctx context.Context, abbr string, a, b time.Time, opts ...Option) (bool, error) {
+	var trains [2][]string
+
+	for i, date := range []time.Time{a, b} {
+		r, err := c.GetStationSchedule(ctx, abbr, date, opts...)
+
+		if err != nil && !errors.Is(err, ErrNoScheduleData) {
+			return false, err
+		}
+
+		for _, t := range r.Station.Trains {
+			trains[i] = append(trains[i], strings.ToUpper(t.Line)+"|"+strings.ToUpper(t.HeadStation)+"|"+strings.TrimSpace(t.OrigTime))
+		}
+
+		sort.Strings(trains[i])
+	}
+
+	if len(trains[0]) != len(trains[1]) {
+		return true, nil
+	}
+
+	for i := range trains[0] {
+		if trains[0][i] != trains[1][i] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
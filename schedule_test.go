@@ -0,0 +1,388 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestGetScheduleList(c *C) {
+	r, err := t.c.GetScheduleList(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(r.Schedules, HasLen, 2)
+	c.Check(r.Schedules[0].ID, Equals, 46)
+	c.Check(r.Schedules[0].EffectiveDate, Equals, "01/14/2019 12:00 AM")
+
+	eff, err := r.Schedules[1].Effective()
+	c.Assert(err, IsNil)
+	c.Check(eff.Equal(time.Date(2019, time.February, 11, 0, 0, 0, 0, bart.Pacific)), Equals, true)
+}
+
+func (t *TestSuite) TestGetStationSchedule(c *C) {
+	r, err := t.c.GetStationSchedule(context.Background(), "12TH", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(r.Date, Equals, "02/04/2019")
+	c.Check(r.SchedNum, Equals, 46)
+	c.Check(r.Station.Abbr, Equals, "12TH")
+	c.Assert(r.Station.Trains, HasLen, 4)
+	c.Check(r.Station.Trains[3], DeepEquals, bart.ScheduledTrain{
+		Line:        "ROUTE 8",
+		HeadStation: "RICH",
+		OrigTime:    "4:55 AM",
+		DestTime:    "5:09 AM",
+		TrainIdx:    4,
+		BikeFlag:    false,
+		Load:        2,
+		Departs:     time.Date(2019, 2, 4, 4, 55, 0, 0, bart.Pacific),
+	})
+}
+
+func (t *TestSuite) TestGetStationScheduleDateOutOfRange(c *C) {
+	ctx := context.Background()
+
+	// before the known start of the data
+	_, err := t.c.GetStationSchedule(ctx, "12TH", time.Date(2001, time.January, 1, 0, 0, 0, 0, bart.Pacific))
+	c.Check(err, Equals, bart.ErrDateOutOfRange)
+
+	_, err = t.c.GetStationSchedule(ctx, "12TH", time.Date(2019, time.January, 1, 12, 0, 0, 0, bart.Pacific))
+	c.Check(err, IsNil)
+
+	// once the schedule list is cached its range is used instead
+	_, err = t.c.GetScheduleList(ctx)
+	c.Assert(err, IsNil)
+
+	_, err = t.c.GetStationSchedule(ctx, "12TH", time.Date(2019, time.January, 1, 12, 0, 0, 0, bart.Pacific))
+	c.Check(err, Equals, bart.ErrDateOutOfRange)
+
+	_, err = t.c.GetStationSchedule(ctx, "12TH", time.Date(2019, time.January, 14, 0, 0, 0, 0, bart.Pacific))
+	c.Check(err, IsNil)
+
+	c.Check(t.srv.count("stnsched"), Equals, 2)
+}
+
+func (t *TestSuite) TestGetHolidays(c *C) {
+	r, err := t.c.GetHolidays(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(r.Holidays, HasLen, 3)
+	c.Check(r.Holidays[0], DeepEquals, bart.Holiday{Name: "New Year's Day", Date: "01/01/2019", ScheduleType: "Sunday"})
+}
+
+func (t *TestSuite) TestScheduleType(c *C) {
+	ctx := context.Background()
+
+	tests := []struct {
+		date    time.Time
+		typ     string
+		weekend bool
+	}{
+		{time.Date(2019, 1, 2, 12, 0, 0, 0, bart.Pacific), bart.WeekdaySchedule, false},
+		{time.Date(2019, 1, 5, 12, 0, 0, 0, bart.Pacific), bart.SaturdaySchedule, true},
+		{time.Date(2019, 1, 6, 12, 0, 0, 0, bart.Pacific), bart.SundaySchedule, true},
+
+		// New Year's Day, a Tuesday
+		{time.Date(2019, 1, 1, 0, 30, 0, 0, bart.Pacific), bart.SundaySchedule, true},
+
+		// Presidents' Day, a Monday
+		{time.Date(2019, 2, 18, 8, 0, 0, 0, bart.Pacific), bart.SaturdaySchedule, true},
+
+		// Monday in UTC, but still Sunday in Pacific time
+		{time.Date(2019, 1, 7, 5, 0, 0, 0, time.UTC), bart.SundaySchedule, true},
+	}
+
+	for _, tt := range tests {
+		typ, err := t.c.ScheduleType(ctx, tt.date)
+		c.Assert(err, IsNil)
+		c.Check(typ, Equals, tt.typ, Commentf("%s", tt.date))
+
+		weekend, err := t.c.IsWeekendSchedule(ctx, tt.date)
+		c.Assert(err, IsNil)
+		c.Check(weekend, Equals, tt.weekend, Commentf("%s", tt.date))
+	}
+
+	// the holidays are only fetched once
+	c.Check(t.srv.count("holiday"), Equals, 1)
+}
+
+func (t *TestSuite) TestSetClock(c *C) {
+	ctx := context.Background()
+
+	tests := []struct {
+		now time.Time
+		typ string
+	}{
+		// New Year's Day
+		{time.Date(2019, 1, 1, 20, 0, 0, 0, time.UTC), bart.SundaySchedule},
+
+		// the start of DST: 1:59 AM PST and 3:00 AM PDT on a Sunday
+		{time.Date(2019, 3, 10, 9, 59, 0, 0, time.UTC), bart.SundaySchedule},
+		{time.Date(2019, 3, 10, 10, 0, 0, 0, time.UTC), bart.SundaySchedule},
+
+		// the end of DST: still Sunday in Pacific time, Monday in UTC
+		{time.Date(2019, 11, 4, 7, 59, 0, 0, time.UTC), bart.SundaySchedule},
+		{time.Date(2019, 11, 4, 8, 0, 0, 0, time.UTC), bart.WeekdaySchedule},
+	}
+
+	for _, tt := range tests {
+		now := tt.now
+		t.c.SetClock(func() time.Time { return now })
+
+		typ, err := t.c.ScheduleType(ctx, time.Time{})
+		c.Assert(err, IsNil)
+		c.Check(typ, Equals, tt.typ, Commentf("%s", tt.now))
+	}
+
+	t.c.SetClock(nil)
+
+	_, err := t.c.ScheduleType(ctx, time.Time{})
+	c.Check(err, IsNil)
+}
+
+func (t *TestSuite) TestSpecialSchedule(c *C) {
+	ctx := context.Background()
+
+	// flagged by BART in the response
+	r, err := t.c.GetStationSchedule(ctx, "POWL", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(r.SpecialSchedule, Equals, true)
+	c.Check(r.ScheduleType, Equals, "Sunday")
+
+	r, err = t.c.GetStationSchedule(ctx, "12TH", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(r.SpecialSchedule, Equals, false)
+	c.Check(r.ScheduleType, Equals, "")
+
+	// not flagged, and the holidays haven't been fetched
+	r, err = t.c.GetStationSchedule(ctx, "MCAR", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(r.SpecialSchedule, Equals, false)
+
+	_, err = t.c.GetHolidays(ctx)
+	c.Assert(err, IsNil)
+
+	r, err = t.c.GetStationSchedule(ctx, "MCAR", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(r.SpecialSchedule, Equals, true)
+	c.Check(r.ScheduleType, Equals, "Sunday")
+
+	r, err = t.c.GetStationSchedule(ctx, "12TH", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(r.SpecialSchedule, Equals, false)
+}
+
+func (t *TestSuite) TestLookupSchedule(c *C) {
+	s, err := t.c.LookupSchedule(context.Background(), 47)
+	c.Assert(err, IsNil)
+	c.Check(s.EffectiveDate, Equals, "02/11/2019 12:00 AM")
+
+	// the list is cached
+	_, err = t.c.LookupSchedule(context.Background(), 46)
+	c.Assert(err, IsNil)
+	c.Check(t.srv.count("scheds"), Equals, 1)
+
+	_, err = t.c.LookupSchedule(context.Background(), 42)
+	c.Check(errors.Is(err, bart.ErrUnknownSchedule), Equals, true)
+	c.Check(err, ErrorMatches, "bart: unknown schedule: 42")
+}
+
+func (t *TestSuite) TestIsStale(c *C) {
+	r, err := t.c.GetStationSchedule(context.Background(), "MCAR", time.Date(2019, 1, 1, 0, 0, 0, 0, bart.Pacific))
+	c.Assert(err, IsNil)
+	c.Check(r.EffectiveDate, DeepEquals, time.Date(2019, 1, 1, 0, 0, 0, 0, bart.Pacific))
+
+	// the service day runs past midnight
+	c.Check(r.IsStale(time.Date(2019, 1, 1, 23, 0, 0, 0, bart.Pacific)), Equals, false)
+	c.Check(r.IsStale(time.Date(2019, 1, 2, 2, 30, 0, 0, bart.Pacific)), Equals, false)
+	c.Check(r.IsStale(time.Date(2019, 1, 2, 3, 30, 0, 0, bart.Pacific)), Equals, true)
+
+	// the age is set per client, and applies to its later responses
+	t.c.SetStaleScheduleAge(time.Hour)
+	c.Check(r.IsStale(time.Date(2019, 1, 1, 2, 0, 0, 0, bart.Pacific)), Equals, false)
+
+	r, err = t.c.GetStationSchedule(context.Background(), "MCAR", time.Date(2019, 1, 1, 0, 0, 0, 0, bart.Pacific))
+	c.Assert(err, IsNil)
+	c.Check(r.IsStale(time.Date(2019, 1, 1, 2, 0, 0, 0, bart.Pacific)), Equals, true)
+
+	t.c.SetStaleScheduleAge(0)
+
+	trips, err := t.c.GetDepartures(context.Background(), "ASHB", "CIVC", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(trips.EffectiveDate, DeepEquals, time.Date(2019, 2, 4, 0, 0, 0, 0, bart.Pacific))
+	c.Check(trips.IsStale(time.Date(2019, 2, 4, 0, 30, 0, 0, bart.Pacific)), Equals, false)
+
+	rs, err := t.c.GetRouteSchedule(context.Background(), 6, time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(rs.EffectiveDate, DeepEquals, time.Date(2019, 2, 11, 0, 0, 0, 0, bart.Pacific))
+
+	c.Check((&bart.RouteScheduleResponse{}).IsStale(time.Now()), Equals, true)
+}
+
+func (t *TestSuite) TestStationScheduleAfter(c *C) {
+	r, err := t.c.GetStationSchedule(context.Background(), "12TH", time.Time{})
+	c.Assert(err, IsNil)
+
+	idxs := func(trains []bart.ScheduledTrain) []int {
+		var s []int
+
+		for _, train := range trains {
+			s = append(s, train.TrainIdx)
+		}
+
+		return s
+	}
+
+	at := time.Date(2019, 2, 4, 4, 41, 0, 0, bart.Pacific)
+
+	c.Check(idxs(r.After(at)), DeepEquals, []int{3, 4})
+	c.Check(idxs(r.After(at.Add(-time.Second))), DeepEquals, []int{2, 3, 4})
+	c.Check(idxs(r.NextN(at, 1)), DeepEquals, []int{3})
+	c.Check(idxs(r.NextN(at, 5)), DeepEquals, []int{3, 4})
+	c.Check(r.After(at.AddDate(0, 0, 1)), HasLen, 0)
+
+	// the times are in Pacific regardless of the zone of t
+	c.Check(idxs(r.After(at.UTC())), DeepEquals, []int{3, 4})
+}
+
+func (t *TestSuite) TestStationScheduleAfterMidnight(c *C) {
+	r := &bart.StationScheduleResponse{}
+	r.Station.Trains = []bart.ScheduledTrain{
+		{OrigTime: "11:40 PM", TrainIdx: 1},
+		{OrigTime: "11:58 PM", TrainIdx: 2},
+		{OrigTime: "12:16 AM", TrainIdx: 3},
+		{OrigTime: "1:02 AM", TrainIdx: 4},
+	}
+
+	// without a date, the day of t is used
+	trains := r.After(time.Date(2019, 2, 4, 23, 50, 0, 0, bart.Pacific))
+	c.Assert(trains, HasLen, 3)
+	c.Check(trains[1].TrainIdx, Equals, 3)
+
+	// the early morning trains are on the next day
+	trains = r.After(time.Date(2019, 2, 4, 12, 30, 0, 0, bart.Pacific))
+	c.Check(trains, HasLen, 4)
+}
+
+func (t *TestSuite) TestErrNoScheduleData(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.FormValue("cmd") {
+		case "stnsched":
+			rw.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<root><date>12/25/2030</date><sched_num>99</sched_num><station><name>12th St. Oakland City Center</name><abbr>12TH</abbr></station></root>`))
+		case "routesched":
+			rw.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<root><date>12/25/2030</date><sched_num>99</sched_num><route></route></root>`))
+		case "depart":
+			rw.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<root><origin>12TH</origin><destination>MCAR</destination><schedule><date>Dec 25, 2030</date><request></request></schedule></root>`))
+		default:
+			fixtures.ServeHTTP(rw, req)
+		}
+	})
+
+	ctx := context.Background()
+	date := time.Date(2030, 12, 25, 0, 0, 0, 0, bart.Pacific)
+
+	s, err := t.c.GetStationSchedule(ctx, "12TH", date)
+	c.Check(errors.Is(err, bart.ErrNoScheduleData), Equals, true)
+	c.Check(err, ErrorMatches, "bart: no schedule data: stnsched response for 12/25/2030 has no trains")
+	c.Assert(s, NotNil)
+	c.Check(s.SchedNum, Equals, 99)
+
+	r, err := t.c.GetRouteSchedule(ctx, 6, date)
+	c.Check(errors.Is(err, bart.ErrNoScheduleData), Equals, true)
+	c.Check(r, NotNil)
+
+	d, err := t.c.GetDepartures(ctx, "12TH", "MCAR", date)
+	c.Check(errors.Is(err, bart.ErrNoScheduleData), Equals, true)
+	c.Check(err, ErrorMatches, "bart: no schedule data: depart response for Dec 25, 2030 has no trains")
+	c.Check(d, NotNil)
+}
+
+func (t *TestSuite) TestScheduleChangedBetween(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	items := map[string]string{
+		// the same trains, in a different order and with other loads
+		"02/05/2019": `<item line="ROUTE 8" trainHeadStation="RICH" origTime="4:55 AM" trainIdx="1" load="1" />
+<item line="ROUTE 7" trainHeadStation="MLBR" origTime="4:51 AM" trainIdx="2" load="1" />
+<item line="ROUTE 2" trainHeadStation="PITT" origTime="4:41 AM" trainIdx="3" load="3" />
+<item line="ROUTE 7" trainHeadStation="MLBR" origTime="4:36 AM" trainIdx="4" load="1" />`,
+
+		// the Richmond train leaves later
+		"02/06/2019": `<item line="ROUTE 7" trainHeadStation="MLBR" origTime="4:36 AM" trainIdx="1" />
+<item line="ROUTE 2" trainHeadStation="PITT" origTime="4:41 AM" trainIdx="2" />
+<item line="ROUTE 7" trainHeadStation="MLBR" origTime="4:51 AM" trainIdx="3" />
+<item line="ROUTE 8" trainHeadStation="RICH" origTime="4:58 AM" trainIdx="4" />`,
+
+		"02/07/2019": ``,
+	}
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		date := req.FormValue("date")
+
+		if req.FormValue("cmd") != "stnsched" || date == "02/04/2019" {
+			fixtures.ServeHTTP(rw, req)
+			return
+		}
+
+		rw.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<root><date>` + date + `</date><station><abbr>12TH</abbr>` + items[date] + `</station></root>`))
+	})
+
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2019, 2, d, 12, 0, 0, 0, bart.Pacific) }
+
+	changed, err := t.c.ScheduleChangedBetween(ctx, "12TH", day(4), day(5))
+	c.Assert(err, IsNil)
+	c.Check(changed, Equals, false)
+
+	changed, err = t.c.ScheduleChangedBetween(ctx, "12TH", day(4), day(6))
+	c.Assert(err, IsNil)
+	c.Check(changed, Equals, true)
+
+	changed, err = t.c.ScheduleChangedBetween(ctx, "12TH", day(7), day(4))
+	c.Assert(err, IsNil)
+	c.Check(changed, Equals, true)
+
+	_, err = t.c.ScheduleChangedBetween(ctx, "12TH", time.Date(2001, 1, 1, 0, 0, 0, 0, bart.Pacific), day(4))
+	c.Check(errors.Is(err, bart.ErrDateOutOfRange), Equals, true)
+}
+
+func (t *TestSuite) TestHeadways(c *C) {
+	r, err := t.c.GetStationSchedule(context.Background(), "12TH", time.Time{})
+	c.Assert(err, IsNil)
+
+	c.Check(r.Station.Trains[0].Direction(), Equals, bart.South)
+	c.Check(r.Station.Trains[1].Direction(), Equals, bart.North)
+
+	c.Check(r.Headways(), DeepEquals, map[bart.Direction][]time.Duration{
+		bart.South: {15 * time.Minute},
+		bart.North: {14 * time.Minute},
+	})
+
+	// a single train in a direction has no headway
+	r.Station.Trains = r.Station.Trains[:2]
+	c.Check(r.Headways(), HasLen, 0)
+
+	r.Station.Trains = nil
+	c.Check(r.Headways(), HasLen, 0)
+
+	// trains after midnight follow the ones before it
+	r.Station.Trains = []bart.ScheduledTrain{
+		{Line: "ROUTE 7", OrigTime: "11:50 PM"},
+		{Line: "ROUTE 7", OrigTime: "12:10 AM"},
+		{Line: "ROUTE 99", OrigTime: "12:20 AM"},
+	}
+	c.Check(r.Headways(), DeepEquals, map[bart.Direction][]time.Duration{
+		bart.South: {20 * time.Minute},
+	})
+}
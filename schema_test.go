@@ -0,0 +1,87 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestSetStrictSchema(c *C) {
+	var mu sync.Mutex
+	var warnings []error
+
+	t.c.SetHooks(bartapi.Hooks{Warning: func(ctx context.Context, err error) {
+		mu.Lock()
+		warnings = append(warnings, err)
+		mu.Unlock()
+	}})
+
+	ctx := context.Background()
+
+	_, err := t.c.GetStations(ctx)
+	c.Assert(err, IsNil)
+
+	// disabled by default
+	c.Check(warnings, HasLen, 0)
+
+	t.c.SetStrictSchema(true)
+
+	_, err = t.c.GetStationInfo(ctx, "MCAR")
+	c.Assert(err, IsNil)
+	_, err = t.c.GetStationAccess(ctx, "MCAR")
+	c.Assert(err, IsNil)
+	_, err = t.c.GetEstimates(ctx, "MCAR")
+	c.Assert(err, IsNil)
+	_, err = t.c.GetAdvisories(ctx)
+	c.Assert(err, IsNil)
+	_, err = t.c.GetHolidays(ctx)
+	c.Assert(err, IsNil)
+	_, err = t.c.GetRoutes(ctx)
+	c.Assert(err, IsNil)
+	_, err = t.c.GetStationSchedule(ctx, "12TH", time.Time{})
+	c.Assert(err, IsNil)
+
+	// all of the elements in the fixtures are decoded
+	c.Check(warnings, HasLen, 0)
+}
+
+func (t *TestSuite) TestSetStrictSchemaUnknown(c *C) {
+	var warnings []error
+
+	t.c.SetHooks(bartapi.Hooks{Warning: func(ctx context.Context, err error) {
+		warnings = append(warnings, err)
+	}})
+	t.c.SetStrictSchema(true)
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<root>
+  <schedules>
+    <schedule id="46" effectivedate="01/14/2019 12:00 AM" type="weekday"><note>hi</note></schedule>
+    <schedule id="47" effectivedate="02/11/2019 12:00 AM" type="weekday"><note>hi</note></schedule>
+  </schedules>
+  <message></message>
+</root>`))
+	})
+
+	r, err := t.c.GetScheduleList(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(r.Schedules, HasLen, 2)
+
+	c.Assert(warnings, HasLen, 2)
+	c.Check(warnings[0], ErrorMatches, `bart: scheds response: bartapi: unknown attribute in response: root>schedules>schedule type`)
+	c.Check(warnings[1], ErrorMatches, `bart: scheds response: bartapi: unknown element in response: root>schedules>schedule>note`)
+
+	var u *bartapi.UnknownFieldError
+	c.Assert(errors.As(warnings[1], &u), Equals, true)
+	c.Check(u.Path, Equals, "root>schedules>schedule>note")
+}
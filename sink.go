@@ -0,0 +1,54 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+)
+
+// sinkTimeFormat is the format of the timestamp in the names of the
+// files written by WithResponseSink. It sorts in chronological order.
+const sinkTimeFormat = "20060102T150405.000000000Z"
+
+// WithResponseSink sets a directory that the raw body of each response is
+// written to, for archiving the responses to process later. It's not a
+// cache, and the files are never read by the client. Each response is
+// written to a new file named after the cmd and the time it was received
+// in UTC, like "etd-20190204T224000.000000000Z-123456.xml", where the
+// suffix keeps the names of responses received at the same time unique.
+//
+// The files are written in the background, so they don't slow down the
+// request, and may not exist as soon as the method returns. Errors writing
+// them are passed to the Warning hook. The directory must already exist.
+// Responses aren't written once the client's background context is done.
+func WithResponseSink(dir string) Option {
+	return func(o *options) { o.sinkDir = dir }
+}
+
+// sink writes the response body to a new file in dir in the background.
+func (c *Client) sink(ctx context.Context, dir, cmd string, body []byte) {
+	pattern := fmt.Sprintf("%s-%s-*.xml", cmd, c.now().UTC().Format(sinkTimeFormat))
+
+	c.background(func(context.Context) {
+		f, err := ioutil.TempFile(dir, pattern)
+
+		if err != nil {
+			c.warn(ctx, fmt.Errorf("bart: writing %s response to sink: %w", cmd, err))
+			return
+		}
+
+		_, err = f.Write(body)
+
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+
+		if err != nil {
+			c.warn(ctx, fmt.Errorf("bart: writing %s response to sink: %w", cmd, err))
+		}
+	})
+}
@@ -0,0 +1,97 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/theckman/go-bart"
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestWithResponseSink(c *C) {
+	dir := c.MkDir()
+
+	t.c.SetClock(func() time.Time { return time.Date(2019, 2, 4, 14, 40, 0, 0, bart.Pacific) })
+
+	_, err := t.c.GetAdvisories(context.Background(), bart.WithResponseSink(dir))
+	c.Assert(err, IsNil)
+
+	var files []os.FileInfo
+
+	// the file is written in the background
+	for i := 0; i < 100 && len(files) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+
+		files, err = ioutil.ReadDir(dir)
+		c.Assert(err, IsNil)
+	}
+
+	c.Assert(files, HasLen, 1)
+	c.Check(strings.HasPrefix(files[0].Name(), "bsa-20190204T224000.000000000Z-"), Equals, true)
+	c.Check(strings.HasSuffix(files[0].Name(), ".xml"), Equals, true)
+
+	got, err := ioutil.ReadFile(filepath.Join(dir, files[0].Name()))
+	c.Assert(err, IsNil)
+
+	want, err := ioutil.ReadFile(filepath.Join("testdata", "bsa.xml"))
+	c.Assert(err, IsNil)
+
+	c.Check(string(got), Equals, string(want))
+}
+
+func (t *TestSuite) TestWithResponseSinkError(c *C) {
+	warnings := make(chan error, 1)
+
+	t.c.SetHooks(bartapi.Hooks{Warning: func(ctx context.Context, err error) { warnings <- err }})
+
+	_, err := t.c.GetAdvisories(context.Background(), bart.WithResponseSink(filepath.Join(c.MkDir(), "missing")))
+	c.Assert(err, IsNil)
+
+	select {
+	case err := <-warnings:
+		c.Check(err, ErrorMatches, "bart: writing bsa response to sink: .*")
+		c.Check(errors.Is(err, os.ErrNotExist), Equals, true)
+	case <-time.After(time.Second):
+		c.Fatal("no warning")
+	}
+}
+
+func (t *TestSuite) TestWithResponseSinkClose(c *C) {
+	dir := c.MkDir()
+
+	_, err := t.c.GetAdvisories(context.Background(), bart.WithResponseSink(dir))
+	c.Assert(err, IsNil)
+
+	// Close waits for the file to be written
+	c.Assert(t.c.Close(), IsNil)
+
+	files, err := ioutil.ReadDir(dir)
+	c.Assert(err, IsNil)
+	c.Check(files, HasLen, 1)
+}
+
+func (t *TestSuite) TestSetBackgroundContext(c *C) {
+	dir := c.MkDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.c.SetBackgroundContext(ctx)
+	cancel()
+
+	_, err := t.c.GetAdvisories(context.Background(), bart.WithResponseSink(dir))
+	c.Assert(err, IsNil)
+	c.Assert(t.c.Close(), IsNil)
+
+	files, err := ioutil.ReadDir(dir)
+	c.Assert(err, IsNil)
+	c.Check(files, HasLen, 0)
+}
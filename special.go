@@ -0,0 +1,109 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// SpecialSchedule is a notice of service that differs from the regular
+// schedule for a period, like extra trains after a sporting event or
+// single tracking for track work. These are separate from the holidays
+// returned by GetHolidays.
+type SpecialSchedule struct {
+	// Text is the description of the special service.
+	Text string `xml:"text"`
+
+	// Link is the URL of BART's page about it, if there is one.
+	Link string `xml:"link"`
+
+	StartDate string `xml:"start_date"`
+	EndDate   string `xml:"end_date"`
+	StartTime string `xml:"start_time"`
+	EndTime   string `xml:"end_time"`
+
+	// Start and End are the dates parsed as the start of the day in
+	// Pacific time, or the zero value if BART didn't give a valid date.
+	Start time.Time `xml:"-"`
+	End   time.Time `xml:"-"`
+
+	// Orig and Dest are the abbreviations of the stations the service
+	// is between, when it's limited to part of the system.
+	Orig string `xml:"orig"`
+	Dest string `xml:"dest"`
+
+	// DayOfWeek is the comma-separated days it applies to, where zero
+	// is Sunday.
+	DayOfWeek string `xml:"day_of_week"`
+
+	// RoutesAffected is the comma-separated routes that are affected,
+	// like "ROUTE 1, ROUTE 2".
+	RoutesAffected string `xml:"routes_affected"`
+
+	// ScheduleType is the kind of schedule, when BART includes it.
+	ScheduleType string `xml:"schedule_type"`
+}
+
+// Routes returns the routes that are affected.
+func (s SpecialSchedule) Routes() []string {
+	var routes []string
+
+	for _, r := range strings.Split(s.RoutesAffected, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			routes = append(routes, r)
+		}
+	}
+
+	return routes
+}
+
+// Covers returns whether the notice is in effect on the day of t, in
+// Pacific time. It only considers the dates, not the days of the week
+// or the times of day.
+func (s SpecialSchedule) Covers(t time.Time) bool {
+	if s.Start.IsZero() {
+		return false
+	}
+
+	t = t.In(Pacific)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, Pacific)
+
+	end := s.End
+
+	if end.IsZero() {
+		end = s.Start
+	}
+
+	return !day.Before(s.Start) && !day.After(end)
+}
+
+// SpecialSchedulesResponse is the response of the special command.
+type SpecialSchedulesResponse struct {
+	bartapi.Envelope
+	Meta
+	SpecialSchedules []SpecialSchedule `xml:"special_schedules>special_schedule"`
+}
+
+// GetSpecialSchedules returns the special schedule notices BART has
+// posted, with their dates parsed in to Start and End.
+func (c *Client) GetSpecialSchedules(ctx context.Context, opts ...Option) (*SpecialSchedulesResponse, error) {
+	r := &SpecialSchedulesResponse{}
+
+	if err := c.get(ctx, c.schedule, "special", nil, r, opts...); err != nil {
+		return nil, err
+	}
+
+	for i := range r.SpecialSchedules {
+		s := &r.SpecialSchedules[i]
+		s.Start = parseServiceDate(s.StartDate)
+		s.End = parseServiceDate(s.EndDate)
+	}
+
+	return r, nil
+}
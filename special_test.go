@@ -0,0 +1,37 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestGetSpecialSchedules(c *C) {
+	r, err := t.c.GetSpecialSchedules(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(r.SpecialSchedules, HasLen, 2)
+
+	s := r.SpecialSchedules[0]
+	c.Check(s.Text, Equals, "Extra service after the Warriors game at Oracle Arena.")
+	c.Check(s.Orig, Equals, "COLS")
+	c.Check(s.ScheduleType, Equals, "Event")
+	c.Check(s.Start, Equals, time.Date(2019, 2, 9, 0, 0, 0, 0, bart.Pacific))
+	c.Check(s.End, Equals, time.Date(2019, 2, 10, 0, 0, 0, 0, bart.Pacific))
+	c.Check(s.Routes(), DeepEquals, []string{"ROUTE 3", "ROUTE 5"})
+
+	c.Check(s.Covers(time.Date(2019, 2, 8, 23, 0, 0, 0, bart.Pacific)), Equals, false)
+	c.Check(s.Covers(time.Date(2019, 2, 10, 23, 0, 0, 0, bart.Pacific)), Equals, true)
+
+	// still the 10th in Pacific time
+	c.Check(s.Covers(time.Date(2019, 2, 11, 3, 0, 0, 0, time.UTC)), Equals, true)
+	c.Check(s.Covers(time.Date(2019, 2, 11, 9, 0, 0, 0, time.UTC)), Equals, false)
+
+	c.Check(r.SpecialSchedules[1].ScheduleType, Equals, "")
+	c.Check(r.SpecialSchedules[1].Link, Equals, "")
+}
@@ -0,0 +1,116 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// lastGood is the last successful response of a request, kept for
+// SetStaleIfError.
+type lastGood struct {
+	resp *bartapi.Response
+	cmd  string
+	at   time.Time
+}
+
+// SetStaleIfError sets whether a request that fails is answered with the
+// last successful response of the same request, so an app can keep showing
+// slightly old data while the API is down. Responses used this way have
+// Stale set in their Meta, and the error is passed to the Warning hook.
+//
+// It applies to every request, and a request fails if it returns an error,
+// a status other than 200, or an error in the response envelope. The
+// schedules and reference data, like GetStationSchedule, GetRoutes and
+// GetFare, are used regardless of their age. The real-time data, which is
+// GetEstimates, GetAdvisories, GetTrainCount and GetElevatorStatus, goes
+// out of date quickly, so it only applies to them if realtimeGrace is
+// positive, and then only to responses younger than it.
+//
+// A canceled context or a closed client aren't treated as failures. The
+// last response of each request is kept in memory while this is enabled,
+// which it isn't by default; disabling it drops them.
+func (c *Client) SetStaleIfError(enabled bool, realtimeGrace time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.staleGrace = realtimeGrace
+
+	if !enabled {
+		c.lastGood = nil
+	} else if c.lastGood == nil {
+		c.lastGood = make(map[string]lastGood)
+	}
+}
+
+// pullStale calls pullCached, falling back to the last successful response
+// of the request if it fails and SetStaleIfError allows it. It returns
+// whether the response is a stale one.
+func (c *Client) pullStale(ctx context.Context, api *bartapi.Client, cmd string, query map[string]string, pull func() (*bartapi.Response, error)) (*bartapi.Response, bool, error) {
+	resp, err := c.pullCached(api, cmd, query, pull)
+
+	c.mu.Lock()
+	enabled, grace := c.lastGood != nil, c.staleGrace
+	c.mu.Unlock()
+
+	realtime := realtimeCmds[cmd]
+
+	if !enabled || (realtime && grace <= 0) {
+		return resp, false, err
+	}
+
+	key := coalesceKey(api, cmd, query)
+	now := c.now()
+
+	c.mu.Lock()
+
+	// the fallback may have been disabled during the request
+	if c.lastGood == nil {
+		c.mu.Unlock()
+		return resp, false, err
+	}
+
+	failed := err
+
+	// PullResponse doesn't return an error for other statuses, or
+	// for errors in the envelope
+	if err == nil {
+		failed = responseErr(resp)
+	}
+
+	if failed == nil {
+		// drop the real-time responses that are too old to be used
+		for k, r := range c.lastGood {
+			if realtimeCmds[r.cmd] && now.Sub(r.at) > grace {
+				delete(c.lastGood, k)
+			}
+		}
+
+		c.lastGood[key] = lastGood{resp: resp, cmd: cmd, at: now}
+		c.mu.Unlock()
+
+		return resp, false, nil
+	}
+
+	last, ok := c.lastGood[key]
+	c.mu.Unlock()
+
+	if errors.Is(failed, context.Canceled) || errors.Is(failed, bartapi.ErrClosed) {
+		return resp, false, err
+	}
+
+	if !ok || (realtime && now.Sub(last.at) > grace) {
+		return resp, false, err
+	}
+
+	c.warn(ctx, fmt.Errorf("bart: using the %s response from %s: %w", cmd, last.at.Format(time.RFC3339), failed))
+
+	return last.resp, true, nil
+}
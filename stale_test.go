@@ -0,0 +1,165 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/theckman/go-bart"
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestSetStaleIfError(c *C) {
+	ctx := context.Background()
+
+	now := time.Date(2019, 1, 1, 10, 0, 0, 0, bart.Pacific)
+	t.c.SetClock(func() time.Time { return now })
+
+	var warnings []error
+
+	t.c.SetHooks(bartapi.Hooks{Warning: func(ctx context.Context, err error) {
+		warnings = append(warnings, err)
+	}})
+
+	t.c.SetStaleIfError(true, time.Minute)
+
+	sched, err := t.c.GetStationSchedule(ctx, "MCAR", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(sched.Stale, Equals, false)
+
+	est, err := t.c.GetEstimates(ctx, "MCAR")
+	c.Assert(err, IsNil)
+	c.Check(est.Stale, Equals, false)
+
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		http.Error(rw, "down", http.StatusServiceUnavailable)
+	})
+
+	// schedules are used regardless of their age
+	now = now.Add(48 * time.Hour)
+
+	sched, err = t.c.GetStationSchedule(ctx, "MCAR", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(sched.Stale, Equals, true)
+	c.Check(sched.Station.Abbr, Equals, "MCAR")
+	c.Assert(warnings, HasLen, 1)
+	c.Check(warnings[0], ErrorMatches, "bart: using the stnsched response from 2019-01-01T10:00:00-08:00: bartapi: unexpected HTTP status: 503 Service Unavailable")
+
+	// there's no response to fall back to for other requests
+	_, err = t.c.GetStationSchedule(ctx, "12TH", time.Time{})
+	c.Check(err, NotNil)
+
+	// the estimates are too old
+	_, err = t.c.GetEstimates(ctx, "MCAR")
+	c.Check(err, NotNil)
+
+	t.srv.Config.Handler = fixtures
+
+	_, err = t.c.GetEstimates(ctx, "MCAR")
+	c.Assert(err, IsNil)
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		http.Error(rw, "down", http.StatusServiceUnavailable)
+	})
+
+	now = now.Add(30 * time.Second)
+
+	est, err = t.c.GetEstimates(ctx, "MCAR")
+	c.Assert(err, IsNil)
+	c.Check(est.Stale, Equals, true)
+
+	// a canceled context isn't a failure of the API
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = t.c.GetStationSchedule(canceled, "MCAR", time.Time{})
+	c.Check(err, NotNil)
+
+	t.c.SetStaleIfError(false, 0)
+
+	_, err = t.c.GetStationSchedule(ctx, "MCAR", time.Time{})
+	c.Check(err, NotNil)
+}
+
+func (t *TestSuite) TestSetStaleIfErrorReference(c *C) {
+	ctx := context.Background()
+
+	t.c.SetStaleIfError(true, 0)
+
+	fare, err := t.c.GetFare(ctx, "12TH", "MCAR")
+	c.Assert(err, IsNil)
+	c.Check(fare.Stale, Equals, false)
+
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") != "fare" {
+			fixtures.ServeHTTP(rw, req)
+			return
+		}
+
+		http.Error(rw, "down", http.StatusServiceUnavailable)
+	})
+
+	fare, err = t.c.GetFare(ctx, "12TH", "MCAR")
+	c.Assert(err, IsNil)
+	c.Check(fare.Stale, Equals, true)
+
+	// the real-time data isn't covered without a grace period
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		http.Error(rw, "down", http.StatusServiceUnavailable)
+	})
+
+	_, err = t.c.GetEstimates(ctx, "MCAR")
+	c.Check(err, NotNil)
+}
+
+func (t *TestSuite) TestSetStaleIfErrorEnvelope(c *C) {
+	ctx := context.Background()
+
+	t.c.SetStaleIfError(true, 0)
+
+	h, err := t.c.GetHolidays(ctx)
+	c.Assert(err, IsNil)
+	c.Check(h.Stale, Equals, false)
+
+	want := len(h.Holidays)
+	c.Assert(want > 0, Equals, true)
+
+	const apiError = `<?xml version="1.0" encoding="utf-8"?><root><message><error><text>Internal</text></error></message></root>`
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(apiError))
+	})
+
+	// an error in the envelope is a failure
+	h, err = t.c.GetHolidays(ctx)
+	c.Assert(err, IsNil)
+	c.Check(h.Stale, Equals, true)
+	c.Check(h.Holidays, HasLen, want)
+
+	// and the response wasn't kept in place of the good one
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		http.Error(rw, "down", http.StatusServiceUnavailable)
+	})
+
+	h, err = t.c.GetHolidays(ctx)
+	c.Assert(err, IsNil)
+	c.Check(h.Stale, Equals, true)
+	c.Check(h.Holidays, HasLen, want)
+
+	// without a response to fall back to the envelope's error is returned
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(apiError))
+	})
+
+	_, err = t.c.GetSpecialSchedules(ctx)
+	c.Check(err, ErrorMatches, "bartapi: Internal")
+}
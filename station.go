@@ -0,0 +1,292 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// Station is the general information about a BART station.
+type Station struct {
+	Name      string  `xml:"name"`
+	Abbr      string  `xml:"abbr"`
+	Latitude  float64 `xml:"gtfs_latitude"`
+	Longitude float64 `xml:"gtfs_longitude"`
+	Address   string  `xml:"address"`
+	City      string  `xml:"city"`
+	County    string  `xml:"county"`
+	State     string  `xml:"state"`
+	Zipcode   string  `xml:"zipcode"`
+}
+
+// StationsResponse is the response of the stns command.
+type StationsResponse struct {
+	bartapi.Envelope
+	Meta
+	Stations []Station `xml:"stations>station"`
+}
+
+// Platform is a station platform, along with the routes that
+// depart from it.
+type Platform struct {
+	Number    int
+	Routes    []int
+	Direction Direction
+
+	// Description is the part of the station's PlatformInfo about the
+	// platform, if any. See ParsePlatformInfo.
+	Description string
+}
+
+// StationInfo is the detailed information about a single station.
+//
+// The API only associates platforms with routes by their direction of travel,
+// so each Platform lists all of the routes heading in its direction.
+type StationInfo struct {
+	Station
+	NorthRoutes    []string `xml:"north_routes>route"`
+	SouthRoutes    []string `xml:"south_routes>route"`
+	NorthPlatforms []int    `xml:"north_platforms>platform"`
+	SouthPlatforms []int    `xml:"south_platforms>platform"`
+	PlatformInfo   string   `xml:"platform_info"`
+	Intro          string   `xml:"intro"`
+	CrossStreet    string   `xml:"cross_street"`
+	Food           string   `xml:"food"`
+	Shopping       string   `xml:"shopping"`
+	Attraction     string   `xml:"attraction"`
+	Link           string   `xml:"link"`
+
+	// Platforms is built from the platform and route lists above.
+	Platforms []Platform `xml:"-"`
+
+	// PlatformNotes is the PlatformInfo parsed using ParsePlatformInfo.
+	PlatformNotes map[int]string `xml:"-"`
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface. It decodes the
+// station info and then builds the Platforms field.
+func (s *StationInfo) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type stationInfo StationInfo
+
+	if err := d.DecodeElement((*stationInfo)(s), &start); err != nil {
+		return err
+	}
+
+	north, err := routeNumbers(s.NorthRoutes)
+
+	if err != nil {
+		return err
+	}
+
+	south, err := routeNumbers(s.SouthRoutes)
+
+	if err != nil {
+		return err
+	}
+
+	s.Platforms = nil
+	s.PlatformNotes = ParsePlatformInfo(s.PlatformInfo)
+
+	for _, n := range s.NorthPlatforms {
+		s.Platforms = append(s.Platforms, Platform{Number: n, Routes: north, Direction: North, Description: s.PlatformNotes[n]})
+	}
+
+	for _, n := range s.SouthPlatforms {
+		s.Platforms = append(s.Platforms, Platform{Number: n, Routes: south, Direction: South, Description: s.PlatformNotes[n]})
+	}
+
+	return nil
+}
+
+var (
+	platformRegexp       = regexp.MustCompile(`(?i)\bplatforms?\s+(\d+(?:\s*(?:,|&|and)\s*\d+)*)\s*[:\-]?\s*`)
+	platformNumberRegexp = regexp.MustCompile(`\d+`)
+)
+
+// ParsePlatformInfo splits the platform info of a station, which is free
+// text, in to a description of each platform, keyed by platform number.
+// The text is split at each mention of platforms, like "Platform 1" or
+// "Platforms 2 and 4:", and the text up to the next mention describes each
+// of the platforms. Text before the first mention isn't about a specific
+// platform, so it's not included. Nil is returned if no platforms are
+// mentioned.
+func ParsePlatformInfo(info string) map[int]string {
+	matches := platformRegexp.FindAllStringSubmatchIndex(info, -1)
+
+	if len(matches) == 0 {
+		return nil
+	}
+
+	notes := make(map[int]string)
+
+	for i, m := range matches {
+		end := len(info)
+
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+
+		desc := strings.TrimSpace(info[m[1]:end])
+
+		for _, n := range platformNumberRegexp.FindAllString(info[m[2]:m[3]], -1) {
+			num, _ := strconv.Atoi(n)
+			notes[num] = desc
+		}
+	}
+
+	return notes
+}
+
+// StationInfoResponse is the response of the stninfo command.
+type StationInfoResponse struct {
+	bartapi.Envelope
+	Meta
+	Station StationInfo `xml:"stations>station"`
+}
+
+// StationAccess is the information about getting to, and from, a station.
+type StationAccess struct {
+	Name            string `xml:"name"`
+	Abbr            string `xml:"abbr"`
+	ParkingFlag     bool   `xml:"parking_flag,attr"`
+	BikeFlag        bool   `xml:"bike_flag,attr"`
+	BikeStationFlag bool   `xml:"bike_station_flag,attr"`
+	LockerFlag      bool   `xml:"locker_flag,attr"`
+	Entering        string `xml:"entering"`
+	Exiting         string `xml:"exiting"`
+	Parking         string `xml:"parking"`
+	FillTime        string `xml:"fill_time"`
+	CarShare        string `xml:"car_share"`
+	Lockers         string `xml:"lockers"`
+	BikeStationText string `xml:"bike_station_text"`
+	Destinations    string `xml:"destinations"`
+	TransitInfo     string `xml:"transit_info"`
+	Link            string `xml:"link"`
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+func (a *StationAccess) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type stationAccess StationAccess
+
+	v := struct {
+		*stationAccess
+		ParkingFlag     xmlBool `xml:"parking_flag,attr"`
+		BikeFlag        xmlBool `xml:"bike_flag,attr"`
+		BikeStationFlag xmlBool `xml:"bike_station_flag,attr"`
+		LockerFlag      xmlBool `xml:"locker_flag,attr"`
+	}{stationAccess: (*stationAccess)(a)}
+
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+
+	a.ParkingFlag = bool(v.ParkingFlag)
+	a.BikeFlag = bool(v.BikeFlag)
+	a.BikeStationFlag = bool(v.BikeStationFlag)
+	a.LockerFlag = bool(v.LockerFlag)
+
+	return nil
+}
+
+// StationAccessResponse is the response of the stnaccess command.
+type StationAccessResponse struct {
+	bartapi.Envelope
+	Meta
+	Station StationAccess `xml:"stations>station"`
+}
+
+// GetStations returns the list of all BART stations. The result is
+// cached by the client, and used by ResolveStation. Concurrent calls
+// made before the list is cached share a single request to the API, which
+// isn't canceled when one of their contexts is.
+func (c *Client) GetStations(ctx context.Context, opts ...Option) (*StationsResponse, error) {
+	c.mu.Lock()
+	cached := c.stations
+	c.mu.Unlock()
+
+	if cached != nil {
+		return cached, nil
+	}
+
+	v, err := c.shared(ctx, "stns", func(ctx context.Context) (interface{}, error) {
+		r := &StationsResponse{}
+
+		if err := c.get(ctx, c.station, "stns", nil, r, opts...); err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		c.stations = r
+		c.mu.Unlock()
+
+		return r, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*StationsResponse), nil
+}
+
+// GetStationInfo returns the detailed information for the station
+// with the abbreviation abbr.
+func (c *Client) GetStationInfo(ctx context.Context, abbr string, opts ...Option) (*StationInfoResponse, error) {
+	abbr, err := c.stationAbbr(newOptions(opts), abbr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := &StationInfoResponse{}
+
+	if err := c.get(ctx, c.station, "stninfo", map[string]string{"orig": abbr}, r, opts...); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetStationAccess returns the access information for the station
+// with the abbreviation abbr.
+func (c *Client) GetStationAccess(ctx context.Context, abbr string, opts ...Option) (*StationAccessResponse, error) {
+	abbr, err := c.stationAbbr(newOptions(opts), abbr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := &StationAccessResponse{}
+
+	if err := c.get(ctx, c.station, "stnaccess", map[string]string{"orig": abbr}, r, opts...); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// routeNumbers parses route names, like "ROUTE 7", in to their numbers.
+func routeNumbers(routes []string) ([]int, error) {
+	nums := make([]int, 0, len(routes))
+
+	for _, r := range routes {
+		n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(r)), "ROUTE")))
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid route %q", r)
+		}
+
+		nums = append(nums, n)
+	}
+
+	return nums, nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/theckman/go-bart"
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestGetStationInfo(c *C) {
+	r, err := t.c.GetStationInfo(context.Background(), "MCAR")
+	c.Assert(err, IsNil)
+	c.Check(t.srv.count("stninfo"), Equals, 1)
+	c.Check(r.Latency > 0, Equals, true)
+
+	s := r.Station
+	c.Check(s.Name, Equals, "MacArthur")
+	c.Check(s.Abbr, Equals, "MCAR")
+	c.Check(s.Latitude, Equals, 37.829065)
+	c.Check(s.Longitude, Equals, -122.267040)
+	c.Check(s.City, Equals, "Oakland")
+	c.Check(s.NorthRoutes, DeepEquals, []string{"ROUTE 2", "ROUTE 3", "ROUTE 8"})
+	c.Check(s.NorthPlatforms, DeepEquals, []int{1, 3})
+	c.Check(s.SouthPlatforms, DeepEquals, []int{2, 4})
+
+	north := "Richmond and Antioch trains."
+	south := "San Francisco, Millbrae and Berryessa trains."
+
+	c.Check(s.Platforms, DeepEquals, []bart.Platform{
+		{Number: 1, Routes: []int{2, 3, 8}, Direction: bart.North, Description: north},
+		{Number: 3, Routes: []int{2, 3, 8}, Direction: bart.North, Description: north},
+		{Number: 2, Routes: []int{1, 4, 7}, Direction: bart.South, Description: south},
+		{Number: 4, Routes: []int{1, 4, 7}, Direction: bart.South, Description: south},
+	})
+	c.Check(s.PlatformInfo, Matches, "Always check destination signs.*Berryessa trains.")
+	c.Check(s.PlatformNotes, DeepEquals, map[int]string{1: north, 3: north, 2: south, 4: south})
+
+	_, err = t.c.GetStationInfo(context.Background(), "NOPE")
+	c.Check(err, Not(IsNil))
+}
+
+func (*TestSuite) TestParsePlatformInfo(c *C) {
+	c.Check(bart.ParsePlatformInfo("Always check destination signs."), IsNil)
+	c.Check(bart.ParsePlatformInfo(""), IsNil)
+
+	c.Check(bart.ParsePlatformInfo("Platform 1 serves Richmond trains. platforms 2, 3 & 4: all other trains"), DeepEquals, map[int]string{
+		1: "serves Richmond trains.",
+		2: "all other trains",
+		3: "all other trains",
+		4: "all other trains",
+	})
+}
+
+func (t *TestSuite) TestGetStations(c *C) {
+	r, err := t.c.GetStations(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(r.Stations, HasLen, 3)
+	c.Check(r.Stations[0].Abbr, Equals, "12TH")
+	c.Check(r.Stations[1].Abbr, Equals, "MCAR")
+	c.Check(r.Stations[2].Name, Equals, "West Oakland")
+	c.Check(r.Stations[2].Zipcode, Equals, "94607")
+
+	cached, err := t.c.GetStations(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(cached, Equals, r)
+	c.Check(t.srv.count("stns"), Equals, 1)
+}
+
+func (t *TestSuite) TestGetStationsConcurrent(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	// hold the request open so the calls overlap
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			r, err := t.c.GetStations(context.Background())
+
+			if err == nil && len(r.Stations) != 3 {
+				err = fmt.Errorf("got %d stations", len(r.Stations))
+			}
+
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		c.Check(err, IsNil)
+	}
+
+	c.Check(t.srv.count("stns"), Equals, 1)
+}
+
+func (t *TestSuite) TestGetStationsCanceled(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	arrived, release := make(chan struct{}), make(chan struct{})
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		close(arrived)
+		<-release
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+
+	go func() {
+		_, err := t.c.GetStations(ctx)
+		first <- err
+	}()
+
+	<-arrived
+
+	second := make(chan error, 1)
+
+	go func() {
+		r, err := t.c.GetStations(context.Background())
+
+		if err == nil && len(r.Stations) != 3 {
+			err = fmt.Errorf("got %d stations", len(r.Stations))
+		}
+
+		second <- err
+	}()
+
+	// let the second call join the request before the first is canceled
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	c.Check(<-first, Equals, context.Canceled)
+
+	close(release)
+	c.Check(<-second, IsNil)
+	c.Check(t.srv.count("stns"), Equals, 1)
+}
+
+func (t *TestSuite) TestGetStationAccess(c *C) {
+	r, err := t.c.GetStationAccess(context.Background(), "12TH")
+	c.Assert(err, IsNil)
+	c.Check(r.Station.Abbr, Equals, "12TH")
+	c.Check(r.Station.ParkingFlag, Equals, true)
+	c.Check(r.Station.BikeFlag, Equals, true)
+	c.Check(r.Station.BikeStationFlag, Equals, false)
+	c.Check(r.Station.TransitInfo, Equals, "AC Transit buses stop nearby.")
+}
+
+func (*TestSuite) TestDecodeRepeatedElements(c *C) {
+	stations := &bart.StationsResponse{}
+
+	err := bartapi.Decode(strings.NewReader(`<root>
+		<stations>
+			<station><name>12th St. Oakland City Center</name><abbr>12TH</abbr></station>
+			<station><name>16th St. Mission</name><abbr>16TH</abbr></station>
+			<station><name>24th St. Mission</name><abbr>24TH</abbr></station>
+		</stations>
+	</root>`), stations)
+	c.Assert(err, IsNil)
+	c.Assert(stations.Stations, HasLen, 3)
+	c.Check(stations.Stations[0].Abbr, Equals, "12TH")
+	c.Check(stations.Stations[1].Abbr, Equals, "16TH")
+	c.Check(stations.Stations[2].Abbr, Equals, "24TH")
+
+	// the etd command repeats <station> at the root when orig=ALL
+	estimates := &bart.EstimatesResponse{}
+
+	err = bartapi.Decode(strings.NewReader(`<root>
+		<station><abbr>12TH</abbr><etd><abbreviation>RICH</abbreviation></etd></station>
+		<station><abbr>16TH</abbr><etd><abbreviation>DUBL</abbreviation></etd><etd><abbreviation>MLBR</abbreviation></etd></station>
+	</root>`), estimates)
+	c.Assert(err, IsNil)
+	c.Assert(estimates.Stations, HasLen, 2)
+	c.Check(estimates.Stations[0].ETDs, HasLen, 1)
+	c.Check(estimates.Stations[1].ETDs, HasLen, 2)
+	c.Check(estimates.Stations[1].ETDs[1].Abbreviation, Equals, "MLBR")
+
+	// the bsa command repeats <bsa> at the root
+	advisories := &bart.AdvisoriesResponse{}
+
+	err = bartapi.Decode(strings.NewReader(`<root><bsa id="1"/><bsa id="2"/></root>`), advisories)
+	c.Assert(err, IsNil)
+	c.Assert(advisories.Advisories, HasLen, 2)
+	c.Check(advisories.Advisories[1].ID, Equals, "2")
+}
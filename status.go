@@ -0,0 +1,164 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OverallStatus is the overall health of the BART system.
+type OverallStatus int
+
+const (
+	// StatusUnknown is the status when the advisories couldn't be fetched.
+	StatusUnknown OverallStatus = iota
+
+	// StatusNormal is the status when there are no advisories.
+	StatusNormal
+
+	// StatusDegraded is the status when there are advisories, but
+	// none of them are for major delays.
+	StatusDegraded
+
+	// StatusMajorDelays is the status when there are emergencies, or
+	// delays of at least MajorDelayMinutes.
+	StatusMajorDelays
+)
+
+func (s OverallStatus) String() string {
+	switch s {
+	case StatusNormal:
+		return "Normal"
+	case StatusDegraded:
+		return "Degraded"
+	case StatusMajorDelays:
+		return "MajorDelays"
+	default:
+		return "Unknown"
+	}
+}
+
+// MajorDelayMinutes is the length of the delays, in minutes, that
+// are considered major by AdvisoryStatus.
+const MajorDelayMinutes = 15
+
+// noDelays is the description of the advisory BART
+// returns when there are no delays.
+const noDelays = "no delays reported"
+
+var delayMinutesRegexp = regexp.MustCompile(`(?i)(\d+)[- ]?min`)
+
+// DelayMinutes returns the length of the delay, in minutes, mentioned in
+// the description of the advisory. If there's more than one, the longest is
+// returned. Zero is returned if the description doesn't mention a delay.
+func (a Advisory) DelayMinutes() int {
+	var max int
+
+	for _, m := range delayMinutesRegexp.FindAllStringSubmatch(a.Description, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > max {
+			max = n
+		}
+	}
+
+	return max
+}
+
+// AdvisoryStatus returns the status the advisory puts the system in:
+//
+//   - StatusNormal if it's BART's "No delays reported." advisory
+//   - StatusMajorDelays if it's an emergency, or it's for a delay of at
+//     least MajorDelayMinutes
+//   - StatusDegraded otherwise
+func AdvisoryStatus(a Advisory) OverallStatus {
+	desc := strings.ToLower(strings.TrimSpace(a.Description))
+
+	if strings.HasPrefix(desc, noDelays) {
+		return StatusNormal
+	}
+
+	if strings.EqualFold(strings.TrimSpace(a.Type), "EMERGENCY") || a.DelayMinutes() >= MajorDelayMinutes {
+		return StatusMajorDelays
+	}
+
+	return StatusDegraded
+}
+
+// OverallStatusOf returns the most severe AdvisoryStatus of the
+// advisories, or StatusNormal if there are none.
+func OverallStatusOf(advisories []Advisory) OverallStatus {
+	status := StatusNormal
+
+	for _, a := range advisories {
+		if s := AdvisoryStatus(a); s > status {
+			status = s
+		}
+	}
+
+	return status
+}
+
+// SystemStatus is the overall health of the BART system, returned by
+// GetSystemStatus. Each section is fetched separately, so if one fails its
+// Err field is set and the section is nil, without affecting the others.
+type SystemStatus struct {
+	// Status is computed from the advisories using OverallStatusOf. It's
+	// StatusUnknown if the advisories couldn't be fetched.
+	Status OverallStatus
+
+	Advisories    *AdvisoriesResponse
+	AdvisoriesErr error
+
+	TrainCount    *TrainCountResponse
+	TrainCountErr error
+
+	Elevators    *AdvisoriesResponse
+	ElevatorsErr error
+}
+
+// GetSystemStatus concurrently fetches the current advisories, the number
+// of active trains, and the elevator status, and computes the overall
+// status of the system from the advisories.
+//
+// Errors fetching the sections are set on the SystemStatus. A MultiError,
+// keyed by the command of each section, is only returned if all of them
+// failed.
+func (c *Client) GetSystemStatus(ctx context.Context) (*SystemStatus, error) {
+	s := &SystemStatus{}
+
+	var wg sync.WaitGroup
+
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		s.Advisories, s.AdvisoriesErr = c.GetAdvisories(ctx)
+	}()
+
+	go func() {
+		defer wg.Done()
+		s.TrainCount, s.TrainCountErr = c.GetTrainCount(ctx)
+	}()
+
+	go func() {
+		defer wg.Done()
+		s.Elevators, s.ElevatorsErr = c.GetElevatorStatus(ctx)
+	}()
+
+	wg.Wait()
+
+	if s.Advisories != nil {
+		s.Status = OverallStatusOf(s.Advisories.Advisories)
+	}
+
+	if s.AdvisoriesErr != nil && s.TrainCountErr != nil && s.ElevatorsErr != nil {
+		return s, MultiError{"bsa": s.AdvisoriesErr, "count": s.TrainCountErr, "elev": s.ElevatorsErr}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (*TestSuite) TestAdvisoryStatus(c *C) {
+	tests := []struct {
+		a       bart.Advisory
+		minutes int
+		status  bart.OverallStatus
+	}{
+		{bart.Advisory{Description: "No delays reported."}, 0, bart.StatusNormal},
+		{bart.Advisory{Type: "DELAY", Description: "There is a 10-minute delay at West Oakland."}, 10, bart.StatusDegraded},
+		{bart.Advisory{Type: "DELAY", Description: "Expect 5 min delays, up to 20 minutes in the East Bay."}, 20, bart.StatusMajorDelays},
+		{bart.Advisory{Type: "DELAY", Description: "There is a 15-minute delay."}, 15, bart.StatusMajorDelays},
+		{bart.Advisory{Type: "EMERGENCY", Description: "Service is suspended."}, 0, bart.StatusMajorDelays},
+		{bart.Advisory{Type: "DELAY", Description: "Platform 3 at MacArthur is closed."}, 0, bart.StatusDegraded},
+	}
+
+	for _, tt := range tests {
+		c.Check(tt.a.DelayMinutes(), Equals, tt.minutes, Commentf("%s", tt.a.Description))
+		c.Check(bart.AdvisoryStatus(tt.a), Equals, tt.status, Commentf("%s", tt.a.Description))
+	}
+
+	c.Check(bart.OverallStatusOf(nil), Equals, bart.StatusNormal)
+	c.Check(bart.OverallStatusOf([]bart.Advisory{tests[1].a, tests[4].a, tests[0].a}), Equals, bart.StatusMajorDelays)
+
+	c.Check(bart.StatusMajorDelays.String(), Equals, "MajorDelays")
+	c.Check(bart.StatusUnknown.String(), Equals, "Unknown")
+}
+
+func (t *TestSuite) TestGetSystemStatus(c *C) {
+	s, err := t.c.GetSystemStatus(context.Background())
+	c.Assert(err, IsNil)
+
+	// the longest delay is 10 minutes
+	c.Check(s.Status, Equals, bart.StatusDegraded)
+	c.Check(s.Advisories.Advisories, HasLen, 4)
+	c.Check(s.TrainCount.TrainCount, Equals, 52)
+	c.Check(s.Elevators.Advisories, HasLen, 2)
+}
+
+func (t *TestSuite) TestGetSystemStatusErrors(c *C) {
+	fixtures := t.srv.Config.Handler
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.FormValue("cmd") == "bsa" {
+			http.Error(rw, "down", http.StatusInternalServerError)
+			return
+		}
+
+		fixtures.ServeHTTP(rw, req)
+	})
+
+	s, err := t.c.GetSystemStatus(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(s.Status, Equals, bart.StatusUnknown)
+	c.Check(s.AdvisoriesErr, NotNil)
+	c.Check(s.TrainCount.TrainCount, Equals, 52)
+
+	c.Assert(t.c.Close(), IsNil)
+
+	_, err = t.c.GetSystemStatus(context.Background())
+	c.Assert(err, FitsTypeOf, bart.MultiError{})
+	c.Check(err.(bart.MultiError), HasLen, 3)
+}
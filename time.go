@@ -0,0 +1,83 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"strings"
+	"time"
+
+	// BART is in Pacific time, so make sure the
+	// zone is available regardless of the host.
+	_ "time/tzdata"
+)
+
+// dateFormat is the layout of dates the API uses, and accepts, in its params.
+const dateFormat = "01/02/2006"
+
+// dateTimeFormat is the layout of the dates with times in the responses.
+const dateTimeFormat = "01/02/2006 03:04 PM"
+
+// clockFormat is the layout of the times of day in schedule responses.
+const clockFormat = "3:04 PM"
+
+// Pacific is the timezone BART operates in. All of the dates and
+// times in API responses are in this zone.
+var Pacific = loadLocation("America/Los_Angeles")
+
+func loadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return loc
+}
+
+// formatDate formats t, in Pacific time, as a date param.
+func formatDate(t time.Time) string {
+	return t.In(Pacific).Format(dateFormat)
+}
+
+// parseDateTime parses a date with a time from an API response.
+func parseDateTime(s string) (time.Time, error) {
+	return time.ParseInLocation(dateTimeFormat, s, Pacific)
+}
+
+// serviceDateFormats are the layouts of the dates schedule responses
+// are for, which differ between the commands.
+var serviceDateFormats = []string{dateFormat, "Jan 2, 2006", "January 2, 2006"}
+
+// parseServiceDate parses the date a schedule response is for as the
+// start of the day in Pacific time. The zero value is returned if it's
+// invalid.
+func parseServiceDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+
+	for _, layout := range serviceDateFormats {
+		if t, err := time.ParseInLocation(layout, s, Pacific); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// defaultStaleScheduleAge is how long after the start of its date a
+// schedule response is considered stale, unless it's changed using
+// SetStaleScheduleAge. BART's service day runs until about 3am the next
+// day, so it's 27 hours.
+const defaultStaleScheduleAge = 27 * time.Hour
+
+// isStale returns whether a schedule for the date is stale at now, once
+// it's older than age, or defaultStaleScheduleAge if that's zero.
+// Schedules without a date are always stale.
+func isStale(date, now time.Time, age time.Duration) bool {
+	if age <= 0 {
+		age = defaultStaleScheduleAge
+	}
+
+	return date.IsZero() || now.Sub(date) > age
+}
@@ -0,0 +1,295 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// maxTripCount is the most trips BART returns before, or after,
+// the requested time.
+const maxTripCount = 4
+
+// ErrInvalidTripCount is returned by the trip planning methods when the
+// Before or After options are outside of the range BART allows. The API
+// would silently clamp them otherwise.
+var ErrInvalidTripCount = fmt.Errorf("bart: trip count must be between 0 and %d", maxTripCount)
+
+// timeFormat is the layout of the time param of the trip planning commands.
+const timeFormat = "3:04pm"
+
+// tripTimeFormat is the layout of the dates and times of trips and legs.
+const tripTimeFormat = "01/02/2006 3:04 PM"
+
+// tripTimes parses the departure and the arrival, which are dates and times
+// in Pacific time. BART sometimes gives trips crossing midnight an arrival
+// date that's the same as the departure's, so arrivals before the departure
+// are taken to be on the next day.
+func tripTimes(origDate, origTime, destDate, destTime string) (orig, dest time.Time, ok bool) {
+	orig, err := time.ParseInLocation(tripTimeFormat, strings.TrimSpace(origDate)+" "+strings.TrimSpace(origTime), Pacific)
+
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	dest, err = time.ParseInLocation(tripTimeFormat, strings.TrimSpace(destDate)+" "+strings.TrimSpace(destTime), Pacific)
+
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	if dest.Before(orig) {
+		// the dates are in Pacific time, so add a calendar day
+		// rather than 24 hours in case of a DST change
+		dest = dest.AddDate(0, 0, 1)
+	}
+
+	return orig, dest, true
+}
+
+// tripDuration returns the time between the departure and the arrival.
+// See tripTimes.
+func tripDuration(origDate, origTime, destDate, destTime string) (time.Duration, bool) {
+	orig, dest, ok := tripTimes(origDate, origTime, destDate, destTime)
+	return dest.Sub(orig), ok
+}
+
+// The transfer codes of a Leg, which describe the transfer at its
+// destination to the next leg of the trip.
+const (
+	// NormalTransfer is a transfer where the trains aren't coordinated.
+	NormalTransfer = "N"
+
+	// TimedTransfer is a transfer where the next train waits
+	// for this one.
+	TimedTransfer = "T"
+
+	// ScheduledTransfer is a transfer where the trains are
+	// scheduled to meet, but the next train doesn't wait.
+	ScheduledTransfer = "S"
+)
+
+// Leg is a single train ride of a Trip. TransferCode is set when there's
+// a transfer to another train at the leg's destination.
+type Leg struct {
+	Order            int    `xml:"order,attr"`
+	TransferCode     string `xml:"transfercode,attr"`
+	Origin           string `xml:"origin,attr"`
+	Destination      string `xml:"destination,attr"`
+	OrigTimeMin      string `xml:"origTimeMin,attr"`
+	OrigTimeDate     string `xml:"origTimeDate,attr"`
+	DestTimeMin      string `xml:"destTimeMin,attr"`
+	DestTimeDate     string `xml:"destTimeDate,attr"`
+	Line             string `xml:"line,attr"`
+	BikeFlag         bool   `xml:"bikeflag,attr"`
+	TrainHeadStation string `xml:"trainHeadStation,attr"`
+	Load             int    `xml:"load,attr"`
+	TrainID          string `xml:"trainId,attr"`
+	TrainIdx         int    `xml:"trainIdx,attr"`
+
+	// Departs and Arrives are the parsed times of the leg, in the
+	// client's display location. They're zero if the times are invalid.
+	Departs time.Time `xml:"-"`
+	Arrives time.Time `xml:"-"`
+}
+
+// UnmarshalXML satisfies the xml.Unmarshaler interface.
+func (l *Leg) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type leg Leg
+
+	v := struct {
+		*leg
+		BikeFlag xmlBool `xml:"bikeflag,attr"`
+	}{leg: (*leg)(l)}
+
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+
+	l.BikeFlag = bool(v.BikeFlag)
+
+	return nil
+}
+
+// Duration returns how long the leg's train ride takes, from its
+// departure to its arrival. Zero is returned if its times are invalid.
+func (l Leg) Duration() time.Duration {
+	d, _ := tripDuration(l.OrigTimeDate, l.OrigTimeMin, l.DestTimeDate, l.DestTimeMin)
+	return d
+}
+
+// Trip is a planned trip between two stations, made up of one or more legs.
+type Trip struct {
+	Origin       string `xml:"origin,attr"`
+	Destination  string `xml:"destination,attr"`
+	Fare         string `xml:"fare,attr"`
+	OrigTimeMin  string `xml:"origTimeMin,attr"`
+	OrigTimeDate string `xml:"origTimeDate,attr"`
+	DestTimeMin  string `xml:"destTimeMin,attr"`
+	DestTimeDate string `xml:"destTimeDate,attr"`
+	TripTime     int    `xml:"tripTime,attr"`
+	Fares        Fares  `xml:"fares"`
+	Legs         []Leg  `xml:"leg"`
+
+	// Departs and Arrives are the parsed times of the trip, in the
+	// client's display location. They're zero if the times are invalid.
+	Departs time.Time `xml:"-"`
+	Arrives time.Time `xml:"-"`
+}
+
+// Duration returns how long the trip takes, from its departure to its
+// arrival, including the time spent transferring. If its times are
+// invalid the TripTime is used instead.
+func (t Trip) Duration() time.Duration {
+	if d, ok := tripDuration(t.OrigTimeDate, t.OrigTimeMin, t.DestTimeDate, t.DestTimeMin); ok {
+		return d
+	}
+
+	return time.Duration(t.TripTime) * time.Minute
+}
+
+// Transfers returns the stations at which the trip transfers between
+// trains, in order. A transfer is where a leg has a TransferCode, or
+// where the next leg is on a different line or train, as BART doesn't
+// always flag them.
+func (t Trip) Transfers() []string {
+	var stations []string
+
+	for i := 0; i+1 < len(t.Legs); i++ {
+		l, next := t.Legs[i], t.Legs[i+1]
+
+		if l.TransferCode != "" || l.Line != next.Line || l.TrainHeadStation != next.TrainHeadStation {
+			stations = append(stations, l.Destination)
+		}
+	}
+
+	return stations
+}
+
+// TripsResponse is the response of the arrive and depart commands. SchedNum
+// is the number of the schedule the trips were planned with, which can be
+// passed to LookupSchedule to get the date it took effect.
+type TripsResponse struct {
+	bartapi.Envelope
+	Meta
+	Origin      string `xml:"origin"`
+	Destination string `xml:"destination"`
+	SchedNum    int    `xml:"sched_num"`
+	Date        string `xml:"schedule>date"`
+	Time        string `xml:"schedule>time"`
+	Before      int    `xml:"schedule>before"`
+	After       int    `xml:"schedule>after"`
+	Trips       []Trip `xml:"schedule>request>trip"`
+
+	// EffectiveDate is the Date parsed as the start of the day in
+	// Pacific time, or the zero value if it's invalid.
+	EffectiveDate time.Time `xml:"-"`
+}
+
+// GetDepartures plans trips from orig to dest departing around t. Both are
+// station abbreviations. If t is the zero value, the trips depart around
+// the current time. Use the Before and After options to set how many trips
+// are returned. If there aren't any trips the response is returned along
+// with ErrNoScheduleData.
+func (c *Client) GetDepartures(ctx context.Context, orig, dest string, t time.Time, opts ...Option) (*TripsResponse, error) {
+	return c.trips(ctx, "depart", orig, dest, t, opts)
+}
+
+// GetArrivals plans trips from orig to dest arriving around t. Both are
+// station abbreviations. If t is the zero value, the trips arrive around
+// the current time. Use the Before and After options to set how many trips
+// are returned. If there aren't any trips the response is returned along
+// with ErrNoScheduleData.
+func (c *Client) GetArrivals(ctx context.Context, orig, dest string, t time.Time, opts ...Option) (*TripsResponse, error) {
+	return c.trips(ctx, "arrive", orig, dest, t, opts)
+}
+
+func (c *Client) trips(ctx context.Context, cmd, orig, dest string, t time.Time, opts []Option) (*TripsResponse, error) {
+	o := newOptions(opts)
+
+	if err := o.checkTrips(); err != nil {
+		return nil, err
+	}
+
+	orig, err := c.stationAbbr(o, orig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dest, err = c.stationAbbr(o, dest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.connecting(ctx, o, orig, dest); err != nil {
+		return nil, err
+	}
+
+	query := o.query(map[string]string{"orig": orig, "dest": dest})
+
+	if o.before != nil {
+		query["b"] = strconv.Itoa(*o.before)
+	}
+
+	if o.after != nil {
+		query["a"] = strconv.Itoa(*o.after)
+	}
+
+	if !t.IsZero() {
+		if err := c.checkDate(t); err != nil {
+			return nil, err
+		}
+
+		query["date"] = formatDate(t)
+		query["time"] = t.In(Pacific).Format(timeFormat)
+	}
+
+	r := &TripsResponse{}
+
+	if err := c.get(ctx, c.schedule, cmd, query, r, opts...); err != nil {
+		return nil, err
+	}
+
+	r.EffectiveDate = parseServiceDate(r.Date)
+
+	loc := c.displayLocation()
+
+	for i := range r.Trips {
+		t := &r.Trips[i]
+
+		if orig, dest, ok := tripTimes(t.OrigTimeDate, t.OrigTimeMin, t.DestTimeDate, t.DestTimeMin); ok {
+			t.Departs, t.Arrives = orig.In(loc), dest.In(loc)
+		}
+
+		for j := range t.Legs {
+			l := &t.Legs[j]
+
+			if orig, dest, ok := tripTimes(l.OrigTimeDate, l.OrigTimeMin, l.DestTimeDate, l.DestTimeMin); ok {
+				l.Departs, l.Arrives = orig.In(loc), dest.In(loc)
+			}
+		}
+	}
+
+	if len(r.Trips) == 0 {
+		return r, noScheduleData(cmd, r.Date)
+	}
+
+	return r, nil
+}
+
+// IsStale returns whether the trips are stale at now, as they were planned
+// for a service day that's over. See StationScheduleResponse.IsStale.
+func (r *TripsResponse) IsStale(now time.Time) bool {
+	return isStale(r.EffectiveDate, now, r.staleAge)
+}
@@ -0,0 +1,133 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestGetDepartures(c *C) {
+	at := time.Date(2019, 2, 4, 14, 40, 0, 0, bart.Pacific)
+
+	r, err := t.c.GetDepartures(context.Background(), "ASHB", "CIVC", at, bart.Before(1), bart.After(2))
+	c.Assert(err, IsNil)
+
+	q := t.srv.query("depart")
+	c.Check(q.Get("orig"), Equals, "ASHB")
+	c.Check(q.Get("dest"), Equals, "CIVC")
+	c.Check(q.Get("date"), Equals, "02/04/2019")
+	c.Check(q.Get("time"), Equals, "2:40pm")
+	c.Check(q.Get("b"), Equals, "1")
+	c.Check(q.Get("a"), Equals, "2")
+
+	c.Check(r.Origin, Equals, "ASHB")
+	c.Check(r.SchedNum, Equals, 47)
+	c.Check(r.Before, Equals, 1)
+	c.Check(r.After, Equals, 2)
+	c.Assert(r.Trips, HasLen, 3)
+
+	trip := r.Trips[2]
+	c.Check(trip.Fare, Equals, "4.35")
+	c.Check(trip.TripTime, Equals, 34)
+	c.Assert(trip.Legs, HasLen, 2)
+	c.Check(trip.Legs[0], DeepEquals, bart.Leg{
+		Order: 1, TransferCode: "N", Origin: "ASHB", Destination: "MCAR",
+		OrigTimeMin: "2:52 PM", OrigTimeDate: "02/04/2019",
+		DestTimeMin: "2:55 PM", DestTimeDate: "02/04/2019",
+		Line: "ROUTE 4", BikeFlag: true, TrainHeadStation: "BERY", Load: 1,
+		TrainID: "414", TrainIdx: 22,
+		Departs: time.Date(2019, 2, 4, 14, 52, 0, 0, bart.Pacific),
+		Arrives: time.Date(2019, 2, 4, 14, 55, 0, 0, bart.Pacific),
+	})
+
+	c.Check(trip.Transfers(), DeepEquals, []string{"MCAR"})
+	c.Check(r.Trips[0].Transfers(), HasLen, 0)
+
+	// the counts aren't sent unless they're set
+	_, err = t.c.GetArrivals(context.Background(), "ASHB", "CIVC", time.Time{})
+	c.Assert(err, IsNil)
+
+	q = t.srv.query("arrive")
+	c.Check(q.Get("b"), Equals, "")
+	c.Check(q.Get("time"), Equals, "")
+}
+
+func (t *TestSuite) TestInvalidTripCount(c *C) {
+	for _, opt := range []bart.Option{bart.Before(-1), bart.Before(5), bart.After(-1), bart.After(5)} {
+		_, err := t.c.GetDepartures(context.Background(), "ASHB", "CIVC", time.Time{}, opt)
+		c.Check(errors.Is(err, bart.ErrInvalidTripCount), Equals, true)
+	}
+
+	_, err := t.c.GetArrivals(context.Background(), "ASHB", "CIVC", time.Time{}, bart.After(9))
+	c.Check(err, ErrorMatches, "bart: trip count must be between 0 and 4: after is 9")
+
+	c.Check(t.srv.count("depart"), Equals, 0)
+	c.Check(t.srv.count("arrive"), Equals, 0)
+}
+
+func (*TestSuite) TestTransfers(c *C) {
+	// BART doesn't flag the transfer at MCAR
+	trip := bart.Trip{Legs: []bart.Leg{
+		{Origin: "ASHB", Destination: "MCAR", Line: "ROUTE 4", TrainHeadStation: "BERY"},
+		{Origin: "MCAR", Destination: "12TH", Line: "ROUTE 1", TrainHeadStation: "SFIA", TransferCode: bart.TimedTransfer},
+		{Origin: "12TH", Destination: "WOAK", Line: "ROUTE 6", TrainHeadStation: "DALY"},
+	}}
+
+	c.Check(trip.Transfers(), DeepEquals, []string{"MCAR", "12TH"})
+}
+
+func (t *TestSuite) TestTripDuration(c *C) {
+	r, err := t.c.GetDepartures(context.Background(), "ASHB", "CIVC", time.Time{})
+	c.Assert(err, IsNil)
+
+	trip := r.Trips[2]
+	c.Check(trip.Duration(), Equals, 34*time.Minute)
+	c.Check(trip.Legs[0].Duration(), Equals, 3*time.Minute)
+	c.Check(trip.Legs[1].Duration(), Equals, 26*time.Minute)
+
+	// crossing midnight, with the arrival date on the next day or not
+	for _, destDate := range []string{"02/05/2019", "02/04/2019"} {
+		leg := bart.Leg{OrigTimeMin: "11:50 PM", OrigTimeDate: "02/04/2019", DestTimeMin: "12:15 AM", DestTimeDate: destDate}
+		c.Check(leg.Duration(), Equals, 25*time.Minute, Commentf("%s", destDate))
+	}
+
+	// invalid times fall back to the trip time
+	c.Check(bart.Trip{TripTime: 12}.Duration(), Equals, 12*time.Minute)
+	c.Check(bart.Leg{}.Duration(), Equals, time.Duration(0))
+}
+
+func (t *TestSuite) TestSetDisplayLocation(c *C) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	c.Assert(err, IsNil)
+
+	t.c.SetDisplayLocation(tokyo)
+
+	r, err := t.c.GetDepartures(context.Background(), "ASHB", "CIVC", time.Time{})
+	c.Assert(err, IsNil)
+
+	trip := r.Trips[0]
+	c.Check(trip.Departs.Location(), Equals, tokyo)
+	c.Check(trip.Departs.Equal(time.Date(2019, 2, 4, 14, 30, 0, 0, bart.Pacific)), Equals, true)
+	c.Check(trip.Arrives.Format("Jan 2 15:04"), Equals, "Feb 5 07:56")
+	c.Check(trip.Legs[0].Departs.Location(), Equals, tokyo)
+
+	// the dates BART works with stay in Pacific
+	c.Check(r.EffectiveDate.Location(), Equals, bart.Pacific)
+
+	s, err := t.c.GetStationSchedule(context.Background(), "12TH", time.Time{})
+	c.Assert(err, IsNil)
+	c.Check(s.Station.Trains[0].Departs.Format("Jan 2 15:04"), Equals, "Feb 4 21:36")
+
+	now := time.Date(2019, 2, 4, 12, 0, 0, 0, time.UTC)
+	c.Check(t.c.DisplayTime(now).Format("15:04"), Equals, "21:00")
+
+	t.c.SetDisplayLocation(nil)
+	c.Check(t.c.DisplayTime(now).Format("15:04"), Equals, "04:00")
+}
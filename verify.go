@@ -0,0 +1,78 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/theckman/go-bart/api"
+)
+
+// ErrInvalidKey is returned by Verify when the API rejects the key.
+var ErrInvalidKey = errors.New("bart: invalid API key")
+
+// VerifyResult is the result of Verify.
+type VerifyResult struct {
+	// Reachable is whether the API responded.
+	Reachable bool
+
+	// KeyValid is whether the API accepted the key. It's only set when
+	// the request succeeded, so it's false if the key couldn't be checked.
+	KeyValid bool
+
+	// Latency is how long the request took, if the API was reachable.
+	Latency time.Duration
+}
+
+// Verify checks that the API is reachable and accepts the client's key,
+// using the count command as it's the cheapest one. The result is always
+// returned, along with the error that caused the check to fail. If the
+// API rejected the key the error matches ErrInvalidKey using errors.Is,
+// and any other error with Reachable set is from the API itself.
+func (c *Client) Verify(ctx context.Context) (*VerifyResult, error) {
+	r := &VerifyResult{}
+
+	resp, err := c.advisory.PullResponse(ctx, "count", nil)
+
+	if err != nil {
+		// being throttled means the API is up
+		r.Reachable = errors.Is(err, bartapi.ErrRateLimited)
+		return r, err
+	}
+
+	r.Reachable, r.Latency = true, resp.Latency
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return r, fmt.Errorf("%w: %v", ErrInvalidKey, &bartapi.StatusError{StatusCode: resp.StatusCode})
+	case resp.StatusCode != http.StatusOK:
+		return r, &bartapi.StatusError{StatusCode: resp.StatusCode}
+	}
+
+	e, err := bartapi.DecodeEnvelope(resp.Body)
+
+	if err != nil {
+		return r, err
+	}
+
+	if err := e.Err(); err != nil {
+		var apiErr *bartapi.APIError
+
+		if errors.As(err, &apiErr) && strings.Contains(strings.ToLower(apiErr.Text+" "+apiErr.Details), "key") {
+			return r, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+
+		return r, err
+	}
+
+	r.KeyValid = true
+
+	return r, nil
+}
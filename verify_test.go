@@ -0,0 +1,61 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/theckman/go-bart"
+	"github.com/theckman/go-bart/api"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestVerify(c *C) {
+	r, err := t.c.Verify(context.Background())
+	c.Assert(err, IsNil)
+	c.Check(r.Reachable, Equals, true)
+	c.Check(r.KeyValid, Equals, true)
+	c.Check(r.Latency > 0, Equals, true)
+	c.Check(t.srv.count("count"), Equals, 1)
+}
+
+func (t *TestSuite) TestVerifyInvalidKey(c *C) {
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("<root><message><error><text>Invalid key</text><details>The api key was missing or invalid.</details></error></message></root>"))
+	})
+
+	r, err := t.c.Verify(context.Background())
+	c.Check(errors.Is(err, bart.ErrInvalidKey), Equals, true)
+	c.Check(r, DeepEquals, &bart.VerifyResult{Reachable: true, Latency: r.Latency})
+
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusForbidden)
+	})
+
+	r, err = t.c.Verify(context.Background())
+	c.Check(errors.Is(err, bart.ErrInvalidKey), Equals, true)
+	c.Check(r.Reachable, Equals, true)
+
+	// other API errors aren't about the key
+	t.srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("<root><message><error><text>Service unavailable</text></error></message></root>"))
+	})
+
+	r, err = t.c.Verify(context.Background())
+	c.Check(errors.Is(err, bart.ErrInvalidKey), Equals, false)
+	c.Check(err, FitsTypeOf, &bartapi.APIError{})
+	c.Check(r.Reachable, Equals, true)
+	c.Check(r.KeyValid, Equals, false)
+}
+
+func (t *TestSuite) TestVerifyUnreachable(c *C) {
+	t.srv.Close()
+
+	r, err := t.c.Verify(context.Background())
+	c.Check(err, NotNil)
+	c.Check(r, DeepEquals, &bart.VerifyResult{})
+}
@@ -0,0 +1,157 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// EstimateEventName is the name of the Server-Sent Events event written by
+// EstimateEvent's SSE method.
+const EstimateEventName = "estimates"
+
+// EstimateEvent is the departures from a station after they changed, as
+// seen by an EstimateWatcher. It's shaped to be marshaled to JSON and sent
+// to a browser, such as using Server-Sent Events.
+type EstimateEvent struct {
+	Station    string           `json:"station"`
+	Time       time.Time        `json:"time"`
+	Departures []EventDeparture `json:"departures"`
+}
+
+// EventDeparture is a departure in an EstimateEvent.
+type EventDeparture struct {
+	Destination     string `json:"destination"`
+	DestinationAbbr string `json:"destinationAbbr"`
+	Line            string `json:"line"`
+	Minutes         int    `json:"minutes"`
+	Platform        int    `json:"platform,omitempty"`
+
+	// Direction is "North", "South", or omitted if it's unknown.
+	Direction string `json:"direction,omitempty"`
+
+	// Delay is the delay of the train in seconds.
+	Delay int `json:"delay,omitempty"`
+}
+
+// SSE returns the event as a Server-Sent Events message, named
+// EstimateEventName with the event as JSON as its data. It's written to
+// the response as is, followed by a flush.
+func (e EstimateEvent) SSE() ([]byte, error) {
+	data, err := json.Marshal(e)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+
+	b.WriteString("event: " + EstimateEventName + "\n")
+	b.WriteString("data: ")
+	b.Write(data)
+	b.WriteString("\n\n")
+
+	return b.Bytes(), nil
+}
+
+// EstimateWatcher polls the estimated departures from a station, emitting
+// an EstimateEvent each time they change. It's safe for concurrent use.
+type EstimateWatcher struct {
+	c       *Client
+	station string
+	opts    []Option
+
+	mu      sync.Mutex
+	handler func(EstimateEvent)
+	last    []EventDeparture
+	seen    bool
+}
+
+// NewEstimateWatcher returns an EstimateWatcher of the departures from
+// station, fetched using c. The options are passed to GetEstimates.
+func NewEstimateWatcher(c *Client, station string, opts ...Option) *EstimateWatcher {
+	return &EstimateWatcher{c: c, station: station, opts: opts}
+}
+
+// SetHandler sets the function each EstimateEvent is emitted to. It's
+// called by Poll, so a slow handler delays the next poll.
+func (w *EstimateWatcher) SetHandler(fn func(EstimateEvent)) {
+	w.mu.Lock()
+	w.handler = fn
+	w.mu.Unlock()
+}
+
+// Poll fetches the estimates using GetEstimates and emits an event to the
+// handler if the departures changed, returning whether any train is
+// delayed. It has the signature AdaptivePoller.Run expects:
+//
+//	err := bart.NewAdaptivePoller(15*time.Second, time.Minute).Run(ctx, w.Poll)
+func (w *EstimateWatcher) Poll(ctx context.Context) (bool, error) {
+	resp, err := w.c.GetEstimates(ctx, w.station, w.opts...)
+
+	if err != nil {
+		return false, err
+	}
+
+	if e, ok := w.Record(resp, w.c.now()); ok {
+		w.mu.Lock()
+		fn := w.handler
+		w.mu.Unlock()
+
+		if fn != nil {
+			fn(e)
+		}
+	}
+
+	return resp.Delayed(), nil
+}
+
+// Record records the estimates in resp as seen at now, and returns the
+// event for them if the departures changed since the last call. The first
+// call always returns an event.
+func (w *EstimateWatcher) Record(resp *EstimatesResponse, now time.Time) (EstimateEvent, bool) {
+	deps := eventDepartures(resp.Flatten())
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.seen && reflect.DeepEqual(deps, w.last) {
+		return EstimateEvent{}, false
+	}
+
+	w.last, w.seen = deps, true
+
+	return EstimateEvent{Station: w.station, Time: now, Departures: deps}, true
+}
+
+// eventDepartures converts the departures to their form in an event. The
+// list isn't nil, so it's marshaled as an empty array.
+func eventDepartures(deps []Departure) []EventDeparture {
+	events := make([]EventDeparture, 0, len(deps))
+
+	for _, d := range deps {
+		e := EventDeparture{
+			Destination:     d.Destination,
+			DestinationAbbr: d.DestinationAbbr,
+			Line:            d.Line,
+			Minutes:         int(d.Minutes),
+			Platform:        d.Platform,
+			Delay:           int(d.Delay / time.Second),
+		}
+
+		if d.Direction != DirectionUnknown {
+			e.Direction = d.Direction.String()
+		}
+
+		events = append(events, e)
+	}
+
+	return events
+}
@@ -0,0 +1,58 @@
+// Copyright 2015 Tim Heckman. All rights reserved.
+// Use of this source code is governed by the BSD 3-Clause
+// license that can be found in the LICENSE file.
+
+package bart_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/theckman/go-bart"
+	. "gopkg.in/check.v1"
+)
+
+func (t *TestSuite) TestEstimateWatcher(c *C) {
+	now := time.Date(2019, 2, 4, 10, 12, 33, 0, time.UTC)
+	t.c.SetClock(func() time.Time { return now })
+
+	w := bart.NewEstimateWatcher(t.c, "RICH")
+
+	var events []bart.EstimateEvent
+
+	w.SetHandler(func(e bart.EstimateEvent) { events = append(events, e) })
+
+	for i := 0; i < 2; i++ {
+		delayed, err := w.Poll(context.Background())
+		c.Assert(err, IsNil)
+		c.Check(delayed, Equals, false)
+	}
+
+	// the departures didn't change the second time
+	c.Assert(events, HasLen, 1)
+	c.Check(t.srv.count("etd"), Equals, 2)
+
+	e := events[0]
+	c.Check(e.Station, Equals, "RICH")
+	c.Check(e.Time, Equals, now)
+	c.Check(e.Departures, DeepEquals, []bart.EventDeparture{
+		{Destination: "Millbrae", DestinationAbbr: "MLBR", Line: "RED", Minutes: 0, Platform: 2, Direction: "South"},
+		{Destination: "Berryessa", DestinationAbbr: "BERY", Line: "ORANGE", Minutes: 4, Platform: 1, Direction: "South"},
+	})
+
+	b, err := e.SSE()
+	c.Assert(err, IsNil)
+	c.Check(string(b), Equals, "event: estimates\n"+
+		`data: {"station":"RICH","time":"2019-02-04T10:12:33Z","departures":[`+
+		`{"destination":"Millbrae","destinationAbbr":"MLBR","line":"RED","minutes":0,"platform":2,"direction":"South"},`+
+		`{"destination":"Berryessa","destinationAbbr":"BERY","line":"ORANGE","minutes":4,"platform":1,"direction":"South"}]}`+
+		"\n\n")
+
+	// no departures are an empty list rather than null
+	e, ok := w.Record(&bart.EstimatesResponse{}, now)
+	c.Assert(ok, Equals, true)
+
+	b, err = e.SSE()
+	c.Assert(err, IsNil)
+	c.Check(string(b), Equals, "event: estimates\ndata: {\"station\":\"RICH\",\"time\":\"2019-02-04T10:12:33Z\",\"departures\":[]}\n\n")
+}